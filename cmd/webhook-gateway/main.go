@@ -7,9 +7,12 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
 	// Internal packages.
 	_ "go.deuill.org/webhook-gateway/pkg/destination/xmpp"
+	"go.deuill.org/webhook-gateway/pkg/gateway"
 	"go.deuill.org/webhook-gateway/pkg/service"
 	_ "go.deuill.org/webhook-gateway/pkg/source/cloudflare-notifications"
 	_ "go.deuill.org/webhook-gateway/pkg/source/grafana"
@@ -20,8 +23,11 @@ import (
 
 // Global configuration.
 var (
-	configPath = flag.String("config", "config.toml", "Path to main configuration file, in TOML format.")
-	logLevel   = flag.String("log-level", "info", "The minimum log level to process logs under")
+	configPath  = flag.String("config", "config.toml", "Path to main configuration file, in TOML format.")
+	logLevel    = flag.String("log-level", "info", "The minimum log level to process logs under")
+	watchConfig = flag.Duration("watch-config", 0, "Interval for checking configuration file for changes, reloading on change; disabled if zero.")
+
+	shutdownTimeout = flag.Duration("shutdown-timeout", 30*time.Second, "Maximum amount of time to wait for pending requests and messages on shut-down.")
 )
 
 func logger() (*slog.Logger, error) {
@@ -30,7 +36,8 @@ func logger() (*slog.Logger, error) {
 		return nil, err
 	}
 
-	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level})), nil
+	// Attach request and trace identifiers to all logs produced while handling requests.
+	return slog.New(gateway.NewLogHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level}))), nil
 }
 
 func main() {
@@ -44,21 +51,125 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Use service-wide logger for any components not explicitly given one.
+	slog.SetDefault(log)
+
 	// Wait for and perform graceful shut-down on specific signals.
-	var ctx, _ = signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	var ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Initialize gateway server from configuration.
-	if srv, err := service.New(service.WithLogger(log)); err != nil {
+	srv, err := service.New(service.WithLogger(log))
+	if err != nil {
 		log.Error("Failed initializing service", "error", err.Error())
 		os.Exit(1)
 	} else if _, err := toml.DecodeFile(*configPath, &srv); err != nil {
 		log.Error("Failed to load TOML configuration", "error", err.Error())
 		os.Exit(1)
-	} else if err = srv.Init(ctx); err != nil {
+	}
+
+	// Run any sub-commands given instead of the main service.
+	switch cmd := flag.Arg(0); cmd {
+	case "":
+	case "replay":
+		if err := replay(ctx, srv, flag.Args()[1:]); err != nil {
+			log.Error("Failed replaying dead letters", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	default:
+		log.Error("Unknown command given", "command", cmd)
+		os.Exit(1)
+	}
+
+	// Components run until closed explicitly, allowing for in-flight requests and pending messages
+	// to be processed on shut-down.
+	if err = srv.Init(context.WithoutCancel(ctx)); err != nil {
 		log.Error("Failed to initialize service", "error", err.Error())
 		os.Exit(1)
 	}
 
 	log.Info("Waiting for incoming messages...")
-	<-ctx.Done()
+
+	// Reload configuration on SIGHUP, or on changes to the configuration file, if enabled.
+	var hup = make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var changed <-chan struct{}
+	if *watchConfig > 0 {
+		changed = watch(ctx, *configPath, *watchConfig)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdown(log, srv, stop)
+			return
+		case <-hup:
+		case <-changed:
+		}
+
+		log.Info("Reloading configuration", "path", *configPath)
+		if err := reload(context.WithoutCancel(ctx), srv); err != nil {
+			log.Error("Failed reloading configuration", "error", err.Error())
+		}
+	}
+}
+
+// Shutdown closes the running service, waiting for in-flight requests and pending messages up to
+// the configured shut-down timeout. Signals received during shut-down terminate the process.
+func shutdown(log *slog.Logger, srv *service.Service, stop context.CancelFunc) {
+	stop()
+	log.Info("Shutting down...", "timeout", shutdownTimeout.String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Close(ctx); err != nil {
+		log.Error("Failed shutting down cleanly", "error", err.Error())
+		os.Exit(1)
+	}
+}
+
+// Reload applies configuration from the configuration file to the running service.
+func reload(ctx context.Context, srv *service.Service) error {
+	var data map[string]any
+	if _, err := toml.DecodeFile(*configPath, &data); err != nil {
+		return err
+	}
+
+	return srv.Reload(ctx, data)
+}
+
+// Watch checks the file at the path given for changes in modification time or size, at the given
+// interval, and notifies the returned channel of any changes found.
+func watch(ctx context.Context, path string, interval time.Duration) <-chan struct{} {
+	var changed = make(chan struct{}, 1)
+	var last, _ = os.Stat(path)
+
+	go func() {
+		var ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || (last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+				continue
+			}
+
+			last = info
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changed
 }
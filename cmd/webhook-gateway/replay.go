@@ -0,0 +1,76 @@
+package main
+
+import (
+	// Standard library.
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/delivery"
+	"go.deuill.org/webhook-gateway/pkg/service"
+)
+
+// Replay re-submits dead letters selected by the command-line arguments given through their
+// original destinations, or lists dead letters if requested.
+func replay(ctx context.Context, srv *service.Service, args []string) error {
+	var flags = flag.NewFlagSet("replay", flag.ContinueOnError)
+	var (
+		list        = flags.Bool("list", false, "List dead letters instead of replaying them.")
+		all         = flags.Bool("all", false, "Replay all dead letters, if no other selection is given.")
+		destination = flags.String("destination", "", "Only select dead letters for the given destination name.")
+		gateway     = flags.String("gateway", "", "Only select dead letters for the given gateway path.")
+	)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [options] replay [replay-options] [id...]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var ids = flags.Args()
+	match := func(l *delivery.DeadLetter) bool {
+		if len(ids) > 0 && !slices.Contains(ids, l.ID) {
+			return false
+		} else if *destination != "" && l.Destination != *destination {
+			return false
+		} else if *gateway != "" && l.Gateway != *gateway {
+			return false
+		}
+		return true
+	}
+
+	if *list {
+		letters, err := srv.DeadLetters()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tDESTINATION\tGATEWAY\tFAILED AT\tATTEMPTS\tERROR")
+		for _, l := range letters {
+			if match(l) {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", l.ID, l.Destination, l.Gateway,
+					l.FailedAt.Format(time.RFC3339), l.Attempts, l.LastError)
+			}
+		}
+
+		return w.Flush()
+	}
+
+	if len(ids) == 0 && *destination == "" && *gateway == "" && !*all {
+		return fmt.Errorf("no dead letters selected for replay, use '-all' to replay all dead letters")
+	}
+
+	count, err := srv.Replay(ctx, match)
+	fmt.Fprintf(os.Stdout, "Replayed %d dead letter(s)\n", count)
+
+	return err
+}
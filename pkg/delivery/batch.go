@@ -0,0 +1,158 @@
+package delivery
+
+import (
+	// Standard library.
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+// Default options for [Batcher] instances.
+const (
+	defaultBatchWindow = time.Minute
+	defaultBatchSize   = 50
+)
+
+// The default template used for rendering digest messages.
+const defaultBatchTemplate = `{{len .Messages}} notifications received:{{range .Messages}}
+- {{if .Title}}{{.Title}}{{else}}{{.Content}}{{end}}{{end}}`
+
+// A Digest represents a batch of messages, as provided to templates used for rendering digest
+// messages.
+type Digest struct {
+	Messages []*gateway.Message // The messages collected in the batch, in the order received.
+	Firing   int                // The number of messages with a 'firing' status.
+	Resolved int                // The number of messages with a 'resolved' status.
+}
+
+// A Batcher determines how messages are collected into batches by a [Queue], for a window of time,
+// or until a maximum number of messages has been collected, and how a single digest message,
+// rendered from a template, is pushed in their place. Batches containing only a single message have
+// that message pushed as-is.
+//
+// Messages are spooled by the queue as these are pushed, and are collected into batches when due
+// for delivery, so that batches pending delivery survive restarts, and are retried on failure.
+type Batcher struct {
+	window   time.Duration
+	size     int
+	template *template.Template
+}
+
+// NewBatcher instantiates a [Batcher] with the options given.
+func NewBatcher(options ...BatchOption) (*Batcher, error) {
+	var b = Batcher{
+		window:   defaultBatchWindow,
+		size:     defaultBatchSize,
+		template: template.Must(template.New("digest").Parse(defaultBatchTemplate)),
+	}
+
+	for _, fn := range options {
+		if err := fn(&b); err != nil {
+			return nil, err
+		}
+	}
+
+	return &b, nil
+}
+
+// A BatchOption represents any configuration provided to new instances of [Batcher] types.
+type BatchOption func(*Batcher) error
+
+// WithBatchWindow sets the amount of time messages are collected for, starting from the first
+// message pushed to a [Queue] with no batch pending.
+func WithBatchWindow(d time.Duration) BatchOption {
+	return func(b *Batcher) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid batch window '%s'", d)
+		}
+		b.window = d
+		return nil
+	}
+}
+
+// WithBatchSize sets the maximum number of messages collected in a batch; batches are pushed as
+// soon as this number is reached, regardless of the batch window.
+func WithBatchSize(size int) BatchOption {
+	return func(b *Batcher) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid batch size '%d'", size)
+		}
+		b.size = size
+		return nil
+	}
+}
+
+// WithBatchTemplate sets the template used for rendering digest messages, as parsed according to
+// rules defined in [text/template], with a [Digest] provided as data.
+func WithBatchTemplate(t string) BatchOption {
+	return func(b *Batcher) error {
+		tpl, err := template.New("digest").Parse(t)
+		if err != nil {
+			return fmt.Errorf("failed parsing digest template: %w", err)
+		}
+		b.template = tpl
+		return nil
+	}
+}
+
+// Digest returns a single [gateway.Message] for the given messages, with content rendered from the
+// configured template, and other fields set as per [gateway.Summarize].
+func (b *Batcher) digest(messages []*gateway.Message) (*gateway.Message, error) {
+	var data = Digest{Messages: messages}
+	for _, m := range messages {
+		switch m.Status {
+		case gateway.StatusFiring:
+			data.Firing++
+		case gateway.StatusResolved:
+			data.Resolved++
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := b.template.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed rendering digest template: %w", err)
+	}
+
+	var msg = gateway.Summarize(messages)
+	msg.Title = fmt.Sprintf("%d notifications", len(messages))
+	msg.Content = strings.TrimSpace(buf.String())
+	msg.Body = msg.Content
+
+	return msg, nil
+}
+
+// UnmarshalTOML configures the [Batcher] based on values sourced from TOML configuration.
+func (b *Batcher) UnmarshalTOML(data any) error {
+	conf, ok := data.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var options []BatchOption
+	if v, ok := conf["window"].(string); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed parsing batch window: %w", err)
+		}
+		options = append(options, WithBatchWindow(d))
+	}
+	if v, ok := conf["max-messages"].(int64); ok {
+		options = append(options, WithBatchSize(int(v)))
+	}
+	if v, ok := conf["template"].(string); ok && v != "" {
+		options = append(options, WithBatchTemplate(v))
+	}
+
+	for _, fn := range options {
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,291 @@
+package delivery
+
+import (
+	// Standard library.
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+var testTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestBatcherSize(t *testing.T) {
+	var testCases = []struct {
+		descr    string
+		template string
+		messages []*gateway.Message
+		want     []*gateway.Message
+	}{
+		{
+			descr:    "single message",
+			messages: []*gateway.Message{{Content: "Hello"}},
+			want:     []*gateway.Message{{Content: "Hello"}},
+		},
+		{
+			descr: "default template",
+			messages: []*gateway.Message{
+				{Title: "Disk full", Content: "Disk full on host-1", Status: gateway.StatusFiring, Source: "grafana", Labels: map[string]string{"env": "prod", "host": "host-1"}, Timestamp: testTime.Add(time.Minute)},
+				{Content: "CPU high on host-2", Status: gateway.StatusResolved, Source: "grafana", Labels: map[string]string{"env": "prod", "host": "host-2"}, Timestamp: testTime},
+			},
+			want: []*gateway.Message{{
+				Content:   "2 notifications received:\n- Disk full\n- CPU high on host-2",
+				Title:     "2 notifications",
+				Body:      "2 notifications received:\n- Disk full\n- CPU high on host-2",
+				Status:    gateway.StatusFiring,
+				Source:    "grafana",
+				Labels:    map[string]string{"env": "prod"},
+				Timestamp: testTime,
+			}},
+		},
+		{
+			descr:    "custom template",
+			template: `{{.Firing}} firing, {{.Resolved}} resolved`,
+			messages: []*gateway.Message{
+				{Content: "A", Status: gateway.StatusResolved, Severity: "critical", Timestamp: testTime},
+				{Content: "B", Status: gateway.StatusResolved, Severity: "warning", Timestamp: testTime},
+			},
+			want: []*gateway.Message{{
+				Content:   "0 firing, 2 resolved",
+				Title:     "2 notifications",
+				Body:      "0 firing, 2 resolved",
+				Status:    gateway.StatusResolved,
+				Timestamp: testTime,
+			}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var options = []BatchOption{WithBatchWindow(time.Hour), WithBatchSize(len(tt.messages))}
+			if tt.template != "" {
+				options = append(options, WithBatchTemplate(tt.template))
+			}
+
+			b, err := NewBatcher(options...)
+			if err != nil {
+				t.Fatalf("NewBatcher(): want error 'nil', have '%s'", err)
+			}
+
+			d := &testDestination{wait: 1, done: make(chan struct{})}
+			q, err := New("test", d, WithBatcher(b), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			for _, msg := range tt.messages {
+				if err := q.PushMessages(context.Background(), msg); err != nil {
+					t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if err := q.Init(ctx); err != nil {
+				t.Fatalf("Queue.Init(): want error 'nil', have '%s'", err)
+			}
+
+			select {
+			case <-d.done:
+			case <-time.After(time.Second):
+				t.Fatalf("Queue.PushMessages(): timed out waiting for digest")
+			}
+
+			d.mu.Lock()
+			defer d.mu.Unlock()
+
+			if !reflect.DeepEqual(tt.want, d.messages) {
+				t.Fatalf("Queue.PushMessages(): want messages '%#v', have '%#v'", tt.want, d.messages)
+			}
+		})
+	}
+}
+
+func TestBatcherWindow(t *testing.T) {
+	d := &testDestination{wait: 1, done: make(chan struct{})}
+	q, err := New("test", d, WithBatcher(must(NewBatcher(WithBatchWindow(10*time.Millisecond)))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	ctx, cancel := context.WithCancel(gateway.SetPath(context.Background(), "/test"))
+	defer cancel()
+
+	if err := q.Init(ctx); err != nil {
+		t.Fatalf("Queue.Init(): want error 'nil', have '%s'", err)
+	}
+
+	for _, content := range []string{"A", "B", "C"} {
+		if err := q.PushMessages(ctx, &gateway.Message{Content: content}); err != nil {
+			t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	select {
+	case <-d.done:
+	case <-time.After(time.Second):
+		t.Fatalf("Queue.PushMessages(): timed out waiting for digest")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.attempts != 1 {
+		t.Fatalf("Queue.PushMessages(): want 1 attempt, have %d", d.attempts)
+	} else if want := "3 notifications received:\n- A\n- B\n- C"; d.messages[0].Content != want {
+		t.Fatalf("Queue.PushMessages(): want content '%s', have '%s'", want, d.messages[0].Content)
+	}
+}
+
+func TestBatcherProvenance(t *testing.T) {
+	var history = gateway.NewHistory(0, 0)
+	for _, id := range []string{"one", "two", "three"} {
+		history.Add(&gateway.HistoryEntry{ID: id, Gateway: "/" + id})
+	}
+
+	d := &testDestination{wait: 2, done: make(chan struct{})}
+	q, err := New("test", d, WithBatcher(must(NewBatcher(WithBatchWindow(10*time.Millisecond)))), WithHistory(history))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := q.Init(ctx); err != nil {
+		t.Fatalf("Queue.Init(): want error 'nil', have '%s'", err)
+	}
+
+	// Messages pushed from different gateways are collected into separate batches.
+	for _, p := range []struct{ id, path, content string }{{"one", "/a", "A"}, {"two", "/b", "B"}, {"three", "/a", "C"}} {
+		pushCtx := gateway.SetRequestID(gateway.SetPath(ctx, p.path), p.id)
+		if err := q.PushMessages(pushCtx, &gateway.Message{Content: p.content}); err != nil {
+			t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	select {
+	case <-d.done:
+	case <-time.After(time.Second):
+		t.Fatalf("Queue.PushMessages(): timed out waiting for digests")
+	}
+
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatalf("Queue.Close(): want error 'nil', have '%s'", err)
+	}
+
+	d.mu.Lock()
+	var contents []string
+	for _, m := range d.messages {
+		contents = append(contents, m.Content)
+	}
+	d.mu.Unlock()
+
+	slices.Sort(contents)
+	if want := []string{"2 notifications received:\n- A\n- C", "B"}; !slices.Equal(contents, want) {
+		t.Fatalf("Queue.PushMessages(): want messages '%q', have '%q'", want, contents)
+	}
+
+	// Delivery outcomes are recorded for all requests messages were collected from.
+	for _, id := range []string{"one", "two", "three"} {
+		e, ok := history.Get(id)
+		if !ok || len(e.Deliveries) != 1 || e.Deliveries[0].Status != gateway.DeliveryDelivered {
+			t.Fatalf("History.Get(%s): want delivered outcome, have '%v'", id, e)
+		}
+	}
+}
+
+func TestBatcherRetry(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "spool.log")
+	var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	var batcher = must(NewBatcher(WithBatchWindow(10 * time.Millisecond)))
+
+	// Batches pending are spooled, and are loaded back on subsequent runs.
+	prev, err := New("test", &testDestination{}, WithBatcher(batcher), WithSpool(NewSpool(path)), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	for _, content := range []string{"A", "B"} {
+		if err := prev.PushMessages(context.Background(), &gateway.Message{Content: content}); err != nil {
+			t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	if err := prev.spool.Close(); err != nil {
+		t.Fatalf("Spool.Close(): want error 'nil', have '%s'", err)
+	}
+
+	// Failed digests are retried as a whole.
+	d := &testDestination{failures: 1, wait: 2, done: make(chan struct{})}
+	q, err := New("test", d, WithBatcher(batcher), WithSpool(NewSpool(path)), WithBackoff(time.Millisecond, time.Millisecond), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := q.Init(ctx); err != nil {
+		t.Fatalf("Queue.Init(): want error 'nil', have '%s'", err)
+	}
+
+	select {
+	case <-d.done:
+	case <-time.After(time.Second):
+		t.Fatalf("Queue.Init(): timed out waiting for digest to be retried")
+	}
+
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatalf("Queue.Close(): want error 'nil', have '%s'", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if want := "2 notifications received:\n- A\n- B"; len(d.messages) != 1 || d.messages[0].Content != want {
+		t.Fatalf("Queue.Init(): want digest with content '%s', have '%v'", want, d.messages)
+	} else if entries, err := NewSpool(path).Load(); err != nil || len(entries) != 0 {
+		t.Fatalf("Spool.Load(): want no pending entries, have '%v' (error '%v')", entries, err)
+	}
+}
+
+func TestBatcherFlushOnClose(t *testing.T) {
+	d := &testDestination{wait: 1, done: make(chan struct{})}
+	q, err := New("test", d, WithBatcher(must(NewBatcher(WithBatchWindow(time.Hour)))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := q.Init(ctx); err != nil {
+		t.Fatalf("Queue.Init(): want error 'nil', have '%s'", err)
+	} else if err := q.PushMessages(ctx, &gateway.Message{Content: "Hello"}); err != nil {
+		t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+	} else if err := q.Close(context.Background()); err != nil {
+		t.Fatalf("Queue.Close(): want error 'nil', have '%s'", err)
+	}
+
+	select {
+	case <-d.done:
+	default:
+		t.Fatalf("Queue.Close(): want pending batch pushed, have no messages pushed")
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
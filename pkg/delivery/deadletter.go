@@ -0,0 +1,75 @@
+package delivery
+
+import (
+	// Standard library.
+	"fmt"
+	"time"
+)
+
+// A DeadLetter represents a queue [Entry] that failed delivery after exhausting all attempts, along
+// with the name of the destination it was destined for, and the time it was dropped from the queue.
+type DeadLetter struct {
+	Entry
+	Destination string    `json:"destination"`
+	FailedAt    time.Time `json:"failed_at"`
+}
+
+// JournalID returns the unique ID for the [DeadLetter], as used in journal files.
+func (d *DeadLetter) journalID() string {
+	if d == nil {
+		return ""
+	}
+	return d.ID
+}
+
+// DeadLetters represents a file-backed store for [DeadLetter] values. Stores can be safely accessed
+// by multiple processes at once, e.g. for replaying dead letters while the service is running.
+type DeadLetters struct {
+	journal journal[*DeadLetter]
+}
+
+// NewDeadLetters returns a [DeadLetters] store for the file path given. The file, and any parent
+// directories, will be created on the first call to [DeadLetters.Add].
+func NewDeadLetters(path string) *DeadLetters {
+	return &DeadLetters{journal: journal[*DeadLetter]{path: path, shared: true}}
+}
+
+// Add stores the given [DeadLetter].
+func (d *DeadLetters) Add(l *DeadLetter) error {
+	if err := d.journal.write(journalRecord[*DeadLetter]{Op: journalAdd, Value: l}); err != nil {
+		return fmt.Errorf("failed adding dead letter: %w", err)
+	}
+	return nil
+}
+
+// List returns all dead letters currently stored, in the order they were added.
+func (d *DeadLetters) List() ([]*DeadLetter, error) {
+	letters, err := d.journal.read()
+	if err != nil {
+		return nil, fmt.Errorf("failed listing dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+// Remove removes the dead letter for the ID given from the store.
+func (d *DeadLetters) Remove(id string) error {
+	if err := d.journal.write(journalRecord[*DeadLetter]{Op: journalRemove, ID: id}); err != nil {
+		return fmt.Errorf("failed removing dead letter: %w", err)
+	}
+	return nil
+}
+
+// Compact rewrites the store file to only contain dead letters currently stored, e.g. after dead
+// letters have been removed on replay. Other processes using the store re-open the store file on
+// subsequent writes.
+func (d *DeadLetters) Compact() error {
+	if _, err := d.journal.compact(); err != nil {
+		return fmt.Errorf("failed compacting dead letters: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying store file.
+func (d *DeadLetters) Close() error {
+	return d.journal.close()
+}
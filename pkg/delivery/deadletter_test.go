@@ -0,0 +1,58 @@
+package delivery
+
+import (
+	// Standard library.
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDeadLettersCompact(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "dead-letters.log")
+
+	// Stores opened separately stand in for the running service and a replaying process.
+	service, replay := NewDeadLetters(path), NewDeadLetters(path)
+	defer service.Close()
+	defer replay.Close()
+
+	for _, id := range []string{"1", "2"} {
+		if err := service.Add(&DeadLetter{Entry: Entry{ID: id}, Destination: "test"}); err != nil {
+			t.Fatalf("DeadLetters.Add(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("os.Stat(): want error 'nil', have '%s'", err)
+	} else if err := replay.Remove("1"); err != nil {
+		t.Fatalf("DeadLetters.Remove(): want error 'nil', have '%s'", err)
+	} else if err := replay.Compact(); err != nil {
+		t.Fatalf("DeadLetters.Compact(): want error 'nil', have '%s'", err)
+	}
+
+	if after, err := os.Stat(path); err != nil {
+		t.Fatalf("os.Stat(): want error 'nil', have '%s'", err)
+	} else if after.Size() >= before.Size() {
+		t.Fatalf("DeadLetters.Compact(): want smaller store file, have size %d (was %d)", after.Size(), before.Size())
+	}
+
+	// Dead letters added after compaction are written to the compacted store file.
+	if err := service.Add(&DeadLetter{Entry: Entry{ID: "3"}, Destination: "test"}); err != nil {
+		t.Fatalf("DeadLetters.Add(): want error 'nil', have '%s'", err)
+	}
+
+	letters, err := replay.List()
+	if err != nil {
+		t.Fatalf("DeadLetters.List(): want error 'nil', have '%s'", err)
+	}
+
+	var ids []string
+	for _, l := range letters {
+		ids = append(ids, l.ID)
+	}
+
+	if !slices.Equal(ids, []string{"2", "3"}) {
+		t.Fatalf("DeadLetters.List(): want dead letters '[2 3]', have '%v'", ids)
+	}
+}
@@ -0,0 +1,250 @@
+package delivery
+
+import (
+	// Standard library.
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Operations recorded in journal files.
+const (
+	journalAdd    = "add"
+	journalUpdate = "update"
+	journalRemove = "remove"
+)
+
+// A JournalValue is any value that can be stored in a [journal], as identified by a unique ID. Empty
+// IDs, including ones returned for nil values, are taken to denote invalid values.
+type journalValue interface {
+	journalID() string
+}
+
+// A JournalRecord represents a single operation recorded in a [journal] file.
+type journalRecord[T journalValue] struct {
+	Op    string `json:"op"`
+	ID    string `json:"id,omitempty"`
+	Value T      `json:"entry,omitempty"`
+}
+
+// A Journal represents a file-backed, append-only log of operations on values, where the current
+// set of values is determined by replaying all operations in order. Shared journals are safe for use
+// by multiple processes, with writes and compactions coordinated via advisory locks on a separate
+// lock file, and with writers re-opening journal files replaced by compactions.
+type journal[T journalValue] struct {
+	path   string
+	shared bool // Whether the journal is shared between processes.
+
+	// Internal fields.
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Read returns all values currently stored in the journal, in the order they were added. Malformed
+// records, such as ones left partially written by a crash, are skipped.
+func (j *journal[T]) read() ([]T, error) {
+	f, err := os.Open(j.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed opening file: %w", err)
+	}
+
+	defer f.Close()
+
+	var order []string
+	var current = make(map[string]T)
+
+	var scanner = bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var r journalRecord[T]
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+
+		switch r.Op {
+		case journalAdd:
+			if r.Value.journalID() == "" {
+				continue
+			} else if _, ok := current[r.Value.journalID()]; !ok {
+				order = append(order, r.Value.journalID())
+			}
+			current[r.Value.journalID()] = r.Value
+		case journalUpdate:
+			if _, ok := current[r.Value.journalID()]; ok {
+				current[r.Value.journalID()] = r.Value
+			}
+		case journalRemove:
+			delete(current, r.ID)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+
+	var values []T
+	for _, id := range order {
+		if v, ok := current[id]; ok {
+			values = append(values, v)
+			delete(current, id)
+		}
+	}
+
+	return values, nil
+}
+
+// Compact rewrites the journal to only contain records for values currently stored, returning
+// these values. Any open file handle is closed, and will be re-opened on subsequent writes.
+func (j *journal[T]) compact() ([]T, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.closeFile(); err != nil {
+		return nil, err
+	} else if err := os.MkdirAll(filepath.Dir(j.path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed creating directory: %w", err)
+	}
+
+	unlock, err := j.lock(true)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	values, err := j.read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Write current values to a temporary file, and move it in place of the existing journal file.
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed creating file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+	for _, v := range values {
+		if err := writeJournalRecord(tmp, journalRecord[T]{Op: journalAdd, Value: v}); err != nil {
+			tmp.Close()
+			return nil, err
+		}
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed writing file: %w", err)
+	} else if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed writing file: %w", err)
+	} else if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return nil, fmt.Errorf("failed replacing file: %w", err)
+	}
+
+	return values, nil
+}
+
+// Write appends the given record to the journal file, ensuring it is persisted before returning.
+// The journal file, and any parent directories, are created if they don't already exist.
+func (j *journal[T]) write(r journalRecord[T]) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o750); err != nil {
+		return fmt.Errorf("failed creating directory: %w", err)
+	}
+
+	unlock, err := j.lock(false)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	// Re-open journal files replaced by compactions in other processes.
+	if j.shared && j.file != nil {
+		cur, err := j.file.Stat()
+		if err != nil {
+			return fmt.Errorf("failed reading file: %w", err)
+		} else if info, err := os.Stat(j.path); err != nil || !os.SameFile(cur, info) {
+			if err := j.closeFile(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if j.file == nil {
+		f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed opening file: %w", err)
+		}
+
+		j.file = f
+	}
+
+	if err := writeJournalRecord(j.file, r); err != nil {
+		return err
+	} else if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed writing file: %w", err)
+	}
+
+	return nil
+}
+
+// Lock places an advisory lock on the lock file for shared journals, either exclusive for
+// compactions, or shared for writes, and returns a function releasing the lock. Journals not shared
+// between processes are not locked.
+func (j *journal[T]) lock(exclusive bool) (func(), error) {
+	if !j.shared {
+		return func() {}, nil
+	}
+
+	f, err := os.OpenFile(j.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening lock file: %w", err)
+	} else if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed locking file: %w", err)
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// Close closes any open file handle for the journal.
+func (j *journal[T]) close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.closeFile()
+}
+
+// CloseFile closes any open file handle for the journal. The journal lock is expected to be held.
+func (j *journal[T]) closeFile() error {
+	if j.file == nil {
+		return nil
+	}
+
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// WriteJournalRecord encodes the given record to the file given as a single line of JSON.
+func writeJournalRecord[T journalValue](f *os.File, r journalRecord[T]) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed encoding record: %w", err)
+	} else if _, err = f.Write(append(buf, '\n')); err != nil {
+		return fmt.Errorf("failed writing file: %w", err)
+	}
+
+	return nil
+}
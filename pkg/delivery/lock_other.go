@@ -0,0 +1,19 @@
+//go:build !unix
+
+package delivery
+
+import (
+	// Standard library.
+	"os"
+)
+
+// LockFile is a no-op on platforms without support for advisory file locks, where journals shared
+// between processes are not to be compacted while in use.
+func lockFile(*os.File, bool) error {
+	return nil
+}
+
+// UnlockFile is a no-op on platforms without support for advisory file locks.
+func unlockFile(*os.File) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package delivery
+
+import (
+	// Standard library.
+	"os"
+	"syscall"
+)
+
+// LockFile places an advisory lock on the given file, blocking until the lock is acquired. Locks
+// are either exclusive, or shared with any other holders of shared locks.
+func lockFile(f *os.File, exclusive bool) error {
+	var how = syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	return syscall.Flock(int(f.Fd()), how)
+}
+
+// UnlockFile releases any advisory lock placed on the given file.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,913 @@
+// Package delivery contains types used for asynchronous delivery of [gateway.Message] values to
+// [gateway.Destination] instances.
+package delivery
+
+import (
+	// Standard library.
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+	"go.deuill.org/webhook-gateway/pkg/metrics"
+	"go.deuill.org/webhook-gateway/pkg/trace"
+)
+
+// Default options for [Queue] instances.
+const (
+	defaultQueueSize   = 1000
+	defaultMaxAttempts = 10
+	defaultMaxAge      = 24 * time.Hour
+	defaultBackoff     = time.Second
+	defaultMaxBackoff  = 5 * time.Minute
+	defaultTimeout     = 30 * time.Second
+)
+
+// An Entry represents a set of messages queued for delivery, alongside any metadata related to
+// delivery attempts made.
+type Entry struct {
+	ID          string             `json:"id"`
+	Gateway     string             `json:"gateway,omitempty"`
+	RequestID   string             `json:"request_id,omitempty"`
+	TraceParent string             `json:"trace_parent,omitempty"`
+	Target      string             `json:"target,omitempty"` // The destination name known to the gateway.
+	Messages    []*gateway.Message `json:"messages"`
+	CreatedAt   time.Time          `json:"created_at"`
+	Attempts    int                `json:"attempts"`
+	NextAttempt time.Time          `json:"next_attempt"`
+	LastError   string             `json:"last_error,omitempty"`
+	Batch       []*Entry           `json:"batch,omitempty"` // The entries collected into a digest, if any.
+}
+
+// Unbatched returns whether the [Entry] is pending collection into a batch, for queues configured
+// with a [Batcher], i.e. has not been collected into a batch, and has not been attempted yet.
+func (e *Entry) unbatched() bool {
+	return e.Attempts == 0 && e.Batch == nil
+}
+
+// Origins returns the entries the [Entry] was collected from, if pushed as a digest, or the entry
+// itself otherwise, each carrying the request ID and trace context the messages were pushed under.
+func (e *Entry) origins() []*Entry {
+	if e.Batch != nil {
+		return e.Batch
+	}
+	return []*Entry{e}
+}
+
+// Context returns a copy of the given context carrying the request ID and trace context the [Entry]
+// was pushed under, for use in logging and tracing against the entry.
+func (e *Entry) context(ctx context.Context) context.Context {
+	ctx = gateway.SetRequestID(ctx, e.RequestID)
+	if sc, err := trace.ParseTraceParent(e.TraceParent); err == nil {
+		ctx = trace.ContextWithRemote(ctx, sc)
+	}
+	return ctx
+}
+
+// JournalID returns the unique ID for the [Entry], as used in journal files.
+func (e *Entry) journalID() string {
+	if e == nil {
+		return ""
+	}
+	return e.ID
+}
+
+// A Queue wraps a [gateway.Destination], accepting messages immediately and delivering them in the
+// background. Failed deliveries are retried with exponential backoff, until either the maximum
+// number of attempts has been made, or the maximum age for queued messages has been exceeded.
+type Queue struct {
+	// Configuration options.
+	name        string
+	destination gateway.Destination
+	size        int
+	maxAttempts int
+	maxAge      time.Duration
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	timeout     time.Duration
+	spool       *Spool
+	deadLetters *DeadLetters
+	rateLimit   *gateway.RateLimiter
+	batcher     *Batcher
+	history     *gateway.History
+
+	// Internal fields.
+	mu        sync.Mutex
+	entries   []*Entry
+	notify    chan struct{}
+	stop      context.CancelFunc // Stops background processing, as started on [Queue.Init].
+	done      chan struct{}      // Closed once background processing has stopped.
+	closing   chan struct{}      // Closed once the queue is being closed.
+	closeOnce sync.Once          // Ensures the closing channel is only closed once.
+	successor *Queue             // The queue adopting this queue, if any.
+	retired   bool               // Whether the queue has been retired, and rejects further messages.
+	handover  bool               // Whether the spool has been handed over to another queue.
+	logger    *slog.Logger
+
+	// Delivery metrics.
+	delivered atomic.Uint64
+	retried   atomic.Uint64
+	dropped   atomic.Uint64
+	latency   metrics.Histogram
+	activity  gateway.ActivityTracker
+}
+
+// New instantiates a [Queue] for the given [gateway.Destination], identified by name for reporting
+// purposes, and with the options given.
+func New(name string, dest gateway.Destination, options ...Option) (*Queue, error) {
+	var q = Queue{
+		name:        name,
+		destination: dest,
+		size:        defaultQueueSize,
+		maxAttempts: defaultMaxAttempts,
+		maxAge:      defaultMaxAge,
+		backoff:     defaultBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		timeout:     defaultTimeout,
+		notify:      make(chan struct{}, 1),
+		closing:     make(chan struct{}),
+		logger:      slog.Default(),
+	}
+
+	for _, fn := range options {
+		if err := fn(&q); err != nil {
+			return nil, err
+		}
+	}
+
+	return &q, nil
+}
+
+// A Option represents any configuration provided to new instances of [Queue] types.
+type Option func(*Queue) error
+
+// WithSize sets the maximum number of entries held in the [Queue] at any one time. Attempting to
+// push messages to a full queue will return an error.
+func WithSize(size int) Option {
+	return func(q *Queue) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid queue size '%d'", size)
+		}
+		q.size = size
+		return nil
+	}
+}
+
+// WithMaxAttempts sets the maximum number of delivery attempts made for any queued entry, before it
+// is dropped from the [Queue].
+func WithMaxAttempts(n int) Option {
+	return func(q *Queue) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid number of maximum attempts '%d'", n)
+		}
+		q.maxAttempts = n
+		return nil
+	}
+}
+
+// WithMaxAge sets the maximum age of any queued entry, past which no further delivery attempts will
+// be made, and the entry is dropped from the [Queue].
+func WithMaxAge(d time.Duration) Option {
+	return func(q *Queue) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid maximum age '%s'", d)
+		}
+		q.maxAge = d
+		return nil
+	}
+}
+
+// WithBackoff sets the initial and maximum delay between delivery attempts. The delay is doubled for
+// every failed attempt, up to the maximum given.
+func WithBackoff(initial, maximum time.Duration) Option {
+	return func(q *Queue) error {
+		if initial <= 0 || maximum < initial {
+			return fmt.Errorf("invalid backoff range '%s' to '%s'", initial, maximum)
+		}
+		q.backoff, q.maxBackoff = initial, maximum
+		return nil
+	}
+}
+
+// WithTimeout sets the maximum amount of time allowed for any single delivery attempt.
+func WithTimeout(d time.Duration) Option {
+	return func(q *Queue) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid timeout '%s'", d)
+		}
+		q.timeout = d
+		return nil
+	}
+}
+
+// WithSpool sets the given [Spool] as durable storage for entries pending delivery. Entries are
+// recorded in the spool before being accepted into the [Queue], and any entries left pending from
+// previous runs are loaded back into the queue on [Queue.Init].
+func WithSpool(s *Spool) Option {
+	return func(q *Queue) error {
+		q.spool = s
+		return nil
+	}
+}
+
+// WithDeadLetters sets the given [DeadLetters] store as the destination for entries dropped from
+// the [Queue] after exhausting all delivery attempts.
+func WithDeadLetters(d *DeadLetters) Option {
+	return func(q *Queue) error {
+		q.deadLetters = d
+		return nil
+	}
+}
+
+// WithRateLimit sets the [gateway.RateLimit] applied to outgoing messages for the [Queue]. Entries
+// are held in the queue until enough tokens are available for all of their messages, and are not
+// dropped for exceeding the rate limit.
+func WithRateLimit(limit gateway.RateLimit) Option {
+	return func(q *Queue) error {
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			return fmt.Errorf("invalid rate limit of %g per second with burst of %d", limit.Rate, limit.Burst)
+		}
+		q.rateLimit = gateway.NewRateLimiter(limit)
+		return nil
+	}
+}
+
+// WithBatcher sets the [Batcher] used for collecting messages into batches, pushing a single digest
+// message in place of each batch. Entries are spooled as pushed, and are collected into a single
+// entry once the batch is due, which is retried and stored as a dead letter as a whole.
+func WithBatcher(b *Batcher) Option {
+	return func(q *Queue) error {
+		q.batcher = b
+		return nil
+	}
+}
+
+// WithHistory sets the [gateway.History] store delivery outcomes are reported to for entries pushed
+// while handling requests recorded in the store.
+func WithHistory(h *gateway.History) Option {
+	return func(q *Queue) error {
+		q.history = h
+		return nil
+	}
+}
+
+// WithLogger sets the given [slog.Logger] as the log handler for the [Queue].
+func WithLogger(l *slog.Logger) Option {
+	return func(q *Queue) error {
+		q.logger = l
+		return nil
+	}
+}
+
+// PushMessages adds the given messages to the [Queue] as a single entry, returning immediately. An
+// error is returned only if the queue is full, or if the entry could not be recorded in the spool,
+// where one is configured. Messages pushed to queues adopted by other queues are forwarded to these.
+func (q *Queue) PushMessages(ctx context.Context, messages ...*gateway.Message) error {
+	var now = time.Now()
+	var e = &Entry{
+		ID:          newID(),
+		Gateway:     gateway.GetPath(ctx),
+		RequestID:   gateway.GetRequestID(ctx),
+		TraceParent: trace.SpanContextFromContext(ctx).TraceParent(),
+		Target:      gateway.GetDestination(ctx),
+		Messages:    messages,
+		CreatedAt:   now,
+		NextAttempt: now,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.successor != nil {
+		return q.successor.PushMessages(ctx, messages...)
+	} else if q.retired {
+		return fmt.Errorf("delivery queue is closed")
+	} else if len(q.entries) >= q.size {
+		return fmt.Errorf("delivery queue is full")
+	}
+
+	if q.batcher != nil {
+		q.schedule(e, now)
+	}
+
+	if q.spool != nil {
+		if err := q.spool.Add(e); err != nil {
+			return err
+		}
+	}
+
+	q.entries = append(q.entries, e)
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Init initializes the underlying [gateway.Destination], and starts processing queued entries in
+// the background, until the given [context.Context] is cancelled. Any entries left pending in the
+// spool, where one is configured, will be added to the queue ahead of processing.
+func (q *Queue) Init(ctx context.Context) error {
+	if err := q.destination.Init(ctx); err != nil {
+		return err
+	}
+
+	if q.spool != nil {
+		entries, err := q.spool.Load()
+		if err != nil {
+			return fmt.Errorf("failed loading spooled entries: %w", err)
+		} else if len(entries) > 0 {
+			q.logger.Info("Loaded pending entries from spool", "destination", q.name, "count", len(entries))
+		}
+
+		q.mu.Lock()
+		q.entries = append(entries, q.entries...)
+		q.mu.Unlock()
+	}
+
+	q.mu.Lock()
+	ctx, q.stop = context.WithCancel(ctx)
+	q.done = make(chan struct{})
+	q.mu.Unlock()
+
+	go func(done chan struct{}) {
+		defer close(done)
+		q.run(ctx)
+	}(q.done)
+
+	return nil
+}
+
+// Adopt stops background processing for the [Queue] given, and moves all entries pending in it to
+// this queue. Entries are recorded in the spool for this queue and removed from the spool for the
+// given queue, where these differ. Any messages pushed to the given queue after adoption are
+// forwarded to this queue, allowing for queues to be replaced (e.g. on configuration reloads)
+// without losing any messages. Queues are expected to deliver to the same destination, as entries
+// are otherwise delivered to recipients they were never intended for.
+func (q *Queue) Adopt(from *Queue) {
+	from.halt()
+
+	from.mu.Lock()
+	entries, prevSpool := from.entries, from.spool
+	from.entries, from.successor = nil, q
+	from.mu.Unlock()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.spool != nil && q.spool != prevSpool {
+		for _, e := range entries {
+			ctx := e.context(context.Background())
+			if err := q.spool.Add(e); err != nil {
+				q.logger.ErrorContext(ctx, "Failed adding adopted entry to spool", "destination", q.name, "id", e.ID,
+					"error", err.Error())
+			} else if prevSpool != nil {
+				if err := prevSpool.Remove(e.ID); err != nil {
+					q.logger.ErrorContext(ctx, "Failed removing adopted entry from spool", "destination", from.name,
+						"id", e.ID, "error", err.Error())
+				}
+			}
+		}
+	}
+
+	q.entries = append(entries, q.entries...)
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// TakeSpool sets the spool for the [Queue] given as the spool for this queue, which is expected to
+// have none set. Spools are named after queues, and are handed over between queues of the same
+// name (e.g. on configuration reloads) regardless of whether entries are adopted; the spool is left
+// open when the given queue is closed.
+func (q *Queue) TakeSpool(from *Queue) {
+	from.mu.Lock()
+	spool := from.spool
+	from.handover = spool != nil
+	from.mu.Unlock()
+
+	q.mu.Lock()
+	q.spool = spool
+	q.mu.Unlock()
+}
+
+// Retire stops background processing for the [Queue], and moves any entries pending in it to dead
+// letters, where configured, removing these from the spool. Queues are retired where replaced by
+// queues delivering to different destinations (e.g. on configuration reloads), as entries would
+// otherwise be delivered to recipients they were never intended for. Any messages pushed to the
+// queue after being retired are rejected.
+func (q *Queue) Retire() {
+	q.halt()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.retired = true
+	if q.deadLetters == nil || len(q.entries) == 0 {
+		return
+	}
+
+	var now = time.Now()
+	for _, e := range q.entries {
+		ctx := e.context(context.Background())
+		if err := q.deadLetters.Add(&DeadLetter{Entry: *e, Destination: q.name, FailedAt: now}); err != nil {
+			q.logger.ErrorContext(ctx, "Failed storing dead letter", "destination", q.name, "id", e.ID, "error", err.Error())
+			continue
+		} else if q.spool != nil {
+			if err := q.spool.Remove(e.ID); err != nil {
+				q.logger.ErrorContext(ctx, "Failed removing spooled entry", "destination", q.name, "id", e.ID,
+					"error", err.Error())
+			}
+		}
+	}
+
+	q.logger.Warn("Moved entries pending delivery for retired queue to dead letters", "destination", q.name, "count", len(q.entries))
+	q.entries = nil
+}
+
+// Halt stops background processing for the [Queue], waiting for any delivery attempts in progress.
+func (q *Queue) halt() {
+	q.mu.Lock()
+	stop, done := q.stop, q.done
+	q.mu.Unlock()
+
+	if stop != nil {
+		stop()
+		<-done
+	}
+}
+
+// Close stops background processing for the [Queue], once all entries currently due have been
+// delivered, or once the given [context.Context] is cancelled, and closes the spool, if any, and
+// the underlying [gateway.Destination]. Entries left pending, e.g. ones waiting to be retried, are
+// kept in the spool for future runs.
+func (q *Queue) Close(ctx context.Context) error {
+	// Push any pending batch ahead of closing, rather than waiting for the batch window to end.
+	if q.batcher != nil {
+		var now = time.Now()
+		q.mu.Lock()
+		for _, e := range q.entries {
+			if e.unbatched() && e.NextAttempt.After(now) {
+				e.NextAttempt = now
+			}
+		}
+		q.mu.Unlock()
+	}
+
+	q.closeOnce.Do(func() { close(q.closing) })
+
+	q.mu.Lock()
+	stop, done, handover := q.stop, q.done, q.handover
+	q.mu.Unlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			stop()
+			<-done
+		}
+	}
+
+	if n := q.Len(); n > 0 {
+		q.logger.Warn("Closed queue with entries pending delivery", "destination", q.name, "count", n)
+	}
+
+	// Spools handed over to other queues are in use by these, and are left open.
+	var errs []error
+	if spool := q.getSpool(); spool != nil && !handover {
+		if err := spool.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed closing spool: %w", err))
+		}
+	}
+	if err := q.destination.Close(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Destination returns the underlying [gateway.Destination] for the [Queue].
+func (q *Queue) Destination() gateway.Destination {
+	return q.destination
+}
+
+// Len returns the number of entries currently held in the [Queue].
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// Deliveries returns the total number of delivery attempts made by the [Queue], keyed by outcome,
+// i.e. whether entries were delivered, are to be retried, or were dropped after failing delivery.
+func (q *Queue) Deliveries() map[string]uint64 {
+	return map[string]uint64{
+		"delivered": q.delivered.Load(),
+		"retried":   q.retried.Load(),
+		"dropped":   q.dropped.Load(),
+	}
+}
+
+// Latency returns a snapshot of the time taken, in seconds, for delivery attempts made against the
+// underlying [gateway.Destination], excluding attempts interrupted by the queue being stopped.
+func (q *Queue) Latency() metrics.Snapshot {
+	return q.latency.Snapshot()
+}
+
+// Activity returns the [gateway.Activity] for delivery attempts made against the underlying
+// [gateway.Destination].
+func (q *Queue) Activity() gateway.Activity {
+	return q.activity.Activity()
+}
+
+// Run processes queued entries as they become due, until the given context is cancelled, or until
+// no further entries are due once the queue is being closed.
+func (q *Queue) run(ctx context.Context) {
+	var timer = time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		e, wait := q.next(time.Now())
+		if e != nil {
+			if err := q.wait(ctx, e); err != nil {
+				return
+			}
+			q.deliver(ctx, e)
+			continue
+		}
+
+		select {
+		case <-q.closing:
+			return
+		default:
+		}
+
+		var after <-chan time.Time
+		if wait > 0 {
+			timer.Reset(wait)
+			after = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.closing:
+		case <-q.notify:
+		case <-after:
+		}
+
+		timer.Stop()
+	}
+}
+
+// Next removes and returns the earliest entry due for delivery, if any. If no entry is currently
+// due, the amount of time until the next entry becomes due is returned, or zero if the queue is
+// empty.
+func (q *Queue) next(now time.Time) (*Entry, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n = -1
+	for i, e := range q.entries {
+		if n < 0 || e.NextAttempt.Before(q.entries[n].NextAttempt) {
+			n = i
+		}
+	}
+
+	if n < 0 {
+		return nil, 0
+	} else if e := q.entries[n]; e.NextAttempt.After(now) {
+		return nil, e.NextAttempt.Sub(now)
+	}
+
+	e := q.entries[n]
+	q.entries = append(q.entries[:n], q.entries[n+1:]...)
+	if q.batcher != nil && e.unbatched() {
+		e = q.collect(e, now)
+	}
+
+	return e, 0
+}
+
+// Schedule sets the time the given entry is due for delivery as part of a batch, i.e. at the end of
+// the window for the batch pending, if any, or at the end of a new batch window. Batches are kept
+// separately for each gateway, and are made due immediately when reaching the maximum number of
+// messages. The queue lock is expected to be held.
+func (q *Queue) schedule(e *Entry, now time.Time) {
+	var due, count = now.Add(q.batcher.window), len(e.Messages)
+	for _, p := range q.entries {
+		if p.unbatched() && p.Gateway == e.Gateway {
+			count += len(p.Messages)
+			if p.NextAttempt.Before(due) {
+				due = p.NextAttempt
+			}
+		}
+	}
+
+	if count >= q.batcher.size {
+		due = now
+		for _, p := range q.entries {
+			if p.unbatched() && p.Gateway == e.Gateway {
+				p.NextAttempt = now
+			}
+		}
+	}
+
+	e.NextAttempt = due
+}
+
+// Collect returns a single entry for the given entry, and any other entries due for delivery as
+// part of the same batch, removing these from the queue. Batches of more than one message are
+// pushed as a digest message, and are recorded in the spool in place of the entries collected,
+// which are kept in the digest entry for reporting delivery outcomes against. The queue lock is
+// expected to be held.
+func (q *Queue) collect(e *Entry, now time.Time) *Entry {
+	var batch = []*Entry{e}
+	q.entries = slices.DeleteFunc(q.entries, func(p *Entry) bool {
+		if p.unbatched() && p.Gateway == e.Gateway && !p.NextAttempt.After(now) {
+			batch = append(batch, p)
+			return true
+		}
+		return false
+	})
+
+	var messages []*gateway.Message
+	var createdAt = e.CreatedAt
+	for _, b := range batch {
+		messages = append(messages, b.Messages...)
+		if b.CreatedAt.Before(createdAt) {
+			createdAt = b.CreatedAt
+		}
+	}
+
+	if len(messages) == 1 {
+		return e
+	}
+
+	var result = &Entry{
+		ID:          newID(),
+		Gateway:     e.Gateway,
+		RequestID:   e.RequestID,
+		TraceParent: e.TraceParent,
+		Target:      e.Target,
+		Messages:    messages,
+		CreatedAt:   createdAt,
+		NextAttempt: now,
+		Batch:       batch,
+	}
+
+	// Digests are logged against the request and trace the first entry collected was pushed under.
+	var ctx = e.context(context.Background())
+	if msg, err := q.batcher.digest(messages); err != nil {
+		q.logger.ErrorContext(ctx, "Failed rendering message digest, pushing messages as-is", "destination", q.name,
+			"error", err.Error())
+	} else {
+		result.Messages = []*gateway.Message{msg}
+	}
+
+	if q.spool != nil {
+		// Collected entries are kept in the spool if the digest cannot be, as to not lose these.
+		if err := q.spool.Add(result); err != nil {
+			q.logger.ErrorContext(ctx, "Failed adding message digest to spool", "destination", q.name, "id", result.ID,
+				"error", err.Error())
+			batch = nil
+		}
+		for _, b := range batch {
+			if err := q.spool.Remove(b.ID); err != nil {
+				q.logger.ErrorContext(b.context(context.Background()), "Failed removing spooled entry", "destination", q.name,
+					"id", b.ID, "error", err.Error())
+			}
+		}
+	}
+
+	q.logger.DebugContext(ctx, "Collected messages into digest", "destination", q.name, "id", result.ID,
+		"count", len(messages))
+	return result
+}
+
+// Wait blocks until the given entry is allowed for delivery under the configured rate limit, if
+// any. Entries are charged one event per message, or the cost reported by the destination, where
+// this implements [gateway.RateCoster]. If the given context is cancelled while waiting, the entry
+// is returned to the queue, and the context error is returned.
+func (q *Queue) wait(ctx context.Context, e *Entry) error {
+	if q.rateLimit == nil {
+		return nil
+	}
+
+	var cost = len(e.Messages)
+	if c, ok := q.destination.(gateway.RateCoster); ok {
+		cost = c.Cost(e.Messages...)
+	}
+
+	if err := q.rateLimit.Wait(ctx, cost); err != nil {
+		q.mu.Lock()
+		q.entries = append(q.entries, e)
+		q.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Deliver attempts to push the given entry to the underlying destination, re-queueing the entry
+// with an appropriate delay on failure. Each attempt is traced under a span of its own, continuing
+// the trace the entry was pushed under, if any; digest entries have a span started under the trace
+// of each entry collected.
+func (q *Queue) deliver(ctx context.Context, e *Entry) {
+	var pushCtx, spans = ctx, []*trace.Span{}
+	for _, o := range e.origins() {
+		spanCtx, span := trace.Start(o.context(ctx), "deliver "+q.name, trace.KindConsumer)
+		defer span.End()
+
+		// Messages are pushed under the request and trace the first entry collected was pushed under.
+		if spans = append(spans, span); len(spans) == 1 {
+			pushCtx = spanCtx
+		}
+	}
+
+	pushCtx, cancel := context.WithTimeout(pushCtx, q.timeout)
+	defer cancel()
+
+	e.Attempts++
+	for _, span := range spans {
+		span.SetAttribute("delivery.destination", q.name)
+		span.SetAttribute("delivery.id", e.ID)
+		span.SetAttribute("delivery.attempt", e.Attempts)
+		span.SetAttribute("delivery.messages", len(e.Messages))
+	}
+
+	start := time.Now()
+	err := q.destination.PushMessages(pushCtx, e.Messages...)
+	for _, span := range spans {
+		span.SetError(err)
+	}
+	if err == nil {
+		q.latency.ObserveSince(start)
+		q.delivered.Add(1)
+		q.activity.Success()
+		q.logger.DebugContext(pushCtx, "Delivered queued messages", "destination", q.name, "id", e.ID, "attempts", e.Attempts)
+		q.report(e, gateway.DeliveryDelivered, nil)
+		q.remove(pushCtx, e)
+		return
+	} else if ctx.Err() != nil {
+		// Return entries interrupted by processing being stopped to the queue as-is.
+		e.Attempts--
+		q.mu.Lock()
+		q.entries = append(q.entries, e)
+		q.mu.Unlock()
+		return
+	}
+
+	var now = time.Now()
+	e.LastError = err.Error()
+
+	q.latency.ObserveSince(start)
+	q.activity.Failure(err)
+	if e.Attempts >= q.maxAttempts || now.Sub(e.CreatedAt) >= q.maxAge {
+		q.dropped.Add(1)
+		q.logger.ErrorContext(pushCtx, "Failed delivering queued messages, dropping", "destination", q.name, "id", e.ID,
+			"attempts", e.Attempts, "error", e.LastError)
+		q.report(e, gateway.DeliveryDropped, err)
+		if q.deadLetters != nil {
+			if err := q.deadLetters.Add(&DeadLetter{Entry: *e, Destination: q.name, FailedAt: now}); err != nil {
+				q.logger.ErrorContext(pushCtx, "Failed storing dead letter", "destination", q.name, "id", e.ID, "error", err.Error())
+			}
+		}
+		q.remove(pushCtx, e)
+		return
+	}
+
+	q.retried.Add(1)
+	e.NextAttempt = now.Add(q.delay(e.Attempts))
+	q.logger.WarnContext(pushCtx, "Failed delivering queued messages, retrying", "destination", q.name, "id", e.ID,
+		"attempts", e.Attempts, "next-attempt", e.NextAttempt, "error", e.LastError)
+	q.report(e, gateway.DeliveryRetrying, err)
+
+	if spool := q.getSpool(); spool != nil {
+		if err := spool.Update(e); err != nil {
+			q.logger.ErrorContext(pushCtx, "Failed updating spooled entry", "destination", q.name, "id", e.ID,
+				"error", err.Error())
+		}
+	}
+
+	q.mu.Lock()
+	q.entries = append(q.entries, e)
+	q.mu.Unlock()
+}
+
+// Report records the delivery outcome for the given entry in the configured [gateway.History], if
+// any, under the destination name known to the gateway the entry was pushed from. Outcomes for
+// digest entries are recorded against the requests for each entry collected.
+func (q *Queue) report(e *Entry, status string, err error) {
+	if q.history == nil {
+		return
+	}
+
+	for _, o := range e.origins() {
+		q.history.RecordDelivery(o.RequestID, cmp.Or(o.Target, q.name), status, e.Attempts, err)
+	}
+}
+
+// Remove removes the given entry from the spool, where one is configured, logging any errors against
+// the context given.
+func (q *Queue) remove(ctx context.Context, e *Entry) {
+	if spool := q.getSpool(); spool == nil {
+		return
+	} else if err := spool.Remove(e.ID); err != nil {
+		q.logger.ErrorContext(ctx, "Failed removing spooled entry", "destination", q.name, "id", e.ID, "error", err.Error())
+	}
+}
+
+// GetSpool returns the spool configured for the queue, if any, which may be set on adoption.
+func (q *Queue) getSpool() *Spool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.spool
+}
+
+// Delay returns the amount of time to wait before making another delivery attempt, given the number
+// of attempts already made.
+func (q *Queue) delay(attempts int) time.Duration {
+	var d = q.backoff
+	for i := 1; i < attempts && d < q.maxBackoff; i++ {
+		d *= 2
+	}
+
+	return min(d, q.maxBackoff)
+}
+
+// UnmarshalTOML configures the [Queue] based on values sourced from TOML configuration.
+func (q *Queue) UnmarshalTOML(data any) error {
+	conf, ok := data.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var options []Option
+	if v, ok := conf["size"].(int64); ok {
+		options = append(options, WithSize(int(v)))
+	}
+	if v, ok := conf["max-attempts"].(int64); ok {
+		options = append(options, WithMaxAttempts(int(v)))
+	}
+	if v, ok := conf["max-age"].(string); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed parsing maximum age: %w", err)
+		}
+		options = append(options, WithMaxAge(d))
+	}
+	if v, ok := conf["timeout"].(string); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed parsing timeout: %w", err)
+		}
+		options = append(options, WithTimeout(d))
+	}
+
+	var initial, maximum = q.backoff, q.maxBackoff
+	if v, ok := conf["backoff"].(string); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed parsing backoff: %w", err)
+		}
+		initial = d
+	}
+	if v, ok := conf["max-backoff"].(string); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed parsing maximum backoff: %w", err)
+		}
+		maximum = d
+	}
+	options = append(options, WithBackoff(initial, maximum))
+
+	for _, fn := range options {
+		if err := fn(q); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewID returns a random, hex-encoded identifier for queue entries.
+func newID() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,332 @@
+package delivery
+
+import (
+	// Standard library.
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+type testDestination struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	messages []*gateway.Message
+
+	// Channel closed when the given number of attempts has been made.
+	wait int
+	done chan struct{}
+}
+
+func (d *testDestination) PushMessages(_ context.Context, messages ...*gateway.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.attempts++
+	if d.done != nil && d.attempts == d.wait {
+		defer close(d.done)
+	}
+
+	if d.attempts <= d.failures {
+		return errors.New("connection lost")
+	}
+
+	d.messages = append(d.messages, messages...)
+	return nil
+}
+
+func (d *testDestination) Init(context.Context) error  { return nil }
+func (d *testDestination) Close(context.Context) error { return nil }
+
+func TestQueueDelivery(t *testing.T) {
+	var testCases = []struct {
+		descr       string
+		failures    int
+		maxAttempts int
+
+		attempts  int
+		delivered int
+	}{
+		{
+			descr:       "delivery on first attempt",
+			maxAttempts: 3,
+			attempts:    1,
+			delivered:   1,
+		},
+		{
+			descr:       "delivery after retries",
+			failures:    2,
+			maxAttempts: 3,
+			attempts:    3,
+			delivered:   1,
+		},
+		{
+			descr:       "delivery failure after maximum attempts",
+			failures:    3,
+			maxAttempts: 3,
+			attempts:    3,
+			delivered:   0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			d := &testDestination{failures: tt.failures, wait: tt.attempts, done: make(chan struct{})}
+			q, err := New("test", d,
+				WithMaxAttempts(tt.maxAttempts),
+				WithBackoff(time.Millisecond, time.Millisecond),
+				WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+			)
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if err := q.Init(ctx); err != nil {
+				t.Fatalf("Queue.Init(): want error 'nil', have '%s'", err)
+			} else if err := q.PushMessages(ctx, &gateway.Message{Content: "Hello"}); err != nil {
+				t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+			}
+
+			select {
+			case <-d.done:
+			case <-time.After(time.Second):
+				t.Fatalf("Queue.PushMessages(): timed out waiting for delivery")
+			}
+
+			// Wait for any re-queueing to settle before checking results.
+			time.Sleep(10 * time.Millisecond)
+
+			d.mu.Lock()
+			defer d.mu.Unlock()
+
+			if d.attempts != tt.attempts {
+				t.Fatalf("Queue.PushMessages(): want %d attempts, have %d", tt.attempts, d.attempts)
+			} else if len(d.messages) != tt.delivered {
+				t.Fatalf("Queue.PushMessages(): want %d messages delivered, have %d", tt.delivered, len(d.messages))
+			} else if q.Len() != 0 {
+				t.Fatalf("Queue.Len(): want empty queue, have %d entries", q.Len())
+			}
+
+			var want = map[string]uint64{
+				"delivered": uint64(tt.delivered),
+				"retried":   uint64(tt.attempts - 1),
+				"dropped":   uint64(1 - tt.delivered),
+			}
+			if have := q.Deliveries(); !maps.Equal(have, want) {
+				t.Fatalf("Queue.Deliveries(): want '%v', have '%v'", want, have)
+			} else if have := q.Latency().Count; have != uint64(tt.attempts) {
+				t.Fatalf("Queue.Latency(): want %d observations, have %d", tt.attempts, have)
+			}
+		})
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	q, err := New("test", &testDestination{}, WithSize(1))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	// Push messages without initializing the queue, so that no entries are processed.
+	if err := q.PushMessages(context.Background(), &gateway.Message{Content: "Hello"}); err != nil {
+		t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+	} else if err := q.PushMessages(context.Background(), &gateway.Message{Content: "Hello"}); err == nil {
+		t.Fatalf("Queue.PushMessages(): want error for full queue, have 'nil'")
+	}
+}
+
+func TestQueueDelay(t *testing.T) {
+	q, err := New("test", &testDestination{}, WithBackoff(time.Second, 10*time.Second))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	var expect = []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, want := range expect {
+		if have := q.delay(i + 1); have != want {
+			t.Fatalf("Queue.delay(%d): want '%s', have '%s'", i+1, want, have)
+		}
+	}
+}
+
+func TestQueueAdopt(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "spool.log")
+	var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Push messages to a queue that's not initialized, so that entries are left pending.
+	prev, err := New("test", &testDestination{}, WithSpool(NewSpool(path)), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	for _, content := range []string{"Hello", "World"} {
+		if err := prev.PushMessages(context.Background(), &gateway.Message{Content: content}); err != nil {
+			t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	d := &testDestination{wait: 3, done: make(chan struct{})}
+	q, err := New("test", d, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := q.Init(ctx); err != nil {
+		t.Fatalf("Queue.Init(): want error 'nil', have '%s'", err)
+	}
+
+	q.TakeSpool(prev)
+	q.Adopt(prev)
+	if prev.Len() != 0 {
+		t.Fatalf("Queue.Adopt(): want no entries left in adopted queue, have %d", prev.Len())
+	}
+
+	// Messages pushed to the adopted queue are forwarded.
+	if err := prev.PushMessages(ctx, &gateway.Message{Content: "!"}); err != nil {
+		t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+	}
+
+	select {
+	case <-d.done:
+	case <-time.After(time.Second):
+		t.Fatalf("Queue.Adopt(): timed out waiting for delivery")
+	}
+
+	// Wait for spool to be updated before checking results.
+	time.Sleep(10 * time.Millisecond)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var contents []string
+	for _, m := range d.messages {
+		contents = append(contents, m.Content)
+	}
+
+	if !slices.Equal(contents, []string{"Hello", "World", "!"}) {
+		t.Fatalf("Queue.Adopt(): want messages delivered in order, have '%v'", contents)
+	} else if entries, err := NewSpool(path).Load(); err != nil || len(entries) != 0 {
+		t.Fatalf("Spool.Load(): want no pending entries, have '%v' (error '%v')", entries, err)
+	}
+}
+
+func TestQueueRetire(t *testing.T) {
+	var dir = t.TempDir()
+	var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var spool, deadLetters = NewSpool(filepath.Join(dir, "spool.log")), NewDeadLetters(filepath.Join(dir, "dead-letters.log"))
+	q, err := New("test", &testDestination{}, WithSpool(spool), WithDeadLetters(deadLetters), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	for _, content := range []string{"Hello", "World"} {
+		if err := q.PushMessages(context.Background(), &gateway.Message{Content: content}); err != nil {
+			t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	q.Retire()
+	if q.Len() != 0 {
+		t.Fatalf("Queue.Retire(): want no entries left in queue, have %d", q.Len())
+	} else if err := q.PushMessages(context.Background(), &gateway.Message{Content: "!"}); err == nil {
+		t.Fatalf("Queue.PushMessages(): want error for retired queue, have 'nil'")
+	} else if err := q.Close(context.Background()); err != nil {
+		t.Fatalf("Queue.Close(): want error 'nil', have '%s'", err)
+	}
+
+	if letters, err := deadLetters.List(); err != nil || len(letters) != 2 || letters[0].Destination != "test" {
+		t.Fatalf("DeadLetters.List(): want 2 dead letters for retired queue, have '%v' (error '%v')", letters, err)
+	} else if entries, err := NewSpool(filepath.Join(dir, "spool.log")).Load(); err != nil || len(entries) != 0 {
+		t.Fatalf("Spool.Load(): want no pending entries, have '%v' (error '%v')", entries, err)
+	}
+}
+
+func TestQueueClose(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "spool.log")
+	var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Fail the first delivery attempt, leaving the first entry pending retry well past closing.
+	d := &testDestination{failures: 1}
+	q, err := New("test", d, WithSpool(NewSpool(path)), WithBackoff(time.Hour, time.Hour), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := q.Init(ctx); err != nil {
+		t.Fatalf("Queue.Init(): want error 'nil', have '%s'", err)
+	}
+
+	for _, content := range []string{"Hello", "World"} {
+		if err := q.PushMessages(ctx, &gateway.Message{Content: content}); err != nil {
+			t.Fatalf("Queue.PushMessages(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), time.Second)
+	defer closeCancel()
+
+	if err := q.Close(closeCtx); err != nil {
+		t.Fatalf("Queue.Close(): want error 'nil', have '%s'", err)
+	} else if closeCtx.Err() != nil {
+		t.Fatalf("Queue.Close(): timed out waiting for due entries to be delivered")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.attempts != 2 {
+		t.Fatalf("Queue.Close(): want 2 attempts, have %d", d.attempts)
+	} else if len(d.messages) != 1 || d.messages[0].Content != "World" {
+		t.Fatalf("Queue.Close(): want due message delivered, have '%v'", d.messages)
+	} else if entries, err := NewSpool(path).Load(); err != nil || len(entries) != 1 {
+		t.Fatalf("Spool.Load(): want pending entry kept, have '%v' (error '%v')", entries, err)
+	}
+}
+
+type costDestination struct {
+	testDestination
+	recipients int
+}
+
+func (d *costDestination) Cost(messages ...*gateway.Message) int {
+	return len(messages) * d.recipients
+}
+
+func TestQueueRateLimitCost(t *testing.T) {
+	q, err := New("test", &costDestination{recipients: 3}, WithRateLimit(gateway.RateLimit{Rate: 0.001, Burst: 3}))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	// Entries are charged the cost reported by the destination, exhausting the burst given.
+	if err := q.wait(context.Background(), &Entry{Messages: []*gateway.Message{{Content: "Hello"}}}); err != nil {
+		t.Fatalf("Queue.wait(): want error 'nil', have '%s'", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := q.wait(ctx, &Entry{Messages: []*gateway.Message{{Content: "World"}}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Queue.wait(): want error '%s', have '%v'", context.DeadlineExceeded, err)
+	}
+}
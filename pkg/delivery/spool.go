@@ -0,0 +1,60 @@
+package delivery
+
+import (
+	// Standard library.
+	"fmt"
+)
+
+// A Spool represents a file-backed, append-only log of queued entries, used for ensuring that any
+// entries pending delivery survive process restarts. Operations on entries are appended to the log
+// as they happen, and the log is compacted to contain only pending entries when loaded.
+type Spool struct {
+	journal journal[*Entry]
+}
+
+// NewSpool returns a [Spool] for the file path given. The file, and any parent directories, will
+// be created on calls to [Spool.Load] if they don't already exist.
+func NewSpool(path string) *Spool {
+	return &Spool{journal: journal[*Entry]{path: path}}
+}
+
+// Load returns all entries still pending delivery, in the order they were added. The spool file is
+// rewritten to only contain pending entries, and is kept open for appending further operations
+// until [Spool.Close] is called.
+func (s *Spool) Load() ([]*Entry, error) {
+	entries, err := s.journal.compact()
+	if err != nil {
+		return nil, fmt.Errorf("failed loading spool: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Add records the given entry as pending delivery.
+func (s *Spool) Add(e *Entry) error {
+	if err := s.journal.write(journalRecord[*Entry]{Op: journalAdd, Value: e}); err != nil {
+		return fmt.Errorf("failed adding spool entry: %w", err)
+	}
+	return nil
+}
+
+// Update records changes made to the given entry, e.g. after failed delivery attempts.
+func (s *Spool) Update(e *Entry) error {
+	if err := s.journal.write(journalRecord[*Entry]{Op: journalUpdate, Value: e}); err != nil {
+		return fmt.Errorf("failed updating spool entry: %w", err)
+	}
+	return nil
+}
+
+// Remove records the entry for the ID given as no longer pending delivery.
+func (s *Spool) Remove(id string) error {
+	if err := s.journal.write(journalRecord[*Entry]{Op: journalRemove, ID: id}); err != nil {
+		return fmt.Errorf("failed removing spool entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying spool file.
+func (s *Spool) Close() error {
+	return s.journal.close()
+}
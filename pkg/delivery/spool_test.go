@@ -0,0 +1,67 @@
+package delivery
+
+import (
+	// Standard library.
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+func TestSpoolLoad(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "spool", "test.log")
+	var entries = []*Entry{
+		{ID: "1", Messages: []*gateway.Message{{Content: "Hello"}}},
+		{ID: "2", Messages: []*gateway.Message{{Content: "World"}}},
+		{ID: "3", Messages: []*gateway.Message{{Content: "!"}}},
+	}
+
+	s := NewSpool(path)
+	if _, err := s.Load(); err != nil {
+		t.Fatalf("Spool.Load(): want error 'nil', have '%s'", err)
+	}
+
+	for _, e := range entries {
+		if err := s.Add(e); err != nil {
+			t.Fatalf("Spool.Add(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	entries[2].Attempts = 2
+	if err := s.Update(entries[2]); err != nil {
+		t.Fatalf("Spool.Update(): want error 'nil', have '%s'", err)
+	} else if err = s.Remove("1"); err != nil {
+		t.Fatalf("Spool.Remove(): want error 'nil', have '%s'", err)
+	} else if err = s.Close(); err != nil {
+		t.Fatalf("Spool.Close(): want error 'nil', have '%s'", err)
+	}
+
+	// Simulate partially written record at the end of the file.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("os.OpenFile(): want error 'nil', have '%s'", err)
+	}
+	_, _ = f.WriteString(`{"op":"remove","id":"2`)
+	f.Close()
+
+	s = NewSpool(path)
+	defer s.Close()
+
+	pending, err := s.Load()
+	if err != nil {
+		t.Fatalf("Spool.Load(): want error 'nil', have '%s'", err)
+	} else if !reflect.DeepEqual(pending, entries[1:]) {
+		t.Fatalf("Spool.Load(): want entries '%#v', have '%#v'", entries[1:], pending)
+	}
+
+	// Spool should have been compacted to only contain pending entries.
+	pending, err = (&journal[*Entry]{path: path}).read()
+	if err != nil {
+		t.Fatalf("journal.read(): want error 'nil', have '%s'", err)
+	} else if !reflect.DeepEqual(pending, entries[1:]) {
+		t.Fatalf("journal.read(): want entries '%#v', have '%#v'", entries[1:], pending)
+	}
+}
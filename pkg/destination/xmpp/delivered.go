@@ -0,0 +1,70 @@
+package xmpp
+
+import (
+	// Standard library.
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+// Amount of time recipients sent messages are remembered for, where pushing messages has failed for
+// other recipients, as matches the default maximum age for messages in delivery queues.
+const deliveredRetention = 24 * time.Hour
+
+// A Delivered represents the recipients a message has been sent to.
+type delivered struct {
+	recipients []string  // The recipient JIDs, as configured.
+	sentAt     time.Time // The time the message was first sent.
+}
+
+// DeliveredMessages keeps track of the recipients messages have been sent to, so that messages
+// pushed again after failing for some recipients, e.g. when retried by delivery queues, are only
+// sent to the remaining recipients. Messages are identified by the [gateway.Message] values pushed,
+// and messages sent before restarts are thus not tracked.
+type deliveredMessages struct {
+	mu       sync.Mutex
+	messages map[*gateway.Message]delivered
+}
+
+// Has returns whether the message given has been sent to the recipient given.
+func (d *deliveredMessages) has(msg *gateway.Message, recipient string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Contains(d.messages[msg].recipients, recipient)
+}
+
+// Add records the message given as sent to the recipient given. Messages older than the retention
+// period are removed.
+func (d *deliveredMessages) add(msg *gateway.Message, recipient string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var now = time.Now()
+	if d.messages == nil {
+		d.messages = make(map[*gateway.Message]delivered)
+	}
+
+	maps.DeleteFunc(d.messages, func(_ *gateway.Message, m delivered) bool { return now.Sub(m.sentAt) > deliveredRetention })
+
+	m, ok := d.messages[msg]
+	if !ok {
+		m.sentAt = now
+	}
+
+	m.recipients = append(m.recipients, recipient)
+	d.messages[msg] = m
+}
+
+// Remove removes any recipients recorded for the messages given, e.g. once sent to all recipients.
+func (d *deliveredMessages) remove(messages ...*gateway.Message) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, msg := range messages {
+		delete(d.messages, msg)
+	}
+}
@@ -0,0 +1,394 @@
+package xmpp
+
+import (
+	// Standard library.
+	"context"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	// Third-party packages.
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// Status codes for XEP-0045: Multi-User Chat presence, as used in determining the outcome of room
+// joins and departures.
+const (
+	mucStatusSelf        = 110 // Presence refers to the occupant itself.
+	mucStatusBanned      = 301 // Occupant has been banned from the room.
+	mucStatusNickChanged = 303 // Occupant has changed their nickname.
+)
+
+// Limits and intervals used in joining group chats.
+const (
+	mucMaxNickAttempts = 5                // The number of nickname suffixes tried on conflicts.
+	mucRejoinBackoff   = 5 * time.Second  // The initial delay between failed attempts at joining.
+	mucRejoinMaxDelay  = 5 * time.Minute  // The maximum delay between failed attempts at joining.
+	mucEncodeTimeout   = 30 * time.Second // The timeout for sending presence when rejoining.
+)
+
+// MUCJoin is a presence stanza used for joining a group chat, optionally with a password. No
+// history is requested, since this is of no use to the client.
+type mucJoin struct {
+	stanza.Presence
+	X struct {
+		XMLName  xml.Name `xml:"http://jabber.org/protocol/muc x"`
+		Password string   `xml:"password,omitempty"`
+		History  struct {
+			MaxStanzas int `xml:"maxstanzas,attr"`
+		} `xml:"history"`
+	}
+}
+
+// MUCPresence is a presence stanza received from a group chat, containing any status codes and
+// errors relevant to the client.
+type mucPresence struct {
+	stanza.Presence
+	User *struct {
+		Status []mucStatus `xml:"status"`
+	} `xml:"http://jabber.org/protocol/muc#user x"`
+	Error stanza.Error `xml:"error"`
+}
+
+// MUCStatus is a status code attached to presence received from a group chat.
+type mucStatus struct {
+	Code int `xml:"code,attr"`
+}
+
+// HasStatus returns whether or not the presence contains the status code given.
+func (p mucPresence) hasStatus(code int) bool {
+	return p.User != nil && slices.Contains(p.User.Status, mucStatus{Code: code})
+}
+
+// MUCMessage is a message stanza received from a group chat, either reflecting a message sent by
+// the client, or reporting an error in sending a message.
+type mucMessage struct {
+	stanza.Message
+	Error     stanza.Error `xml:"error"`
+	StanzaIDs []stanzaID   `xml:"urn:xmpp:sid:0 stanza-id"`
+}
+
+// A Room represents a group chat joined by the client, and the state of the client's occupancy in
+// that group chat. Messages can only be sent to rooms once joined, as confirmed by the server.
+type room struct {
+	jid      jid.JID // The bare JID for the room.
+	nick     string  // The nickname requested for the client in the room.
+	password string  // The password used in joining the room, if any.
+
+	// Internal fields.
+	mu       sync.Mutex
+	occupant jid.JID       // The full JID last used in joining the room.
+	joined   bool          // Whether or not the client has been confirmed as a room occupant.
+	conflict int           // The number of nickname conflicts encountered in joining.
+	retries  int           // The number of failed attempts at joining.
+	ready    chan struct{} // Closed once the room has been joined, or has failed to be joined.
+	err      error         // The error encountered in joining the room, if any.
+}
+
+// NewRoom returns a [room] for the given occupant JID, i.e. the room JID and the client nickname as
+// the resource part.
+func newRoom(occupant jid.JID, password string) *room {
+	return &room{
+		jid:      occupant.Bare(),
+		nick:     occupant.Resourcepart(),
+		password: password,
+		ready:    make(chan struct{}),
+	}
+}
+
+// Join returns the presence stanza to send for joining the room, using a suffixed nickname if any
+// conflicts were previously encountered.
+func (r *room) join() (mucJoin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var nick = r.nick
+	if r.conflict > 0 {
+		nick = fmt.Sprintf("%s_%d", r.nick, r.conflict)
+	}
+
+	occupant, err := r.jid.WithResource(nick)
+	if err != nil {
+		return mucJoin{}, err
+	}
+
+	r.occupant = occupant
+
+	var p = mucJoin{Presence: stanza.Presence{To: occupant}}
+	p.X.Password = r.password
+
+	return p, nil
+}
+
+// Wait blocks until the room has been joined, returning an error if joining failed, or if the
+// given context expired before the room was joined.
+func (r *room) wait(ctx context.Context) error {
+	r.mu.Lock()
+	var ready = r.ready
+	r.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting to join group chat '%s'", r.jid)
+	case <-ready:
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return fmt.Errorf("failed joining group chat '%s': %w", r.jid, r.err)
+	}
+
+	return nil
+}
+
+// SetJoined marks the room as joined under the occupant JID given, which may differ from the one
+// requested, if the server has modified the nickname.
+func (r *room) setJoined(occupant jid.JID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.joined {
+		return
+	}
+
+	r.occupant, r.joined, r.retries, r.err = occupant, true, 0, nil
+	close(r.ready)
+}
+
+// SetFailed marks the room as permanently failed to join, with the error given.
+func (r *room) setFailed(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.joined || r.err != nil {
+		return
+	}
+
+	r.err = err
+	close(r.ready)
+}
+
+// Reset marks the room as no longer joined, e.g. after the client has been removed from the room,
+// returning the delay to wait before attempting to join again. Nickname conflicts are retained, as
+// the nickname requested may still be in use.
+func (r *room) reset() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var delay = mucRejoinBackoff << min(r.retries, 10)
+	r.leave()
+	r.retries++
+
+	return min(delay, mucRejoinMaxDelay)
+}
+
+// Restart marks the room as no longer joined, and clears any failed attempts at joining, as used
+// when joining the room on a new session.
+func (r *room) restart() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.leave()
+	r.retries, r.conflict = 0, 0
+}
+
+// Leave marks the room as no longer joined. The room lock is expected to be held.
+func (r *room) leave() {
+	select {
+	case <-r.ready:
+		r.ready = make(chan struct{})
+	default:
+	}
+
+	r.joined, r.err = false, nil
+}
+
+// Status returns an error if the room is not currently joined, including the error encountered in
+// joining the room, if any.
+func (r *room) status() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return fmt.Errorf("failed joining group chat '%s': %w", r.jid, r.err)
+	} else if !r.joined {
+		return fmt.Errorf("not joined to group chat '%s'", r.jid)
+	}
+
+	return nil
+}
+
+// IsOccupant returns whether or not the JID given is the one last used in joining the room.
+func (r *room) isOccupant(j jid.JID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.occupant.Equal(j)
+}
+
+// OccupantJID returns the full JID last used in joining the room.
+func (r *room) occupantJID() jid.JID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.occupant
+}
+
+// AddConflict records a nickname conflict encountered in joining the room, returning false if no
+// further nicknames are to be tried.
+func (r *room) addConflict() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.conflict++
+	return r.conflict <= mucMaxNickAttempts
+}
+
+// JoinRooms sends presence for joining all configured group chats on the given session, resetting
+// any state left from previous sessions.
+func (x *XMPP) joinRooms(ctx context.Context, session *xmpp.Session) {
+	for _, r := range x.rooms {
+		r.restart()
+		if err := x.joinRoom(ctx, r, func(v any) error { return session.Encode(ctx, v) }); err != nil {
+			x.logger.ErrorContext(ctx, "Failed joining XMPP group chat", "room", r.jid.String(), "error", err.Error())
+		}
+	}
+}
+
+// JoinRoom sends presence for joining the room given, using the encoding function given. Join
+// presence is counted for Stream Management purposes, but is not re-sent on new streams, as rooms
+// are joined explicitly for every new session.
+func (x *XMPP) joinRoom(ctx context.Context, r *room, encode func(any) error) error {
+	p, err := r.join()
+	if err != nil {
+		return err
+	}
+
+	x.logger.DebugContext(ctx, "Joining XMPP group chat", "room", r.jid.String(), "occupant", p.To.String())
+	return x.sm.send(nil, func() error { return encode(p) })
+}
+
+// RejoinRoom attempts to join the room given after a delay, as long as the current session is still
+// active; rooms are otherwise joined when new sessions are established.
+func (x *XMPP) rejoinRoom(ctx context.Context, r *room, session *xmpp.Session) {
+	var delay = r.reset()
+	time.AfterFunc(delay, func() {
+		x.mu.Lock()
+		current := x.session
+		x.mu.Unlock()
+
+		if current != session {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mucEncodeTimeout)
+		defer cancel()
+
+		if err := x.joinRoom(ctx, r, func(v any) error { return session.Encode(ctx, v) }); err != nil {
+			x.logger.ErrorContext(ctx, "Failed joining XMPP group chat", "room", r.jid.String(), "error", err.Error())
+		}
+	})
+}
+
+// HandlePresence handles presence received from joined group chats, marking rooms as joined once
+// the server has confirmed the client as an occupant, and retrying joins on nickname conflicts,
+// temporary errors, and removal from the room.
+func (x *XMPP) handlePresence(ctx context.Context, t xmlstream.TokenReadEncoder, start *xml.StartElement, session *xmpp.Session) error {
+	var p mucPresence
+	if err := decodeElement(t, start, &p); err != nil {
+		return err
+	}
+
+	r, ok := x.rooms[p.From.Bare().String()]
+	if !ok {
+		return nil
+	}
+
+	// Only presence referring to the client itself is relevant here.
+	if p.Type != stanza.ErrorPresence && !p.hasStatus(mucStatusSelf) && !r.isOccupant(p.From) {
+		return nil
+	}
+
+	switch p.Type {
+	case stanza.ErrorPresence:
+		switch p.Error.Condition {
+		case stanza.Conflict:
+			if !r.addConflict() {
+				r.setFailed(fmt.Errorf("nickname already in use"))
+				x.logger.ErrorContext(ctx, "Failed joining XMPP group chat, nickname already in use", "room", r.jid.String())
+				return nil
+			}
+
+			x.logger.WarnContext(ctx, "Nickname already in use for XMPP group chat, retrying", "room", r.jid.String())
+			return x.joinRoom(ctx, r, t.Encode)
+		case stanza.NotAuthorized, stanza.Forbidden, stanza.RegistrationRequired, stanza.NotAllowed:
+			r.setFailed(p.Error)
+			x.logger.ErrorContext(ctx, "Failed joining XMPP group chat", "room", r.jid.String(), "error", p.Error.Error())
+		default:
+			x.logger.WarnContext(ctx, "Failed joining XMPP group chat, retrying", "room", r.jid.String(), "error", p.Error.Error())
+			x.rejoinRoom(ctx, r, session)
+		}
+	case stanza.UnavailablePresence:
+		if p.hasStatus(mucStatusNickChanged) {
+			return nil
+		} else if p.hasStatus(mucStatusBanned) {
+			r.reset()
+			r.setFailed(fmt.Errorf("banned from room"))
+			x.logger.ErrorContext(ctx, "Banned from XMPP group chat", "room", r.jid.String())
+			return nil
+		}
+
+		x.logger.WarnContext(ctx, "Removed from XMPP group chat, rejoining", "room", r.jid.String())
+		x.rejoinRoom(ctx, r, session)
+	case stanza.AvailablePresence:
+		r.setJoined(p.From)
+		x.logger.InfoContext(ctx, "Joined XMPP group chat", "room", r.jid.String(), "occupant", p.From.String())
+	}
+
+	return nil
+}
+
+// HandleMessage handles message errors received from joined group chats, which typically denote
+// that the client is no longer an occupant (e.g. after a server restart), and rejoins these.
+func (x *XMPP) handleMessage(ctx context.Context, t xmlstream.TokenReadEncoder, start *xml.StartElement, session *xmpp.Session) error {
+	var m mucMessage
+	if err := decodeElement(t, start, &m); err != nil {
+		return err
+	}
+
+	r, ok := x.rooms[m.From.Bare().String()]
+	if !ok {
+		return nil
+	} else if m.Type == stanza.GroupChatMessage {
+		// Record IDs assigned by the group chat for messages sent by the client, as reflected back.
+		if m.ID != "" && r.isOccupant(m.From) {
+			for _, sid := range m.StanzaIDs {
+				if sid.By == r.jid.String() {
+					x.sent.setStanzaID(m.ID, sid.ID)
+				}
+			}
+		}
+		return nil
+	} else if m.Type != stanza.ErrorMessage {
+		return nil
+	} else if m.Error.Condition != stanza.NotAcceptable && m.Error.Condition != stanza.ItemNotFound {
+		x.logger.WarnContext(ctx, "Failed sending message to XMPP group chat", "room", r.jid.String(), "error", m.Error.Error())
+		return nil
+	}
+
+	x.logger.WarnContext(ctx, "No longer in XMPP group chat, rejoining", "room", r.jid.String(), "error", m.Error.Error())
+	x.rejoinRoom(ctx, r, session)
+
+	return nil
+}
+
+// DecodeElement decodes the element for the given start element and token reader, as passed to
+// handlers, into the value given.
+func decodeElement(t xml.TokenReader, start *xml.StartElement, v any) error {
+	return xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), t)).Decode(v)
+}
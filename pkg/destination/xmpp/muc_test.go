@@ -0,0 +1,122 @@
+package xmpp
+
+import (
+	// Standard library.
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	// Third-party packages.
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/jid"
+)
+
+// TestStream is a [xmlstream.TokenReadEncoder] reading from a single element, and recording any
+// elements encoded.
+type testStream struct {
+	xml.TokenReader
+	out bytes.Buffer
+}
+
+func (s *testStream) Encode(v any) error {
+	return xml.NewEncoder(&s.out).Encode(v)
+}
+
+func (s *testStream) EncodeElement(v any, start xml.StartElement) error {
+	return xml.NewEncoder(&s.out).EncodeElement(v, start)
+}
+
+func (s *testStream) EncodeToken(t xml.Token) error {
+	return nil
+}
+
+func TestHandlePresence(t *testing.T) {
+	var testCases = []struct {
+		descr    string
+		presence []string
+		joined   bool
+		failed   bool
+		encoded  string
+	}{
+		{
+			descr:    "join with self-presence",
+			presence: []string{`<presence from="room@muc.example.com/alerts"><x xmlns="http://jabber.org/protocol/muc#user"><status code="110"/></x></presence>`},
+			joined:   true,
+		},
+		{
+			descr:    "join with modified nickname",
+			presence: []string{`<presence from="room@muc.example.com/modified"><x xmlns="http://jabber.org/protocol/muc#user"><status code="110"/><status code="210"/></x></presence>`},
+			joined:   true,
+		},
+		{
+			descr:    "ignore presence for other occupants",
+			presence: []string{`<presence from="room@muc.example.com/other"><x xmlns="http://jabber.org/protocol/muc#user"/></presence>`},
+		},
+		{
+			descr:    "retry with suffixed nickname on conflict",
+			presence: []string{`<presence from="room@muc.example.com/alerts" type="error"><error type="cancel"><conflict xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/></error></presence>`},
+			encoded:  `to="room@muc.example.com/alerts_1"`,
+		},
+		{
+			descr:    "fail on invalid password",
+			presence: []string{`<presence from="room@muc.example.com/alerts" type="error"><error type="auth"><not-authorized xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/></error></presence>`},
+			failed:   true,
+		},
+		{
+			descr: "fail after being banned",
+			presence: []string{
+				`<presence from="room@muc.example.com/alerts"><x xmlns="http://jabber.org/protocol/muc#user"><status code="110"/></x></presence>`,
+				`<presence from="room@muc.example.com/alerts" type="unavailable"><x xmlns="http://jabber.org/protocol/muc#user"><status code="110"/><status code="301"/></x></presence>`,
+			},
+			failed: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var x = &XMPP{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+			var r = newRoom(jid.MustParse("room@muc.example.com/alerts"), "")
+			x.rooms = map[string]*room{"room@muc.example.com": r}
+
+			if _, err := r.join(); err != nil {
+				t.Fatalf("room.join(): want error 'nil', have '%s'", err)
+			}
+
+			var s testStream
+			for _, p := range tt.presence {
+				d := xml.NewDecoder(strings.NewReader(p))
+				tok, err := d.Token()
+				if err != nil {
+					t.Fatalf("xml.Decoder.Token(): want error 'nil', have '%s'", err)
+				}
+
+				start := tok.(xml.StartElement)
+				s.TokenReader = xmlstream.InnerElement(d)
+				if err := x.handlePresence(context.Background(), &s, &start, nil); err != nil {
+					t.Fatalf("XMPP.handlePresence(): want error 'nil', have '%s'", err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			err := r.wait(ctx)
+			if r.joined != tt.joined {
+				t.Fatalf("XMPP.handlePresence(): want joined '%v', have '%v'", tt.joined, r.joined)
+			} else if tt.joined && err != nil {
+				t.Fatalf("room.wait(): want error 'nil', have '%s'", err)
+			} else if tt.failed != (r.err != nil) {
+				t.Fatalf("XMPP.handlePresence(): want failed '%v', have error '%v'", tt.failed, r.err)
+			} else if !strings.Contains(s.out.String(), tt.encoded) {
+				t.Fatalf("XMPP.handlePresence(): want encoded '%s', have '%s'", tt.encoded, s.out.String())
+			} else if err := r.status(); tt.joined != (err == nil) {
+				t.Fatalf("room.status(): want joined '%v', have error '%v'", tt.joined, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package xmpp
+
+import (
+	// Standard library.
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+// Namespaces for XEP-0308: Last Message Correction, XEP-0461: Message Replies, and XEP-0359: Unique
+// and Stable Stanza IDs.
+const (
+	nsMessageCorrect = "urn:xmpp:message-correct:0"
+	nsReply          = "urn:xmpp:reply:0"
+	nsStanzaID       = "urn:xmpp:sid:0"
+)
+
+// Marker prepended to message bodies correcting messages sent for firing alerts.
+const resolvedMarker = "[RESOLVED] "
+
+// Amount of time messages sent for firing alerts are remembered for, in order to refer to these
+// once the alerts resolve.
+const sentMessageRetention = 7 * 24 * time.Hour
+
+// A ResolveMode determines how messages for resolved alerts refer to messages sent for the alerts
+// while firing.
+type resolveMode string
+
+// Modes supported for messages for resolved alerts.
+const (
+	resolveNew     resolveMode = "new"     // Messages are sent as new messages, with no reference to the original.
+	resolveCorrect resolveMode = "correct" // Original messages are corrected in place, as per XEP-0308.
+	resolveReply   resolveMode = "reply"   // Messages are sent as replies to the original, as per XEP-0461.
+)
+
+// MessageReplace is an XEP-0308 element, referring to the message being corrected.
+type messageReplace struct {
+	ID string `xml:"id,attr"`
+}
+
+// MessageReply is an XEP-0461 element, referring to the message being replied to, and its author.
+type messageReply struct {
+	To string `xml:"to,attr,omitempty"`
+	ID string `xml:"id,attr"`
+}
+
+// StanzaID is an XEP-0359 element, containing the ID assigned to a message by the entity given.
+type stanzaID struct {
+	ID string `xml:"id,attr"`
+	By string `xml:"by,attr"`
+}
+
+// A SentKey identifies messages sent for an alert to a specific recipient.
+type sentKey struct {
+	recipient string // The recipient JID, as configured.
+	alert     string // The alert fingerprint.
+}
+
+// A SentMessage represents a message sent for a firing alert.
+type sentMessage struct {
+	id       string    // The ID set by the client.
+	stanzaID string    // The ID assigned by the group chat, if any.
+	sentAt   time.Time // The time the message was sent.
+}
+
+// SentMessages keeps track of messages sent for firing alerts, per alert and recipient. Messages are
+// tracked in memory only, and are lost on restarts or when the destination is replaced on reload, in
+// which case messages for resolved alerts are sent as new messages.
+type sentMessages struct {
+	mu       sync.Mutex
+	messages map[sentKey]sentMessage
+}
+
+// Get returns the message last sent to the recipient given for the alert given, if any.
+func (s *sentMessages) get(recipient, alert string) (sentMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[sentKey{recipient, alert}]
+	return m, ok
+}
+
+// Set records the ID for the message sent to the recipient given for the alert given, replacing any
+// previously sent message. Messages older than the retention period are removed.
+func (s *sentMessages) set(recipient, alert, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var now = time.Now()
+	if s.messages == nil {
+		s.messages = make(map[sentKey]sentMessage)
+	}
+
+	maps.DeleteFunc(s.messages, func(_ sentKey, m sentMessage) bool { return now.Sub(m.sentAt) > sentMessageRetention })
+	s.messages[sentKey{recipient, alert}] = sentMessage{id: id, sentAt: now}
+}
+
+// SetStanzaID records the ID assigned by a group chat for the message with the client-set ID given.
+func (s *sentMessages) setStanzaID(id, stanzaID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, m := range s.messages {
+		if m.id == id {
+			m.stanzaID = stanzaID
+			s.messages[k] = m
+			return
+		}
+	}
+}
+
+// Remove removes any message recorded for the recipient and alert given.
+func (s *sentMessages) remove(recipient, alert string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.messages, sentKey{recipient, alert})
+}
+
+// AlertKey returns the key identifying the alert the given [gateway.Message] refers to, which is the
+// message fingerprint where set, or the key for tracked alert state otherwise.
+func alertKey(msg *gateway.Message) string {
+	if msg.Fingerprint != "" {
+		return msg.Fingerprint
+	} else if msg.Alert != nil {
+		return msg.Alert.Key
+	}
+	return ""
+}
+
+// ParseResolveMode returns the [resolveMode] for the name given.
+func parseResolveMode(v string) (resolveMode, error) {
+	switch m := resolveMode(v); m {
+	case resolveNew, resolveCorrect, resolveReply:
+		return m, nil
+	}
+
+	return "", fmt.Errorf("unknown mode '%s' for resolved alerts", v)
+}
+
+// NewID returns a random, hex-encoded identifier for message stanzas.
+func newID() string {
+	var buf [12]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
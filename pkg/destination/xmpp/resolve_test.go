@@ -0,0 +1,188 @@
+package xmpp
+
+import (
+	// Standard library.
+	"context"
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	// Third-party packages.
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestReference(t *testing.T) {
+	var testCases = []struct {
+		descr string
+		mode  resolveMode
+		to    string
+		kind  stanza.MessageType
+		sent  sentMessage
+
+		encoded string
+	}{
+		{
+			descr:   "new message",
+			mode:    resolveNew,
+			to:      "user@example.com",
+			kind:    stanza.ChatMessage,
+			sent:    sentMessage{id: "abc"},
+			encoded: `<body>Resolved</body></message>`,
+		},
+		{
+			descr:   "correction",
+			mode:    resolveCorrect,
+			to:      "user@example.com",
+			kind:    stanza.ChatMessage,
+			sent:    sentMessage{id: "abc"},
+			encoded: `<body>[RESOLVED] Resolved</body><replace xmlns="urn:xmpp:message-correct:0" id="abc"></replace>`,
+		},
+		{
+			descr:   "correction in group chat",
+			mode:    resolveCorrect,
+			to:      "room@muc.example.com",
+			kind:    stanza.GroupChatMessage,
+			sent:    sentMessage{id: "abc", stanzaID: "xyz"},
+			encoded: `<replace xmlns="urn:xmpp:message-correct:0" id="abc"></replace>`,
+		},
+		{
+			descr:   "reply",
+			mode:    resolveReply,
+			to:      "user@example.com",
+			kind:    stanza.ChatMessage,
+			sent:    sentMessage{id: "abc"},
+			encoded: `<body>Resolved</body><reply xmlns="urn:xmpp:reply:0" to="alerts@example.com/gateway" id="abc"></reply>`,
+		},
+		{
+			descr:   "reply in group chat",
+			mode:    resolveReply,
+			to:      "room@muc.example.com",
+			kind:    stanza.GroupChatMessage,
+			sent:    sentMessage{id: "abc", stanzaID: "xyz"},
+			encoded: `<reply xmlns="urn:xmpp:reply:0" to="room@muc.example.com/alerts" id="xyz"></reply>`,
+		},
+		{
+			descr:   "reply in group chat without stanza ID",
+			mode:    resolveReply,
+			to:      "room@muc.example.com",
+			kind:    stanza.GroupChatMessage,
+			sent:    sentMessage{id: "abc"},
+			encoded: `<reply xmlns="urn:xmpp:reply:0" to="room@muc.example.com/alerts" id="abc"></reply>`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var x = &XMPP{onResolve: tt.mode}
+			var r = newRoom(jid.MustParse("room@muc.example.com/alerts"), "")
+			x.rooms = map[string]*room{"room@muc.example.com": r}
+
+			if _, err := r.join(); err != nil {
+				t.Fatalf("room.join(): want error 'nil', have '%s'", err)
+			}
+
+			var m = Message{
+				Message: stanza.Message{ID: "def", To: jid.MustParse(tt.to), Type: tt.kind},
+				Body:    "Resolved",
+			}
+
+			x.reference(&m, tt.sent, jid.MustParse("alerts@example.com/gateway"))
+			buf, err := xml.Marshal(m)
+			if err != nil {
+				t.Fatalf("xml.Marshal(): want error 'nil', have '%s'", err)
+			} else if !strings.Contains(string(buf), tt.encoded) {
+				t.Fatalf("XMPP.reference(): want encoded '%s', have '%s'", tt.encoded, buf)
+			}
+		})
+	}
+}
+
+func TestSentMessages(t *testing.T) {
+	var s sentMessages
+	if _, ok := s.get("user@example.com", "a"); ok {
+		t.Fatalf("sentMessages.get(): want no message, have message")
+	}
+
+	s.set("user@example.com", "a", "abc")
+	s.set("room@muc.example.com/alerts", "a", "def")
+
+	if m, ok := s.get("user@example.com", "a"); !ok || m.id != "abc" {
+		t.Fatalf("sentMessages.get(): want message 'abc', have '%s'", m.id)
+	} else if _, ok := s.get("user@example.com", "b"); ok {
+		t.Fatalf("sentMessages.get(): want no message for unknown alert, have message")
+	}
+
+	s.setStanzaID("def", "xyz")
+	if m, _ := s.get("room@muc.example.com/alerts", "a"); m.stanzaID != "xyz" {
+		t.Fatalf("sentMessages.setStanzaID(): want stanza ID 'xyz', have '%s'", m.stanzaID)
+	}
+
+	// Messages past the retention period are removed when recording new messages.
+	s.messages[sentKey{"user@example.com", "old"}] = sentMessage{id: "old", sentAt: time.Now().Add(-2 * sentMessageRetention)}
+	s.set("user@example.com", "b", "ghi")
+	if _, ok := s.get("user@example.com", "old"); ok {
+		t.Fatalf("sentMessages.set(): want expired message removed, have message")
+	}
+
+	s.remove("user@example.com", "a")
+	if _, ok := s.get("user@example.com", "a"); ok {
+		t.Fatalf("sentMessages.remove(): want no message, have message")
+	}
+}
+
+func TestHandleMessageStanzaID(t *testing.T) {
+	var testCases = []struct {
+		descr   string
+		message string
+
+		stanzaID string
+	}{
+		{
+			descr:    "reflected message",
+			message:  `<message from="room@muc.example.com/alerts" type="groupchat" id="abc"><body>Test</body><stanza-id xmlns="urn:xmpp:sid:0" id="xyz" by="room@muc.example.com"/></message>`,
+			stanzaID: "xyz",
+		},
+		{
+			descr:   "message from other occupant",
+			message: `<message from="room@muc.example.com/other" type="groupchat" id="abc"><body>Test</body><stanza-id xmlns="urn:xmpp:sid:0" id="xyz" by="room@muc.example.com"/></message>`,
+		},
+		{
+			descr:   "stanza ID assigned by other entity",
+			message: `<message from="room@muc.example.com/alerts" type="groupchat" id="abc"><body>Test</body><stanza-id xmlns="urn:xmpp:sid:0" id="xyz" by="example.com"/></message>`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var x = &XMPP{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+			var r = newRoom(jid.MustParse("room@muc.example.com/alerts"), "")
+			x.rooms = map[string]*room{"room@muc.example.com": r}
+			x.sent.set("room@muc.example.com/alerts", "a", "abc")
+
+			if _, err := r.join(); err != nil {
+				t.Fatalf("room.join(): want error 'nil', have '%s'", err)
+			}
+
+			d := xml.NewDecoder(strings.NewReader(tt.message))
+			tok, err := d.Token()
+			if err != nil {
+				t.Fatalf("xml.Decoder.Token(): want error 'nil', have '%s'", err)
+			}
+
+			start := tok.(xml.StartElement)
+			s := testStream{TokenReader: xmlstream.InnerElement(d)}
+			if err := x.handleMessage(context.Background(), &s, &start, nil); err != nil {
+				t.Fatalf("XMPP.handleMessage(): want error 'nil', have '%s'", err)
+			}
+
+			if m, _ := x.sent.get("room@muc.example.com/alerts", "a"); m.stanzaID != tt.stanzaID {
+				t.Fatalf("XMPP.handleMessage(): want stanza ID '%s', have '%s'", tt.stanzaID, m.stanzaID)
+			}
+		})
+	}
+}
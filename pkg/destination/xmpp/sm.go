@@ -0,0 +1,169 @@
+package xmpp
+
+import (
+	// Standard library.
+	"encoding/xml"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Namespace for XEP-0198: Stream Management.
+const nsStreamManagement = "urn:xmpp:sm:3"
+
+// Elements used in XEP-0198: Stream Management negotiation and acknowledgements.
+type (
+	smEnable struct {
+		XMLName xml.Name `xml:"urn:xmpp:sm:3 enable"`
+	}
+	smRequest struct {
+		XMLName xml.Name `xml:"urn:xmpp:sm:3 r"`
+	}
+	smAnswer struct {
+		XMLName xml.Name `xml:"urn:xmpp:sm:3 a"`
+		H       uint32   `xml:"h,attr"`
+	}
+)
+
+// StreamManagement holds state for XEP-0198: Stream Management, as used for tracking stanzas sent
+// to, and acknowledged by the server. Since stream resumption is not supported, any stanzas left
+// unacknowledged when a connection is lost are returned for re-sending on a new connection.
+type streamManagement struct {
+	mu        sync.Mutex
+	stream    uint32 // The number of streams Stream Management has been enabled or disabled on.
+	enabled   bool   // Whether or not Stream Management has been requested on the stream.
+	confirmed bool   // Whether or not the server has confirmed Stream Management on the stream.
+	inbound   uint32 // The number of stanzas received since confirmation.
+	outbound  uint32 // The number of stanzas sent since being enabled.
+	unacked   []any  // Stanzas sent, but not yet acknowledged by the server, in order.
+
+	answered time.Time // The time the last acknowledgement was received from the server.
+}
+
+// Enable marks Stream Management as requested for the current stream, resetting all counters, and
+// returning any stanzas left unacknowledged from the previous stream.
+func (sm *streamManagement) enable() []any {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	// Stanzas not meant to be re-sent are tracked as nil values, and are skipped here.
+	var unacked = slices.DeleteFunc(sm.unacked, func(v any) bool { return v == nil })
+	sm.enabled, sm.confirmed = true, false
+	sm.inbound, sm.outbound, sm.unacked = 0, 0, nil
+	sm.stream++
+
+	return unacked
+}
+
+// Active returns whether or not Stream Management has been confirmed as active on the current
+// stream.
+func (sm *streamManagement) active() bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.confirmed
+}
+
+// Confirm marks Stream Management as active on the current stream, as confirmed by the server.
+func (sm *streamManagement) confirm() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.confirmed = sm.enabled
+}
+
+// Disable marks Stream Management as inactive on the current stream, e.g. when the server has
+// failed to enable it. Any stanzas previously tracked are dropped.
+func (sm *streamManagement) disable() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.enabled, sm.confirmed, sm.unacked = false, false, nil
+	sm.stream++
+}
+
+// Send calls the given function for sending a stanza, tracking the stanza for acknowledgement if
+// Stream Management is enabled. Stanzas given as nil are counted, but are not returned for
+// re-sending on new streams; this is useful for stanzas that only make sense in the context of the
+// current stream, e.g. replies to incoming requests.
+//
+// Stanzas are tracked ahead of being sent, as the lock is not held while sending, so that incoming
+// acknowledgement requests can be answered in the meantime. Stanzas that fail to send are returned
+// to the caller and are not re-sent on new streams, but are still counted as sent.
+func (sm *streamManagement) send(v any, fn func() error) error {
+	sm.mu.Lock()
+	var tracked, stream, seq = sm.enabled, sm.stream, sm.outbound
+	if tracked {
+		sm.outbound++
+		sm.unacked = append(sm.unacked, v)
+	}
+	sm.mu.Unlock()
+
+	if err := fn(); err != nil {
+		if tracked {
+			sm.forget(stream, seq)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Forget marks the stanza tracked under the stream and sequence number given as not to be re-sent
+// on new streams, if still unacknowledged on the current stream.
+func (sm *streamManagement) forget(stream, seq uint32) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	// Stanza counts are allowed to wrap around, as per the specification.
+	var acked = sm.outbound - uint32(len(sm.unacked))
+	if i := seq - acked; sm.stream == stream && i < uint32(len(sm.unacked)) {
+		sm.unacked[i] = nil
+	}
+}
+
+// Received increments the count of stanzas received from the server, if Stream Management is active.
+func (sm *streamManagement) received() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.confirmed {
+		sm.inbound++
+	}
+}
+
+// Handled returns the count of stanzas received from the server, for use in acknowledgements.
+func (sm *streamManagement) handled() uint32 {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.inbound
+}
+
+// Ack removes stanzas acknowledged by the server from the list of unacknowledged stanzas, given the
+// total count of stanzas handled by the server.
+func (sm *streamManagement) ack(h uint32) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	// Stanza counts are allowed to wrap around, as per the specification.
+	var acked = sm.outbound - uint32(len(sm.unacked))
+	var n = int(min(h-acked, uint32(len(sm.unacked))))
+	sm.unacked, sm.answered = sm.unacked[n:], time.Now()
+}
+
+// AnsweredSince returns whether or not an acknowledgement has been received from the server since
+// the time given.
+func (sm *streamManagement) answeredSince(t time.Time) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return !sm.answered.Before(t)
+}
+
+// ParseAnswer returns the count of handled stanzas for the given acknowledgement element.
+func parseAnswer(start *xml.StartElement) (uint32, bool) {
+	for _, a := range start.Attr {
+		if a.Name.Local == "h" {
+			h, err := strconv.ParseUint(a.Value, 10, 32)
+			return uint32(h), err == nil
+		}
+	}
+
+	return 0, false
+}
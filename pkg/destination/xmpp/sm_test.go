@@ -0,0 +1,88 @@
+package xmpp
+
+import (
+	// Standard library.
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStreamManagementAck(t *testing.T) {
+	var testCases = []struct {
+		descr   string
+		initial uint32
+		sent    []any
+		ack     uint32
+		unacked []any
+	}{
+		{
+			descr:   "partial acknowledgement",
+			sent:    []any{"a", "b", "c"},
+			ack:     2,
+			unacked: []any{"c"},
+		},
+		{
+			descr:   "full acknowledgement",
+			sent:    []any{"a", "b", "c"},
+			ack:     3,
+			unacked: []any{},
+		},
+		{
+			descr:   "acknowledgement with wrap-around",
+			initial: math.MaxUint32,
+			sent:    []any{"a", "b", "c"},
+			ack:     1,
+			unacked: []any{"c"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var sm streamManagement
+			sm.enable()
+			sm.outbound = tt.initial
+
+			for _, v := range tt.sent {
+				if err := sm.send(v, func() error { return nil }); err != nil {
+					t.Fatalf("streamManagement.send(): want error 'nil', have '%s'", err)
+				}
+			}
+
+			sm.ack(tt.ack)
+			if unacked := sm.enable(); !reflect.DeepEqual(unacked, tt.unacked) {
+				t.Fatalf("streamManagement.ack(%d): want unacked '%v', have '%v'", tt.ack, tt.unacked, unacked)
+			}
+		})
+	}
+}
+
+func TestStreamManagementSend(t *testing.T) {
+	var sm streamManagement
+	sm.enable()
+
+	// Acknowledgement requests can be answered while stanzas are being sent.
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = sm.send("a", func() error { sm.handled(); return nil })
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("streamManagement.send(): timed out waiting for send to complete")
+	}
+
+	// Stanzas failing to send are counted, but are not returned for re-sending.
+	if err := sm.send("b", func() error { return errors.New("failed") }); err == nil {
+		t.Fatalf("streamManagement.send(): want error 'failed', have 'nil'")
+	} else if err := sm.send("c", func() error { return nil }); err != nil {
+		t.Fatalf("streamManagement.send(): want error 'nil', have '%s'", err)
+	}
+
+	if unacked, want := sm.enable(), []any{"a", "c"}; !reflect.DeepEqual(unacked, want) {
+		t.Fatalf("streamManagement.enable(): want unacked '%v', have '%v'", want, unacked)
+	}
+}
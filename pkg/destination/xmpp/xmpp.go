@@ -4,20 +4,36 @@ import (
 	// Standard library.
 	"context"
 	"crypto/tls"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	// Internal packages.
 	"go.deuill.org/webhook-gateway/pkg/gateway"
 
 	// Third-party packages.
 	"mellium.im/sasl"
+	"mellium.im/xmlstream"
 	"mellium.im/xmpp"
 	"mellium.im/xmpp/dial"
 	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/stanza"
 )
 
+// Timeouts and intervals used in managing connections to the XMPP server.
+const (
+	connectTimeout    = 30 * time.Second
+	pingInterval      = time.Minute
+	pingTimeout       = 30 * time.Second
+	reconnectBackoff  = time.Second
+	reconnectMaxDelay = 5 * time.Minute
+)
+
 // DefaultAuthMechanisms represents the list of SASL authentication mechanisms this client is allowed
 // to use in server authentication.
 var defaultAuthMechanisms = []sasl.Mechanism{
@@ -28,14 +44,29 @@ var defaultAuthMechanisms = []sasl.Mechanism{
 	sasl.Plain,
 }
 
-// Message is an XMPP message containing simple body content.
+// Message is an XMPP message containing simple body content, optionally correcting or replying to
+// a previously sent message.
 type Message struct {
 	stanza.Message
-	Body string `xml:"body"`
+	Body    string          `xml:"body"`
+	Replace *messageReplace `xml:"urn:xmpp:message-correct:0 replace,omitempty"`
+	Reply   *messageReply   `xml:"urn:xmpp:reply:0 reply,omitempty"`
+}
+
+// Namespace for XEP-0199: XMPP Ping.
+const nsPing = "urn:xmpp:ping"
+
+// PingIQ is an IQ stanza containing an XEP-0199: XMPP Ping payload.
+type pingIQ struct {
+	stanza.IQ
+	Ping struct{} `xml:"urn:xmpp:ping ping"`
 }
 
 // XMPP represents a client connection to an XMPP server, used for pushing notification messages as
-// an authenticated user.
+// an authenticated user. Connections are monitored for liveness, and are re-established with
+// exponential backoff when lost; where supported by the server, XEP-0198: Stream Management is used
+// for ensuring that messages sent, but not acknowledged by the server before the connection was lost,
+// are sent again once re-connected.
 type XMPP struct {
 	// Client credentials.
 	clientJID      jid.JID // The JID to authenticate the XMPP client as.
@@ -47,47 +78,538 @@ type XMPP struct {
 	useStartTLS bool // Whether or not connection will be allowed to be made over StartTLS.
 
 	// Destination options.
-	recipientJIDs []jid.JID // The list of JIDs to push notifications to.
+	recipientJIDs []jid.JID         // The list of JIDs to push notifications to.
+	roomPasswords map[string]string // Passwords for group chats, keyed by bare room JID.
+	onResolve     resolveMode       // How messages for resolved alerts refer to messages for firing alerts.
 
 	// Internal fields.
-	session *xmpp.Session
+	mu        sync.Mutex
+	session   *xmpp.Session
+	ready     chan struct{} // Closed once a session is available, and replaced once the session is lost.
+	sm        streamManagement
+	rooms     map[string]*room   // Group chats joined, keyed by bare room JID.
+	sent      sentMessages       // Messages sent for firing alerts, per alert and recipient.
+	delivered deliveredMessages  // Recipients sent messages in pushes failing for other recipients.
+	stop      context.CancelFunc // Stops connection management, as started on [XMPP.Init].
+	done      chan struct{}      // Closed once connection management has stopped.
+	closed    bool               // Whether the destination has been closed.
+	lastErr   error              // The error for the last failed connection attempt, if any.
+	logger    *slog.Logger
+
+	// Establishes new sessions with the XMPP server, as overridden in tests.
+	newSession func(context.Context) (*xmpp.Session, error)
+}
+
+// Cost returns the number of stanzas sent for the given messages, as charged against rate limits,
+// i.e. one for each message and recipient.
+func (x *XMPP) Cost(messages ...*gateway.Message) int {
+	return len(messages) * len(x.recipientJIDs)
 }
 
 // PushMessages writes the given messages to the destination JID configured for the XMPP session.
+// Messages pushed while the client is not connected to the XMPP server wait for a connection to be
+// made, and fail if the given [context.Context] is cancelled before then.
+//
+// Messages are sent to each recipient separately, and failing to send messages to any recipient,
+// e.g. for group chats that cannot be joined, does not prevent messages from being sent to others.
+// Messages pushed again after failing, e.g. as retried by delivery queues, are only sent to the
+// recipients these were not already sent to.
+//
+// Messages for resolved alerts may refer to messages previously sent for the same alerts while
+// firing, either by correcting these in place, or by replying to these, depending on configuration.
 func (x *XMPP) PushMessages(ctx context.Context, messages ...*gateway.Message) error {
+	session, err := x.wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Messages are sent to direct recipients first, as group chats may need to be joined beforehand.
+	var recipients = slices.Concat(
+		slices.DeleteFunc(slices.Clone(x.recipientJIDs), func(j jid.JID) bool { return j.Resourcepart() != "" }),
+		slices.DeleteFunc(slices.Clone(x.recipientJIDs), func(j jid.JID) bool { return j.Resourcepart() == "" }),
+	)
+
+	var errs []error
+	for _, r := range recipients {
+		if err := x.pushRecipient(ctx, session, r, messages); err != nil {
+			errs = append(errs, fmt.Errorf("failed pushing messages to '%s': %w", r, err))
+		}
+	}
+
+	// Request acknowledgement for messages sent, if supported.
+	if _, ok := session.Feature(nsStreamManagement); ok {
+		if err := session.Encode(ctx, smRequest{}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	x.delivered.remove(messages...)
+	return nil
+}
+
+// PushRecipient sends the given messages to the recipient given, in order, skipping any messages
+// already sent to the recipient in previous pushes. Messages for group chats are only sent once the
+// group chat has been joined.
+func (x *XMPP) pushRecipient(ctx context.Context, session *xmpp.Session, recipient jid.JID, messages []*gateway.Message) error {
+	// Determine whether this is a direct or group-chat message from the resource part of the JID,
+	// which is only set if the message was destined for a group-chat.
+	var to, kind = recipient, stanza.ChatMessage
+	if recipient.Resourcepart() != "" {
+		to, kind = recipient.Bare(), stanza.GroupChatMessage
+		if err := x.rooms[to.String()].wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	var name = recipient.String()
 	for _, msg := range messages {
-		for _, jid := range x.recipientJIDs {
-			// Determine whether this is a direct or group-chat message from the resource part of
-			// the JID, which is only set if the message was destined for a group-chat.
-			var kind = stanza.ChatMessage
-			if jid.Resourcepart() != "" {
-				jid, kind = jid.Bare(), stanza.GroupChatMessage
-			}
+		if x.delivered.has(msg, name) {
+			continue
+		}
 
-			var m = Message{
-				Message: stanza.Message{To: jid, Type: kind},
-				Body:    msg.Content,
-			}
+		var m = Message{
+			Message: stanza.Message{ID: newID(), To: to, Type: kind},
+			Body:    msg.Content,
+		}
 
-			// TODO: Log rather than return error here.
-			if err := x.session.Encode(ctx, m); err != nil {
-				return err
+		var key = alertKey(msg)
+		if key != "" && x.onResolve != resolveNew && msg.Status == gateway.StatusResolved {
+			if sent, ok := x.sent.get(name, key); ok {
+				x.reference(&m, sent, session.LocalAddr())
 			}
 		}
+
+		if err := x.send(ctx, session, m); err != nil {
+			return err
+		}
+
+		x.delivered.add(msg, name)
+		x.logger.DebugContext(ctx, "Sent message to XMPP recipient", "recipient", m.To.String(), "message-id", m.ID)
+		if key == "" || x.onResolve == resolveNew {
+			continue
+		}
+
+		switch msg.Status {
+		case gateway.StatusFiring:
+			x.sent.set(name, key, m.ID)
+		case gateway.StatusResolved:
+			x.sent.remove(name, key)
+		}
 	}
 
 	return nil
 }
 
+// Reference sets the given [Message] as referring to the message previously sent, either as a
+// correction, marked as resolved, or as a reply, depending on configuration. Replies in group chats
+// refer to the ID assigned by the group chat, where known.
+func (x *XMPP) reference(m *Message, sent sentMessage, from jid.JID) {
+	switch x.onResolve {
+	case resolveCorrect:
+		m.Replace = &messageReplace{ID: sent.id}
+		m.Body = resolvedMarker + m.Body
+	case resolveReply:
+		var reply = &messageReply{To: from.String(), ID: sent.id}
+		if r, ok := x.rooms[m.To.String()]; ok && m.Type == stanza.GroupChatMessage {
+			reply.To = r.occupantJID().String()
+			if sent.stanzaID != "" {
+				reply.ID = sent.stanzaID
+			}
+		}
+		m.Reply = reply
+	}
+}
+
 // Init ensures the [XMPP] destination is configured correctly, and initializes a client connection
-// to the XMPP server pointed to by the client JID configured, authenticating if necessary.
+// to the XMPP server pointed to by the client JID configured, authenticating if necessary. The
+// connection is then managed in the background, until the given [context.Context] is cancelled.
+// Failing to connect initially does not return an error, as connections are retried in the
+// background with exponential backoff, and are reported as unhealthy in the meantime.
 func (x *XMPP) Init(ctx context.Context) error {
 	if x.clientJID.Equal(jid.JID{}) {
 		return fmt.Errorf("empty client JID given in configuration")
 	} else if len(x.recipientJIDs) == 0 {
 		return fmt.Errorf("no recipient JIDs given in configuration")
+	} else if x.logger == nil {
+		x.logger = slog.Default()
+	}
+
+	if x.onResolve == "" {
+		x.onResolve = resolveNew
+	}
+	if x.newSession == nil {
+		x.newSession = x.connect
+	}
+
+	// Determine group chats to join from recipients, which are the only ones set with a resource part.
+	x.rooms = make(map[string]*room)
+	for _, jid := range x.recipientJIDs {
+		if jid.Resourcepart() != "" {
+			x.rooms[jid.Bare().String()] = newRoom(jid, x.roomPasswords[jid.Bare().String()])
+		}
+	}
+
+	session, err := x.newSession(ctx)
+	if err != nil {
+		x.logger.ErrorContext(ctx, "Failed connecting to XMPP server", "jid", x.clientJID.String(), "error", err.Error())
 	}
 
+	x.mu.Lock()
+	ctx, x.stop = context.WithCancel(ctx)
+	x.done, x.ready, x.lastErr = make(chan struct{}), make(chan struct{}), err
+	x.mu.Unlock()
+
+	go func(done chan struct{}) {
+		defer close(done)
+		x.run(ctx, session)
+	}(x.done)
+
+	return nil
+}
+
+// Close sends unavailable presence to the XMPP server, which is also forwarded to any recipients
+// and group chats, and closes the XMPP stream, returning once the server has closed its side of
+// the stream, or once the given [context.Context] is cancelled, whichever comes first. No further
+// connections are made once closed.
+func (x *XMPP) Close(ctx context.Context) error {
+	x.mu.Lock()
+	session, stop, done := x.session, x.stop, x.done
+	x.closed = true
+	x.mu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+
+	defer stop()
+
+	var err error
+	if session != nil {
+		if err = session.Send(ctx, stanza.Presence{Type: stanza.UnavailablePresence}.Wrap(nil)); err != nil {
+			err = fmt.Errorf("failed sending unavailable presence: %w", err)
+		} else if err = session.Close(); err != nil {
+			err = fmt.Errorf("failed closing XMPP stream: %w", err)
+		}
+	}
+
+	// Wait for the server to close the stream, closing the connection forcibly if this takes too long.
+	if session == nil || err != nil {
+		stop()
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		stop()
+		<-done
+	}
+
+	x.logger.InfoContext(ctx, "Closed connection to XMPP server", "jid", x.clientJID.String())
+	return err
+}
+
+// Healthy returns an error if the client is not currently connected to the XMPP server, including
+// the error for the last failed connection attempt, if any, or if any group chats configured have
+// not been joined.
+func (x *XMPP) Healthy(context.Context) error {
+	x.mu.Lock()
+	connected, lastErr := x.session != nil, x.lastErr
+	x.mu.Unlock()
+
+	if !connected && lastErr != nil {
+		return fmt.Errorf("not connected to XMPP server: %w", lastErr)
+	} else if !connected {
+		return fmt.Errorf("not connected to XMPP server")
+	}
+
+	for _, r := range x.rooms {
+		if err := r.status(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Connected returns whether the client is currently connected to the XMPP server.
+func (x *XMPP) Connected() bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.session != nil
+}
+
+// Wait blocks until a session with the XMPP server is available, returning an error if the given
+// [context.Context] is cancelled before then, including the error for the last failed connection
+// attempt, if any.
+func (x *XMPP) wait(ctx context.Context) (*xmpp.Session, error) {
+	x.mu.Lock()
+	session, ready := x.session, x.ready
+	x.mu.Unlock()
+
+	if session != nil {
+		return session, nil
+	} else if ready == nil {
+		return nil, fmt.Errorf("not connected to XMPP server")
+	}
+
+	select {
+	case <-ready:
+		return x.wait(ctx)
+	case <-ctx.Done():
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.lastErr != nil {
+		return nil, fmt.Errorf("not connected to XMPP server: %w", x.lastErr)
+	}
+
+	return nil, fmt.Errorf("not connected to XMPP server")
+}
+
+// IsClosed returns whether the destination has been closed.
+func (x *XMPP) isClosed() bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.closed
+}
+
+// Run serves the given session until the connection is lost, re-connecting with exponential backoff
+// and serving the new session, until the given context is cancelled. Nil sessions, as left by failed
+// initial connection attempts, are re-connected immediately.
+func (x *XMPP) run(ctx context.Context, session *xmpp.Session) {
+	for {
+		if session != nil {
+			x.serve(ctx, session)
+		}
+		if ctx.Err() != nil || x.isClosed() {
+			return
+		}
+
+		for delay := reconnectBackoff; ; delay = min(delay*2, reconnectMaxDelay) {
+			x.logger.WarnContext(ctx, "Not connected to XMPP server, reconnecting", "jid", x.clientJID.String(), "delay", delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			var err error
+			session, err = x.newSession(ctx)
+
+			x.mu.Lock()
+			x.lastErr = err
+			x.mu.Unlock()
+
+			if err == nil {
+				x.logger.InfoContext(ctx, "Reconnected to XMPP server", "jid", x.clientJID.String())
+				break
+			}
+
+			x.logger.ErrorContext(ctx, "Failed reconnecting to XMPP server", "jid", x.clientJID.String(), "error", err.Error())
+		}
+	}
+}
+
+// Serve handles incoming stanzas for the given session, returning once the session is closed. The
+// session is made available for pushing messages, and is periodically checked for liveness while
+// being served.
+func (x *XMPP) serve(ctx context.Context, session *xmpp.Session) {
+	// Enable Stream Management, if supported, and send any stanzas left unacknowledged in previous
+	// sessions.
+	if _, ok := session.Feature(nsStreamManagement); ok {
+		if err := session.Encode(ctx, smEnable{}); err != nil {
+			x.logger.ErrorContext(ctx, "Failed enabling stream management", "error", err.Error())
+		}
+
+		for _, v := range x.sm.enable() {
+			if err := x.send(ctx, session, v); err != nil {
+				x.logger.ErrorContext(ctx, "Failed re-sending unacknowledged stanza", "error", err.Error())
+			}
+		}
+	} else {
+		x.sm.disable()
+	}
+
+	x.mu.Lock()
+	x.session = session
+	close(x.ready)
+	x.mu.Unlock()
+
+	x.joinRooms(ctx, session)
+
+	var done = make(chan struct{})
+	go x.keepalive(ctx, session, done)
+
+	var handler = xmpp.HandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		return x.handleXMPP(ctx, t, start, session)
+	})
+
+	if err := session.Serve(handler); err != nil && ctx.Err() == nil {
+		x.logger.WarnContext(ctx, "Failed serving XMPP session", "error", err.Error())
+	}
+
+	close(done)
+	x.mu.Lock()
+	x.session, x.ready = nil, make(chan struct{})
+	x.mu.Unlock()
+
+	// Ensure the underlying connection is closed, in case the session was closed remotely.
+	_ = session.Conn().Close()
+}
+
+// Keepalive periodically pings the XMPP server on the given session, closing the underlying
+// connection if no response is received in time; this will cause the session to stop being served,
+// and a new connection to be made. Keep-alive checks stop when the given channel is closed.
+//
+// Where Stream Management is active, acknowledgement requests are used in place of XEP-0199 pings,
+// as these would otherwise need to be accounted for as stanzas sent.
+func (x *XMPP) keepalive(ctx context.Context, session *xmpp.Session, done <-chan struct{}) {
+	var ticker = time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			_ = session.Conn().Close()
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if err := x.ping(ctx, session, done); err != nil {
+			x.logger.WarnContext(ctx, "Failed pinging XMPP server, closing connection", "error", err.Error())
+			_ = session.Conn().Close()
+			return
+		}
+	}
+}
+
+// Ping checks that the XMPP server is responsive on the given session, returning an error if no
+// response is received in time.
+func (x *XMPP) ping(ctx context.Context, session *xmpp.Session, done <-chan struct{}) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	if !x.sm.active() {
+		resp, err := session.EncodeIQ(ctx, pingIQ{
+			IQ: stanza.IQ{Type: stanza.GetIQ, To: x.clientJID.Domain()},
+		})
+		if err != nil {
+			return err
+		}
+		return resp.Close()
+	}
+
+	var start = time.Now()
+	if err := session.Encode(ctx, smRequest{}); err != nil {
+		return err
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	if !x.sm.answeredSince(start) {
+		return fmt.Errorf("no acknowledgement received from server")
+	}
+
+	return nil
+}
+
+// HandleXMPP handles incoming elements for the given session, keeping track of handled stanzas and
+// acknowledgements for Stream Management, and group chat occupancy.
+func (x *XMPP) handleXMPP(ctx context.Context, t xmlstream.TokenReadEncoder, start *xml.StartElement, session *xmpp.Session) error {
+	if start.Name.Space != nsStreamManagement {
+		switch start.Name.Local {
+		case "iq":
+			x.sm.received()
+			return x.handleIQ(t, start)
+		case "presence":
+			x.sm.received()
+			return x.handlePresence(ctx, t, start, session)
+		case "message":
+			x.sm.received()
+			return x.handleMessage(ctx, t, start, session)
+		}
+		return nil
+	}
+
+	switch start.Name.Local {
+	case "enabled":
+		x.sm.confirm()
+	case "failed":
+		x.logger.WarnContext(ctx, "Failed enabling stream management on XMPP server")
+		x.sm.disable()
+	case "a":
+		if h, ok := parseAnswer(start); ok {
+			x.sm.ack(h)
+		}
+	case "r":
+		return t.Encode(smAnswer{H: x.sm.handled()})
+	}
+
+	return nil
+}
+
+// HandleIQ responds to incoming IQ requests, answering XEP-0199 pings and returning errors for
+// anything else. Responses are written here, rather than being left to the session, so that these are
+// accounted for in Stream Management.
+func (x *XMPP) handleIQ(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	iq, err := stanza.NewIQ(*start)
+	if err != nil {
+		return err
+	} else if iq.Type != stanza.GetIQ && iq.Type != stanza.SetIQ {
+		return nil
+	}
+
+	var resp = stanza.IQ{ID: iq.ID, To: iq.From, Type: stanza.ResultIQ}
+	var payload xml.TokenReader
+
+	// Find the first child element of the IQ, which determines the type of request made.
+	var child xml.Name
+	for {
+		tok, err := t.Token()
+		if err != nil {
+			break
+		} else if s, ok := tok.(xml.StartElement); ok {
+			child = s.Name
+			break
+		}
+	}
+
+	if child.Space != nsPing || iq.Type != stanza.GetIQ {
+		resp.Type = stanza.ErrorIQ
+		payload = stanza.Error{Type: stanza.Cancel, Condition: stanza.ServiceUnavailable}.TokenReader()
+	}
+
+	return x.sm.send(nil, func() error {
+		_, err := xmlstream.Copy(t, resp.Wrap(payload))
+		return err
+	})
+}
+
+// Send writes the given stanza to the session, tracking it for acknowledgement where Stream
+// Management is enabled.
+func (x *XMPP) send(ctx context.Context, session *xmpp.Session, v any) error {
+	return x.sm.send(v, func() error { return session.Encode(ctx, v) })
+}
+
+// Connect initializes a client connection to the XMPP server pointed to by the client JID
+// configured, authenticating if necessary, and sending initial presence to the server and any
+// recipients.
+func (x *XMPP) connect(ctx context.Context) (*xmpp.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
 	// Initialze connection according to configuration.
 	var tlsConfig = &tls.Config{
 		ServerName:         x.clientJID.Domain().String(),
@@ -101,7 +623,7 @@ func (x *XMPP) Init(ctx context.Context) error {
 
 	conn, err := dialer.Dial(ctx, "tcp", x.clientJID)
 	if err != nil {
-		return fmt.Errorf("connection to XMPP server failed: %w", err)
+		return nil, fmt.Errorf("connection to XMPP server failed: %w", err)
 	}
 
 	// Enable optional features and initialize client session, according to configuration.
@@ -115,26 +637,30 @@ func (x *XMPP) Init(ctx context.Context) error {
 
 	session, err := xmpp.NewClientSession(ctx, x.clientJID, conn, features...)
 	if err != nil {
-		return fmt.Errorf("connection to XMPP server failed: %w", err)
+		_ = conn.Close()
+		return nil, fmt.Errorf("connection to XMPP server failed: %w", err)
 	}
 
-	x.session = session
-
 	// Send initial presence to let the server know we want to send messages.
-	err = x.session.Send(ctx, stanza.Presence{Type: stanza.AvailablePresence}.Wrap(nil))
+	err = session.Send(ctx, stanza.Presence{Type: stanza.AvailablePresence}.Wrap(nil))
 	if err != nil {
-		return fmt.Errorf("setting initial XMPP presence failed: %w", err)
+		_ = session.Conn().Close()
+		return nil, fmt.Errorf("setting initial XMPP presence failed: %w", err)
 	}
 
-	// Send available presences to recipients.
+	// Send available presences to direct recipients; group chats are joined separately.
 	for _, jid := range x.recipientJIDs {
-		err = x.session.Send(ctx, stanza.Presence{Type: stanza.AvailablePresence, To: jid}.Wrap(nil))
+		if jid.Resourcepart() != "" {
+			continue
+		}
+		err = session.Send(ctx, stanza.Presence{Type: stanza.AvailablePresence, To: jid}.Wrap(nil))
 		if err != nil {
-			return fmt.Errorf("sending XMPP presence to %s failed: %w", jid, err)
+			_ = session.Conn().Close()
+			return nil, fmt.Errorf("sending XMPP presence to %s failed: %w", jid, err)
 		}
 	}
 
-	return nil
+	return session, nil
 }
 
 // UnmarshalTOML configures the [XMPP] destination based on values sourced from TOML configuration.
@@ -168,6 +694,27 @@ func (x *XMPP) UnmarshalTOML(data any) error {
 		}
 	}
 
+	if v, ok := conf["room-passwords"].(map[string]any); ok {
+		x.roomPasswords = make(map[string]string, len(v))
+		for k, p := range v {
+			id, err := jid.Parse(k)
+			if err != nil {
+				return fmt.Errorf("failed parsing group chat JID: %w", err)
+			} else if p, ok := p.(string); ok {
+				x.roomPasswords[id.Bare().String()] = p
+			}
+		}
+	}
+
+	if v, ok := conf["on-resolve"].(string); ok {
+		mode, err := parseResolveMode(v)
+		if err != nil {
+			return err
+		}
+
+		x.onResolve = mode
+	}
+
 	if v, ok := conf["no-tls"].(bool); ok {
 		x.noTLS = v
 	}
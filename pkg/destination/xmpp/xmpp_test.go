@@ -0,0 +1,278 @@
+package xmpp
+
+import (
+	// Standard library.
+	"context"
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+
+	// Third-party packages.
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+)
+
+// A TestServer is an in-process XMPP server, accepting client sessions over in-memory connections,
+// and recording the recipients and bodies of any messages received, along with the ID of any message
+// corrected or replied to.
+type testServer struct {
+	messages chan string
+}
+
+// Connect returns a new client session for the JID given, as connected to the [testServer].
+func (s *testServer) connect(ctx context.Context, origin jid.JID) (*xmpp.Session, error) {
+	client, server := net.Pipe()
+
+	// Negotiators keep state, and cannot be shared between sessions.
+	var negotiator = func() xmpp.Negotiator {
+		return xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+			return xmpp.StreamConfig{Features: []xmpp.StreamFeature{xmpp.BindResource()}}
+		})
+	}
+
+	go func() {
+		session, err := xmpp.ReceiveSession(ctx, server, xmpp.Secure|xmpp.Authn, negotiator())
+		if err != nil {
+			_ = server.Close()
+			return
+		}
+
+		_ = session.Serve(xmpp.HandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			var m Message
+			if start.Name.Local == "message" && decodeElement(t, start, &m) == nil {
+				var ref string
+				if m.Replace != nil {
+					ref = " (replaces " + m.Replace.ID + ")"
+				} else if m.Reply != nil {
+					ref = " (replies to " + m.Reply.ID + ")"
+				}
+				s.messages <- m.To.String() + ": " + m.Body + ref
+			}
+			return nil
+		}))
+
+		_ = session.Close()
+	}()
+
+	return xmpp.NewSession(ctx, origin.Domain(), origin, client, xmpp.Secure|xmpp.Authn, negotiator())
+}
+
+func TestXMPPInitOffline(t *testing.T) {
+	// Connections to the local host are expected to be refused, as no XMPP server is running.
+	var x = &XMPP{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	err := x.UnmarshalTOML(map[string]any{"jid": "test@127.0.0.1", "recipients": "alice@example.com", "no-tls": true})
+	if err != nil {
+		t.Fatalf("XMPP.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := x.Init(ctx); err != nil {
+		t.Fatalf("XMPP.Init(): want error 'nil', have '%s'", err)
+	} else if err := x.Healthy(ctx); err == nil || !strings.Contains(err.Error(), "not connected") {
+		t.Fatalf("XMPP.Healthy(): want error for failed connection, have '%v'", err)
+	} else if err := x.Close(ctx); err != nil {
+		t.Fatalf("XMPP.Close(): want error 'nil', have '%s'", err)
+	}
+}
+
+func TestXMPPPushMessages(t *testing.T) {
+	var server = &testServer{messages: make(chan string, 10)}
+	var x = &XMPP{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	err := x.UnmarshalTOML(map[string]any{"jid": "test@example.com", "recipients": "alice@example.com"})
+	if err != nil {
+		t.Fatalf("XMPP.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	}
+
+	x.newSession = func(ctx context.Context) (*xmpp.Session, error) {
+		return server.connect(ctx, x.clientJID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Messages pushed immediately after initialization are expected to wait for the session to be
+	// served, rather than failing outright.
+	if err := x.Init(ctx); err != nil {
+		t.Fatalf("XMPP.Init(): want error 'nil', have '%s'", err)
+	} else if err := x.PushMessages(ctx, &gateway.Message{Content: "Hello"}); err != nil {
+		t.Fatalf("XMPP.PushMessages(): want error 'nil', have '%s'", err)
+	}
+
+	select {
+	case body := <-server.messages:
+		if body != "alice@example.com: Hello" {
+			t.Fatalf("XMPP.PushMessages(): want message 'Hello' for 'alice@example.com', have '%s'", body)
+		}
+	case <-ctx.Done():
+		t.Fatalf("XMPP.PushMessages(): want message received, have none")
+	}
+
+	if err := x.Close(ctx); err != nil {
+		t.Fatalf("XMPP.Close(): want error 'nil', have '%s'", err)
+	}
+}
+
+func TestXMPPPushMessagesPartial(t *testing.T) {
+	var server = &testServer{messages: make(chan string, 10)}
+	var x = &XMPP{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	err := x.UnmarshalTOML(map[string]any{"jid": "test@example.com", "recipients": "room@muc.example.com/alerts alice@example.com"})
+	if err != nil {
+		t.Fatalf("XMPP.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	}
+
+	x.newSession = func(ctx context.Context) (*xmpp.Session, error) {
+		return server.connect(ctx, x.clientJID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := x.Init(ctx); err != nil {
+		t.Fatalf("XMPP.Init(): want error 'nil', have '%s'", err)
+	}
+
+	// Messages are expected to be sent to direct recipients, even if group chats are yet to be joined.
+	var messages = []*gateway.Message{{Content: "Hello"}, {Content: "World"}}
+	pushCtx, pushCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer pushCancel()
+
+	if err := x.PushMessages(pushCtx, messages...); err == nil || !strings.Contains(err.Error(), "room@muc.example.com") {
+		t.Fatalf("XMPP.PushMessages(): want error for group chat, have '%v'", err)
+	}
+
+	// Messages pushed again are expected to only be sent to recipients not already sent these.
+	x.rooms["room@muc.example.com"].setJoined(jid.MustParse("room@muc.example.com/alerts"))
+	if err := x.PushMessages(ctx, messages...); err != nil {
+		t.Fatalf("XMPP.PushMessages(): want error 'nil', have '%s'", err)
+	}
+
+	var want = []string{
+		"alice@example.com: Hello", "alice@example.com: World",
+		"room@muc.example.com: Hello", "room@muc.example.com: World",
+	}
+
+	for _, w := range want {
+		select {
+		case have := <-server.messages:
+			if have != w {
+				t.Fatalf("XMPP.PushMessages(): want message '%s', have '%s'", w, have)
+			}
+		case <-ctx.Done():
+			t.Fatalf("XMPP.PushMessages(): want message '%s', have none", w)
+		}
+	}
+
+	if err := x.Close(ctx); err != nil {
+		t.Fatalf("XMPP.Close(): want error 'nil', have '%s'", err)
+	} else if len(server.messages) != 0 {
+		t.Fatalf("XMPP.PushMessages(): want no further messages, have '%s'", <-server.messages)
+	} else if len(x.delivered.messages) != 0 {
+		t.Fatalf("XMPP.PushMessages(): want no messages tracked once sent to all recipients, have %d", len(x.delivered.messages))
+	}
+}
+
+func TestXMPPPushMessagesResolved(t *testing.T) {
+	var server = &testServer{messages: make(chan string, 10)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var start = func() *XMPP {
+		var x = &XMPP{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+		err := x.UnmarshalTOML(map[string]any{"jid": "test@example.com", "recipients": "alice@example.com", "on-resolve": "correct"})
+		if err != nil {
+			t.Fatalf("XMPP.UnmarshalTOML(): want error 'nil', have '%s'", err)
+		}
+
+		x.newSession = func(ctx context.Context) (*xmpp.Session, error) {
+			return server.connect(ctx, x.clientJID)
+		}
+
+		if err := x.Init(ctx); err != nil {
+			t.Fatalf("XMPP.Init(): want error 'nil', have '%s'", err)
+		}
+		return x
+	}
+
+	var receive = func() string {
+		select {
+		case body := <-server.messages:
+			return body
+		case <-ctx.Done():
+			t.Fatalf("XMPP.PushMessages(): want message received, have none")
+		}
+		return ""
+	}
+
+	var firing = &gateway.Message{Content: "Disk full", Fingerprint: "a", Status: gateway.StatusFiring}
+	var resolved = &gateway.Message{Content: "Disk no longer full", Fingerprint: "a", Status: gateway.StatusResolved}
+
+	// Messages for resolved alerts correct the message sent while the alert was firing.
+	var x = start()
+	if err := x.PushMessages(ctx, firing); err != nil {
+		t.Fatalf("XMPP.PushMessages(): want error 'nil', have '%s'", err)
+	} else if body := receive(); body != "alice@example.com: Disk full" {
+		t.Fatalf("XMPP.PushMessages(): want firing message, have '%s'", body)
+	} else if err := x.PushMessages(ctx, resolved); err != nil {
+		t.Fatalf("XMPP.PushMessages(): want error 'nil', have '%s'", err)
+	} else if body := receive(); !strings.HasPrefix(body, "alice@example.com: [RESOLVED] Disk no longer full (replaces ") {
+		t.Fatalf("XMPP.PushMessages(): want correction for resolved message, have '%s'", body)
+	} else if err := x.Close(ctx); err != nil {
+		t.Fatalf("XMPP.Close(): want error 'nil', have '%s'", err)
+	}
+
+	// Messages sent are tracked in memory only, and messages for alerts resolving after a restart
+	// are thus sent as new messages, with no reference to the message sent while firing.
+	x = start()
+	if err := x.PushMessages(ctx, firing); err != nil {
+		t.Fatalf("XMPP.PushMessages(): want error 'nil', have '%s'", err)
+	} else if body := receive(); body != "alice@example.com: Disk full" {
+		t.Fatalf("XMPP.PushMessages(): want firing message, have '%s'", body)
+	} else if err := x.Close(ctx); err != nil {
+		t.Fatalf("XMPP.Close(): want error 'nil', have '%s'", err)
+	}
+
+	x = start()
+	if err := x.PushMessages(ctx, resolved); err != nil {
+		t.Fatalf("XMPP.PushMessages(): want error 'nil', have '%s'", err)
+	} else if body := receive(); body != "alice@example.com: Disk no longer full" {
+		t.Fatalf("XMPP.PushMessages(): want new message for resolved alert, have '%s'", body)
+	} else if err := x.Close(ctx); err != nil {
+		t.Fatalf("XMPP.Close(): want error 'nil', have '%s'", err)
+	}
+}
+
+func TestXMPPPushMessagesOffline(t *testing.T) {
+	var x = &XMPP{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	err := x.UnmarshalTOML(map[string]any{"jid": "test@127.0.0.1", "recipients": "alice@example.com", "no-tls": true})
+	if err != nil {
+		t.Fatalf("XMPP.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := x.Init(ctx); err != nil {
+		t.Fatalf("XMPP.Init(): want error 'nil', have '%s'", err)
+	}
+
+	// Messages pushed while offline are expected to fail once the context given is cancelled, with
+	// the error for the last connection attempt made.
+	pushCtx, pushCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer pushCancel()
+
+	if err := x.PushMessages(pushCtx, &gateway.Message{Content: "Hello"}); err == nil || !strings.Contains(err.Error(), "connection to XMPP server failed") {
+		t.Fatalf("XMPP.PushMessages(): want error for failed connection, have '%v'", err)
+	} else if err := x.Close(ctx); err != nil {
+		t.Fatalf("XMPP.Close(): want error 'nil', have '%s'", err)
+	}
+}
@@ -0,0 +1,173 @@
+package gateway
+
+import (
+	// Standard library.
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Default amount of time resolved alerts are retained for by [AlertTracker] instances.
+const defaultAlertRetention = 24 * time.Hour
+
+// An Alert represents the state of an alert, as tracked across the notification messages referring
+// to it. Alerts are identified by a key derived from the message fingerprint or labels, and scoped
+// to the gateway receiving messages.
+type Alert struct {
+	Key           string            `json:"key"`
+	Gateway       string            `json:"gateway,omitempty"`
+	Fingerprint   string            `json:"fingerprint,omitempty"`
+	Status        Status            `json:"status"`
+	Title         string            `json:"title,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	StartsAt      time.Time         `json:"starts_at"`
+	EndsAt        time.Time         `json:"ends_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Notifications int               `json:"notifications"`
+}
+
+// Duration returns the amount of time the [Alert] has been firing for, or was firing for before
+// being resolved, rounded to the nearest second.
+func (a *Alert) Duration() time.Duration {
+	var end = a.EndsAt
+	if a.Status != StatusResolved || end.IsZero() {
+		end = time.Now()
+	}
+
+	return end.Sub(a.StartsAt).Round(time.Second)
+}
+
+// An AlertTracker keeps track of alert state across notification messages, allowing for resolved
+// alerts to be correlated with the firing alerts they refer to. Resolved alerts are retained for a
+// period of time, and state is optionally persisted to a file, allowing for tracking to continue
+// across restarts.
+type AlertTracker struct {
+	retention time.Duration
+	path      string
+
+	// Internal fields.
+	mu     sync.Mutex
+	alerts map[string]*Alert
+}
+
+// NewAlertTracker returns an empty [AlertTracker], retaining resolved alerts for the given amount
+// of time. If a retention of zero is given, a default retention of one day is used.
+func NewAlertTracker(retention time.Duration) *AlertTracker {
+	if retention <= 0 {
+		retention = defaultAlertRetention
+	}
+	return &AlertTracker{retention: retention, alerts: make(map[string]*Alert)}
+}
+
+// Load sets the path used for persisting state, and loads any existing state from it. Missing files
+// are not considered an error, and will be created as needed.
+func (t *AlertTracker) Load(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.path = path
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed reading alert state: %w", err)
+	}
+
+	var alerts map[string]*Alert
+	if err := json.Unmarshal(buf, &alerts); err != nil {
+		return fmt.Errorf("failed parsing alert state: %w", err)
+	}
+
+	maps.Copy(t.alerts, alerts)
+	t.prune(time.Now())
+
+	return nil
+}
+
+// Track updates state for the alert with the key given, as received by the gateway path given, for
+// the [Message] given, and returns a copy of the updated [Alert]. Firing messages start tracking for
+// alerts not already firing, with the start time taken from the message timestamp, and resolved
+// messages mark alerts as resolved, with the end time set to the current time. Messages without a
+// status are not tracked, and return no alert.
+func (t *AlertTracker) Track(path, key string, msg *Message) (*Alert, error) {
+	if key == "" || (msg.Status != StatusFiring && msg.Status != StatusResolved) {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var now = time.Now()
+	t.prune(now)
+
+	a, ok := t.alerts[key]
+	if !ok || (a.Status == StatusResolved && msg.Status == StatusFiring) {
+		a = &Alert{Key: key, Gateway: path, StartsAt: msg.Timestamp}
+		t.alerts[key] = a
+	}
+
+	a.Fingerprint, a.Title, a.Labels = msg.Fingerprint, msg.Title, msg.Labels
+	a.UpdatedAt = now
+	a.Notifications++
+
+	if msg.Status == StatusResolved && a.Status != StatusResolved {
+		a.EndsAt = now
+	}
+
+	a.Status = msg.Status
+	if a.StartsAt.IsZero() {
+		a.StartsAt = now
+	}
+
+	var result = *a
+	if t.path == "" {
+		return &result, nil
+	} else if err := writeState(t.path, t.alerts); err != nil {
+		return &result, fmt.Errorf("failed writing alert state: %w", err)
+	}
+
+	return &result, nil
+}
+
+// List returns copies of all alerts currently tracked, with firing alerts listed first, and alerts
+// otherwise ordered by most recent start time.
+func (t *AlertTracker) List() []*Alert {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+
+	var result []*Alert
+	for _, a := range t.alerts {
+		var v = *a
+		result = append(result, &v)
+	}
+
+	slices.SortFunc(result, func(a, b *Alert) int {
+		if a.Status != b.Status {
+			if a.Status == StatusFiring {
+				return -1
+			} else if b.Status == StatusFiring {
+				return 1
+			}
+		}
+		return cmp.Or(b.StartsAt.Compare(a.StartsAt), cmp.Compare(a.Key, b.Key))
+	})
+
+	return result
+}
+
+// Prune removes resolved alerts past the retention period, as of the time given. The tracker lock
+// is expected to be held.
+func (t *AlertTracker) prune(now time.Time) {
+	maps.DeleteFunc(t.alerts, func(_ string, a *Alert) bool {
+		return a.Status == StatusResolved && now.Sub(a.EndsAt) > t.retention
+	})
+}
@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	// Standard library.
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAlertTrackerTrack(t *testing.T) {
+	var start = time.Now().Add(-12 * time.Minute)
+	var testCases = []struct {
+		descr   string
+		key     string
+		message *Message
+
+		status        Status
+		startsAt      time.Time
+		resolved      bool
+		notifications int
+	}{
+		{
+			descr:   "message without key",
+			message: &Message{Status: StatusFiring, Timestamp: start},
+		},
+		{
+			descr:   "message without status",
+			key:     "a",
+			message: &Message{Timestamp: start},
+		},
+		{
+			descr:         "firing alert",
+			key:           "a",
+			message:       &Message{Status: StatusFiring, Timestamp: start},
+			status:        StatusFiring,
+			startsAt:      start,
+			notifications: 1,
+		},
+		{
+			descr:         "repeated firing alert",
+			key:           "a",
+			message:       &Message{Status: StatusFiring, Timestamp: start.Add(time.Minute)},
+			status:        StatusFiring,
+			startsAt:      start,
+			notifications: 2,
+		},
+		{
+			descr:         "resolved alert",
+			key:           "a",
+			message:       &Message{Status: StatusResolved, Timestamp: start},
+			status:        StatusResolved,
+			startsAt:      start,
+			resolved:      true,
+			notifications: 3,
+		},
+		{
+			descr:         "alert firing again",
+			key:           "a",
+			message:       &Message{Status: StatusFiring, Timestamp: start.Add(10 * time.Minute)},
+			status:        StatusFiring,
+			startsAt:      start.Add(10 * time.Minute),
+			notifications: 1,
+		},
+	}
+
+	var path = filepath.Join(t.TempDir(), "alerts.json")
+	var tracker = NewAlertTracker(0)
+	if err := tracker.Load(path); err != nil {
+		t.Fatalf("AlertTracker.Load(): want error 'nil', have '%s'", err)
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			a, err := tracker.Track("/test", tt.key, tt.message)
+			if err != nil {
+				t.Fatalf("AlertTracker.Track(): want error 'nil', have '%s'", err)
+			} else if tt.status == "" {
+				if a != nil {
+					t.Fatalf("AlertTracker.Track(): want no alert, have '%+v'", a)
+				}
+				return
+			}
+
+			if a.Status != tt.status {
+				t.Fatalf("AlertTracker.Track(): want status '%s', have '%s'", tt.status, a.Status)
+			} else if !a.StartsAt.Equal(tt.startsAt) {
+				t.Fatalf("AlertTracker.Track(): want start time '%s', have '%s'", tt.startsAt, a.StartsAt)
+			} else if a.EndsAt.IsZero() == tt.resolved {
+				t.Fatalf("AlertTracker.Track(): want end time set '%v', have '%s'", tt.resolved, a.EndsAt)
+			} else if a.Notifications != tt.notifications {
+				t.Fatalf("AlertTracker.Track(): want %d notifications, have %d", tt.notifications, a.Notifications)
+			} else if tt.resolved && a.Duration().Round(time.Minute) != 12*time.Minute {
+				t.Fatalf("Alert.Duration(): want '12m0s', have '%s'", a.Duration())
+			}
+		})
+	}
+
+	var loaded = NewAlertTracker(0)
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("AlertTracker.Load(): want error 'nil', have '%s'", err)
+	} else if alerts := loaded.List(); len(alerts) != 1 || alerts[0].Key != "a" || alerts[0].Status != StatusFiring {
+		t.Fatalf("AlertTracker.Load(): want firing alert 'a', have '%v'", alerts)
+	}
+}
+
+func TestAlertTrackerList(t *testing.T) {
+	var now = time.Now()
+	var tracker = NewAlertTracker(time.Hour)
+
+	for _, v := range []struct {
+		key     string
+		message *Message
+	}{
+		{key: "old", message: &Message{Status: StatusFiring, Timestamp: now.Add(-2 * time.Hour)}},
+		{key: "new", message: &Message{Status: StatusFiring, Timestamp: now.Add(-time.Hour)}},
+		{key: "resolved", message: &Message{Status: StatusResolved, Timestamp: now}},
+	} {
+		if _, err := tracker.Track("/test", v.key, v.message); err != nil {
+			t.Fatalf("AlertTracker.Track(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	// Expire resolved alert past retention period.
+	tracker.alerts["expired"] = &Alert{Key: "expired", Status: StatusResolved, EndsAt: now.Add(-2 * time.Hour)}
+
+	var expect = []string{"new", "old", "resolved"}
+	var alerts = tracker.List()
+	if len(alerts) != len(expect) {
+		t.Fatalf("AlertTracker.List(): want %d alerts, have %d", len(expect), len(alerts))
+	}
+
+	for i, key := range expect {
+		if alerts[i].Key != key {
+			t.Fatalf("AlertTracker.List(): want alert '%s' at position %d, have '%s'", key, i, alerts[i].Key)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package gateway
+
+import (
+	// Standard library.
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A DedupKey determines how messages are identified for the purposes of deduplication.
+type DedupKey string
+
+// Keys supported for message deduplication.
+const (
+	DedupContent     DedupKey = "content"     // Messages are identified by their formatted content.
+	DedupFingerprint DedupKey = "fingerprint" // Messages are identified by their fingerprint and status.
+	DedupLabels      DedupKey = "labels"      // Messages are identified by their labels and status.
+)
+
+// Default window for message deduplication.
+const defaultDedupWindow = time.Hour
+
+// A Deduplicator keeps track of messages seen within a time window, allowing for repeated messages
+// to be suppressed. Messages are tracked per destination, so that messages pushed to some, but not
+// all destinations are not suppressed for the remaining destinations. State is optionally persisted
+// to a file, allowing for deduplication to continue across restarts.
+type Deduplicator struct {
+	key    DedupKey
+	window time.Duration
+	path   string
+
+	// Internal fields.
+	mu   sync.Mutex
+	seen map[string]time.Time // Message keys seen, and the time these expire.
+}
+
+// NewDeduplicator returns a [Deduplicator] for the key type and window given. If a window of zero
+// is given, a default window of one hour is used.
+func NewDeduplicator(key DedupKey, window time.Duration) (*Deduplicator, error) {
+	switch key {
+	case DedupContent, DedupFingerprint, DedupLabels:
+	default:
+		return nil, fmt.Errorf("unknown deduplication key '%s'", key)
+	}
+
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+
+	return &Deduplicator{key: key, window: window, seen: make(map[string]time.Time)}, nil
+}
+
+// Load sets the path used for persisting state, and loads any existing state from it; expired
+// state is dropped. Missing files are not considered an error, and will be created as needed.
+func (d *Deduplicator) Load(path string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.path = path
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed reading deduplication state: %w", err)
+	}
+
+	var seen map[string]time.Time
+	if err := json.Unmarshal(buf, &seen); err != nil {
+		return fmt.Errorf("failed parsing deduplication state: %w", err)
+	}
+
+	var now = time.Now()
+	for k, t := range seen {
+		if t.After(now) {
+			d.seen[k] = t
+		}
+	}
+
+	return nil
+}
+
+// Duplicate returns whether or not a message with the same key as the [Message] given has been
+// seen for the destination named within the deduplication window.
+func (d *Deduplicator) Duplicate(destination string, msg *Message) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, ok := d.seen[d.keyFor(destination, msg)]
+	return ok && t.After(time.Now())
+}
+
+// Record marks the given messages as seen for the destination named, starting a new deduplication
+// window for each of these, and persists state if a path has been set.
+func (d *Deduplicator) Record(destination string, messages ...*Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var now = time.Now()
+	maps.DeleteFunc(d.seen, func(_ string, t time.Time) bool { return !t.After(now) })
+	for _, msg := range messages {
+		d.seen[d.keyFor(destination, msg)] = now.Add(d.window)
+	}
+
+	if d.path == "" {
+		return nil
+	}
+
+	return d.save()
+}
+
+// Save writes the current state to the configured path, replacing any existing state atomically.
+// The deduplicator lock is expected to be held.
+func (d *Deduplicator) save() error {
+	if err := writeState(d.path, d.seen); err != nil {
+		return fmt.Errorf("failed writing deduplication state: %w", err)
+	}
+	return nil
+}
+
+// KeyFor returns the deduplication key for the destination name and [Message] given, as a hash of
+// the fields used in identifying messages. Messages without a fingerprint are identified by content
+// instead.
+func (d *Deduplicator) keyFor(destination string, msg *Message) string {
+	var h = sha256.New()
+	fmt.Fprintf(h, "%s\x00", destination)
+	switch {
+	case d.key == DedupFingerprint && msg.Fingerprint != "":
+		fmt.Fprintf(h, "fingerprint\x00%s\x00%s", msg.Fingerprint, msg.Status)
+	case d.key == DedupLabels:
+		fmt.Fprintf(h, "labels\x00%s", msg.Status)
+		for _, k := range slices.Sorted(maps.Keys(msg.Labels)) {
+			fmt.Fprintf(h, "\x00%s=%s", k, msg.Labels[k])
+		}
+	default:
+		fmt.Fprintf(h, "content\x00%s", msg.Content)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// UnmarshalTOML configures the [Deduplicator] based on values sourced from TOML configuration.
+func (d *Deduplicator) UnmarshalTOML(data any) error {
+	conf, ok := data.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var key, window = d.key, d.window
+	if v, ok := conf["key"].(string); ok {
+		key = DedupKey(v)
+	}
+
+	if v, ok := conf["window"].(string); ok {
+		w, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid deduplication window '%s': %w", v, err)
+		}
+		window = w
+	}
+
+	n, err := NewDeduplicator(key, window)
+	if err != nil {
+		return err
+	}
+
+	d.key, d.window = n.key, n.window
+	return nil
+}
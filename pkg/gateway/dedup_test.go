@@ -0,0 +1,110 @@
+package gateway
+
+import (
+	// Standard library.
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeduplicatorDuplicate(t *testing.T) {
+	var testCases = []struct {
+		descr     string
+		key       DedupKey
+		seen      *Message
+		message   *Message
+		duplicate bool
+	}{
+		{
+			descr:     "same content",
+			key:       DedupContent,
+			seen:      &Message{Content: "Hello", Status: StatusFiring},
+			message:   &Message{Content: "Hello", Status: StatusFiring},
+			duplicate: true,
+		},
+		{
+			descr:   "different content",
+			key:     DedupContent,
+			seen:    &Message{Content: "Hello"},
+			message: &Message{Content: "World"},
+		},
+		{
+			descr:     "same fingerprint with different content",
+			key:       DedupFingerprint,
+			seen:      &Message{Content: "Hello", Fingerprint: "a1", Status: StatusFiring},
+			message:   &Message{Content: "World", Fingerprint: "a1", Status: StatusFiring},
+			duplicate: true,
+		},
+		{
+			descr:   "same fingerprint with different status",
+			key:     DedupFingerprint,
+			seen:    &Message{Content: "Hello", Fingerprint: "a1", Status: StatusFiring},
+			message: &Message{Content: "Hello", Fingerprint: "a1", Status: StatusResolved},
+		},
+		{
+			descr:     "missing fingerprint with same content",
+			key:       DedupFingerprint,
+			seen:      &Message{Content: "Hello"},
+			message:   &Message{Content: "Hello"},
+			duplicate: true,
+		},
+		{
+			descr:     "same labels with different content",
+			key:       DedupLabels,
+			seen:      &Message{Content: "Hello", Labels: map[string]string{"a": "1", "b": "2"}},
+			message:   &Message{Content: "World", Labels: map[string]string{"b": "2", "a": "1"}},
+			duplicate: true,
+		},
+		{
+			descr:   "different labels",
+			key:     DedupLabels,
+			seen:    &Message{Labels: map[string]string{"a": "1"}},
+			message: &Message{Labels: map[string]string{"a": "2"}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			d, err := NewDeduplicator(tt.key, time.Hour)
+			if err != nil {
+				t.Fatalf("NewDeduplicator(): want error 'nil', have '%s'", err)
+			} else if err := d.Record("test", tt.seen); err != nil {
+				t.Fatalf("Deduplicator.Record(): want error 'nil', have '%s'", err)
+			} else if d.Duplicate("test", tt.message) != tt.duplicate {
+				t.Fatalf("Deduplicator.Duplicate(): want '%v', have '%v'", tt.duplicate, !tt.duplicate)
+			} else if d.Duplicate("other", tt.message) {
+				t.Fatalf("Deduplicator.Duplicate(): want 'false' for other destination, have 'true'")
+			}
+		})
+	}
+}
+
+func TestDeduplicatorLoad(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "dedup", "test.json")
+	var msg = &Message{Content: "Hello"}
+
+	d, _ := NewDeduplicator(DedupContent, time.Hour)
+	if err := d.Load(path); err != nil {
+		t.Fatalf("Deduplicator.Load(): want error 'nil', have '%s'", err)
+	} else if err := d.Record("test", msg); err != nil {
+		t.Fatalf("Deduplicator.Record(): want error 'nil', have '%s'", err)
+	}
+
+	d, _ = NewDeduplicator(DedupContent, time.Hour)
+	if err := d.Load(path); err != nil {
+		t.Fatalf("Deduplicator.Load(): want error 'nil', have '%s'", err)
+	} else if !d.Duplicate("test", msg) {
+		t.Fatalf("Deduplicator.Duplicate(): want 'true' for persisted state, have 'false'")
+	}
+
+	// Expired state should not be loaded.
+	d, _ = NewDeduplicator(DedupContent, time.Nanosecond)
+	if err := d.Record("test", &Message{Content: "World"}); err != nil {
+		t.Fatalf("Deduplicator.Record(): want error 'nil', have '%s'", err)
+	}
+
+	time.Sleep(time.Millisecond)
+	if d.Duplicate("test", &Message{Content: "World"}) {
+		t.Fatalf("Deduplicator.Duplicate(): want 'false' for expired state, have 'true'")
+	}
+}
@@ -2,16 +2,35 @@ package gateway
 
 import (
 	// Standard library.
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"maps"
+	"math"
+	"net"
 	"net/http"
-)
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
-// A Message represents a notification, as parsed in by a [Source], and provided to a [Destination].
-type Message struct {
-	Content string
-}
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/metrics"
+	"go.deuill.org/webhook-gateway/pkg/trace"
+)
 
 // A Source represents any method of parsing a concrete [Message] from an incoming [http.Request].
 // Sources typically have additional internal requirements for authentication and other metadata or
@@ -19,29 +38,61 @@ type Message struct {
 type Source interface {
 	ParseHTTP(*http.Request) ([]*Message, error)
 	Init(context.Context) error
+	Close(context.Context) error
 }
 
 // A Destination represents any method of pushing [Message] content to a (potentially) remote
 // endpoint. Destinations typically require ways of interfacing with their remote endpoints, and
 // thus require additional, source-specific configuration.
+//
+// Destinations are closed gracefully with 'Close', which is expected to flush any pending messages
+// and close any connections to remote endpoints, returning once done, or once the given context is
+// cancelled; cancelling the context given to 'Init' stops destinations immediately instead.
 type Destination interface {
 	PushMessages(context.Context, ...*Message) error
 	Init(context.Context) error
+	Close(context.Context) error
 }
 
 // A Gateway represents a [Source]-to-[Destination] mapping, with some additional metadata related
-// to authentication and HTTP pathing. Though most of the heavy lifting is done by downstream
-// dependencies, [Gateway] instances do, at least, require that they have a unique path and/or secret
-// configured for their correct operation.
+// to authentication and HTTP pathing. Messages parsed by the [Source] are pushed to all configured
+// destinations. Though most of the heavy lifting is done by downstream dependencies, [Gateway]
+// instances do, at least, require that they have a unique path and/or secret configured for their
+// correct operation.
 type Gateway struct {
 	// Configurable fields.
-	path        string
-	secret      string
-	source      Source
-	destination Destination
+	path         string
+	secret       string
+	source       Source
+	destinations []namedDestination
+	routes       []route
+	filters      []Matchers
+	dedup        *Deduplicator
+	silences     []*Silences
+	alerts       *AlertTracker
+	alertKey     []string
+	template     *template.Template
+	rateLimit    *KeyedRateLimiter
+	perClient    bool
+	clientCA     *CertPool
+	stateDir     string
+	history      *History
 
 	// Internal fields.
-	logger *slog.Logger
+	lookup       func(string) (Destination, bool)
+	factory      func(map[string]any) (string, Destination, error)
+	filtered     atomic.Uint64
+	deduplicated atomic.Uint64
+	silenced     atomic.Uint64
+	requests     [numOutcomes]atomic.Uint64
+	parseLatency metrics.Histogram
+	pushLatency  metrics.Histogram
+	activity     ActivityTracker
+	mu           sync.Mutex
+	held         map[string]*hold
+	heldPath     string   // The path held messages are persisted to, if any.
+	adopted      *Gateway // The gateway held messages are forwarded to, once adopted.
+	logger       *slog.Logger
 }
 
 // New instantiates an instance of a [Gateway] type, for the options given.
@@ -59,6 +110,15 @@ func New(options ...Option) (*Gateway, error) {
 	return &g, nil
 }
 
+// NamedDestination is a [Destination] with a name attached, used for reporting errors on specific
+// destinations. Shared destinations are owned by some external party (typically the service), and
+// are not initialized by the [Gateway] itself.
+type namedDestination struct {
+	name   string
+	shared bool
+	Destination
+}
+
 // A Option represents any configuration provided to new instances of [Gateway] types.
 type Option func(*Gateway) error
 
@@ -83,6 +143,17 @@ func WithSecret(secret string) Option {
 	}
 }
 
+// WithClientCA sets the [CertPool] used for verifying client certificates presented in incoming
+// requests to this [Gateway]. Requests made without TLS, or without a client certificate signed by
+// any of the certificates given, are rejected before being processed any further. Note that the HTTP
+// server is required to request client certificates for these to be available to the [Gateway].
+func WithClientCA(pool *CertPool) Option {
+	return func(w *Gateway) error {
+		w.clientCA = pool
+		return nil
+	}
+}
+
 // WithSource sets the given [Source] instance as the default source for the corresponding [Gateway].
 func WithSource(src Source) Option {
 	return func(w *Gateway) error {
@@ -91,11 +162,154 @@ func WithSource(src Source) Option {
 	}
 }
 
-// WithDestination sets the given [Destination] instance as the default destination for the
-// corresponding [Gateway].
-func WithDestination(dest Destination) Option {
+// WithDestination adds the given [Destination] instance to the list of destinations for the
+// corresponding [Gateway], under the name given. Names are used for reporting only, and need not be
+// unique.
+func WithDestination(name string, dest Destination) Option {
+	return func(w *Gateway) error {
+		w.destinations = append(w.destinations, namedDestination{name: name, Destination: dest})
+		return nil
+	}
+}
+
+// WithSharedDestination adds the given [Destination] instance to the list of destinations for the
+// corresponding [Gateway], under the name given. Unlike destinations added with [WithDestination],
+// shared destinations are expected to be initialized ahead of time, and will not be initialized in
+// calls to [Gateway.Init].
+func WithSharedDestination(name string, dest Destination) Option {
+	return func(w *Gateway) error {
+		w.destinations = append(w.destinations, namedDestination{name: name, shared: true, Destination: dest})
+		return nil
+	}
+}
+
+// WithRoute adds the given [Route] to the list of routing rules for the corresponding [Gateway].
+// Routes are evaluated in the order added, and messages not matching any route are pushed to the
+// destinations added with [WithDestination] and [WithSharedDestination], if any. Destinations given
+// in routes are treated as shared; see [WithSharedDestination] for more information.
+func WithRoute(r Route) Option {
+	return func(w *Gateway) error {
+		w.routes = append(w.routes, newRoute(r))
+		return nil
+	}
+}
+
+// WithFilter adds a filter rule for the corresponding [Gateway], dropping any messages matching all
+// of the given [Matchers] before these are routed to destinations.
+func WithFilter(ms Matchers) Option {
+	return func(w *Gateway) error {
+		w.filters = append(w.filters, ms)
+		return nil
+	}
+}
+
+// WithDeduplicator sets the given [Deduplicator] for the corresponding [Gateway], suppressing any
+// messages seen within the deduplication window. State is persisted under the directory set with
+// [WithStateDir], if any.
+func WithDeduplicator(d *Deduplicator) Option {
+	return func(w *Gateway) error {
+		w.dedup = d
+		return nil
+	}
+}
+
+// WithSilences adds the given set of [Silences] to those considered for the corresponding [Gateway];
+// sets of silences may be shared between gateways. Messages matching any active silence are either
+// dropped, or held until the silence ends, depending on the silence action.
+func WithSilences(s *Silences) Option {
 	return func(w *Gateway) error {
-		w.destination = dest
+		w.silences = append(w.silences, s)
+		return nil
+	}
+}
+
+// WithAlertTracker sets the [AlertTracker] used for tracking alert state for the corresponding
+// [Gateway]; trackers may be shared between gateways, with alerts scoped to the gateway path. Alert
+// state is attached to messages before these are pushed to destinations.
+func WithAlertTracker(t *AlertTracker) Option {
+	return func(w *Gateway) error {
+		w.alerts = t
+		return nil
+	}
+}
+
+// WithAlertKey sets the label names used for identifying alerts in tracking alert state for the
+// corresponding [Gateway]. By default, alerts are identified by message fingerprint where available,
+// or by the full set of message labels otherwise.
+func WithAlertKey(labels ...string) Option {
+	return func(w *Gateway) error {
+		w.alertKey = labels
+		return nil
+	}
+}
+
+// WithTemplate sets the template used for rendering message content for the corresponding
+// [Gateway], as parsed according to rules defined in [text/template], with the [Message] provided
+// as data. Templates are rendered after alert state is tracked, and can refer to this state via
+// the 'Alert' field, where set.
+func WithTemplate(t string) Option {
+	return func(w *Gateway) error {
+		tpl, err := template.New("message").Parse(t)
+		if err != nil {
+			return fmt.Errorf("failed parsing message template: %w", err)
+		}
+		w.template = tpl
+		return nil
+	}
+}
+
+// WithRateLimit sets the [RateLimit] applied to incoming requests for the corresponding [Gateway].
+// Limits are applied separately for each client IP address if perClient is true, or across all
+// requests otherwise; requests exceeding the limit are rejected with a '429 Too Many Requests'
+// status.
+func WithRateLimit(limit RateLimit, perClient bool) Option {
+	return func(w *Gateway) error {
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			return fmt.Errorf("invalid rate limit of %g per second with burst of %d", limit.Rate, limit.Burst)
+		}
+		w.rateLimit, w.perClient = NewKeyedRateLimiter(limit), perClient
+		return nil
+	}
+}
+
+// WithStateDir sets the directory used for persisting state for the corresponding [Gateway], such
+// as state for deduplicating messages. No state is persisted if no directory is set.
+func WithStateDir(dir string) Option {
+	return func(w *Gateway) error {
+		w.stateDir = dir
+		return nil
+	}
+}
+
+// WithHistory sets the [History] store used for recording requests handled by the corresponding
+// [Gateway], along with the outcome of processing these; stores may be shared between gateways, with
+// entries scoped to the gateway name.
+func WithHistory(h *History) Option {
+	return func(w *Gateway) error {
+		w.history = h
+		return nil
+	}
+}
+
+// WithDestinationLookup sets the function used for resolving named destination references in
+// gateway configuration, as parsed by [Gateway.UnmarshalTOML]. Destinations returned by the lookup
+// function are treated as shared; see [WithSharedDestination] for more information.
+func WithDestinationLookup(fn func(name string) (Destination, bool)) Option {
+	return func(w *Gateway) error {
+		w.lookup = fn
+		return nil
+	}
+}
+
+// WithDestinationFactory sets the function used for instantiating inline destinations in gateway
+// configuration, as parsed by [Gateway.UnmarshalTOML]. By default, inline destinations are created
+// via [NewDestination] and are owned by the [Gateway]; destinations returned by the factory function
+// are instead treated as shared, and are expected to be initialized by the caller. The factory
+// function also returns the name assigned to the destination, which is used for deduplication and
+// delivery history, and is expected to be unique within the gateway.
+func WithDestinationFactory(fn func(conf map[string]any) (name string, dest Destination, err error)) Option {
+	return func(w *Gateway) error {
+		w.factory = fn
 		return nil
 	}
 }
@@ -109,9 +323,9 @@ func WithLogger(l *slog.Logger) Option {
 	}
 }
 
-// Init ensures the [Service] is configured correctly, and initializes any sub-resources necessary
-// for its operation. Specifically, any attached [Source] and [Destination] instances will have
-// their 'Init' functions called, with any errors being returned immediately.
+// Init ensures the [Gateway] is configured correctly, and initializes any sub-resources necessary
+// for its operation. Specifically, any attached [Source] and non-shared [Destination] instances will
+// have their 'Init' functions called, with any errors being returned immediately.
 func (g *Gateway) Init(ctx context.Context) error {
 	if g.path == "" && g.secret == "" {
 		return fmt.Errorf("no path or secret found in gateway configuration")
@@ -126,37 +340,818 @@ func (g *Gateway) Init(ctx context.Context) error {
 		return fmt.Errorf("failed initializing source: %w", err)
 	}
 
-	if g.destination == nil {
+	if len(g.destinations) == 0 && len(g.routes) == 0 {
 		return fmt.Errorf("no destination configuration found")
-	} else if err := g.destination.Init(ctx); err != nil {
-		return fmt.Errorf("failed initializing destination: %w", err)
 	}
 
+	var destinations = slices.Clone(g.destinations)
+	for _, r := range g.routes {
+		destinations = append(destinations, r.destinations...)
+	}
+
+	for _, d := range destinations {
+		if d.shared {
+			continue
+		} else if err := d.Init(ctx); err != nil {
+			return fmt.Errorf("failed initializing destination '%s': %w", d.name, err)
+		}
+	}
+
+	// Load any persisted deduplication state, named after the gateway path.
+	if g.dedup != nil && g.stateDir != "" {
+		path := filepath.Join(g.stateDir, "dedup", url.PathEscape(g.path)+".json")
+		if err := g.dedup.Load(path); err != nil {
+			return err
+		}
+	}
+
+	// Load any messages held in previous runs, named after the gateway path.
+	if g.stateDir != "" {
+		path := filepath.Join(g.stateDir, "held", url.PathEscape(g.path)+".json")
+		if err := g.loadHeld(path); err != nil {
+			return err
+		}
+	}
+
+	// Keep any held messages for future runs on shutdown, or release these, rather than losing them.
+	go func() {
+		<-ctx.Done()
+		g.suspend()
+	}()
+
 	return nil
 }
 
+// Close releases any messages held by silences, unless these are persisted for future runs, and
+// closes the attached [Source] and any non-shared [Destination] instances, returning once done, or
+// once the given [context.Context] is cancelled. Any errors encountered in closing sources and
+// destinations are returned together.
+func (g *Gateway) Close(ctx context.Context) error {
+	g.suspend()
+
+	var errs []error
+	if g.source != nil {
+		if err := g.source.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed closing source: %w", err))
+		}
+	}
+
+	var destinations = slices.Clone(g.destinations)
+	for _, r := range g.routes {
+		destinations = append(destinations, r.destinations...)
+	}
+
+	for _, d := range destinations {
+		if d.shared {
+			continue
+		} else if err := d.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed closing destination '%s': %w", d.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Adopt takes over messages held by silences for the [Gateway] given, e.g. one replaced on reload,
+// scheduling release of these for when their silences end. Messages held by the gateway given after
+// adoption are forwarded to the adopting gateway.
+func (g *Gateway) Adopt(from *Gateway) {
+	from.mu.Lock()
+	var held = from.held
+	from.held, from.adopted = nil, g
+	from.mu.Unlock()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.held == nil {
+		g.held = make(map[string]*hold)
+	}
+
+	// Messages held by the gateway given take precedence over any loaded from persisted state, as
+	// these are likely to be the same messages, or more recent ones.
+	var now = time.Now()
+	for id, h := range held {
+		if v, ok := g.held[id]; ok {
+			v.timer.Stop()
+		}
+		h.timer.Stop()
+		g.held[id] = h
+		g.schedule(h, now)
+	}
+
+	g.saveHeld()
+}
+
+// Suspend stops scheduled release of messages held by silences where these are persisted, leaving
+// held messages to be released by future runs, or releases all held messages otherwise.
+func (g *Gateway) suspend() {
+	g.mu.Lock()
+	var persisted = g.heldPath != ""
+	if persisted {
+		for _, h := range g.held {
+			h.timer.Stop()
+		}
+	}
+	g.mu.Unlock()
+
+	if !persisted {
+		g.ReleaseAll()
+	}
+}
+
+// ReleaseAll releases all messages currently held by silences, e.g. for gateways removed on reload.
+func (g *Gateway) ReleaseAll() {
+	g.mu.Lock()
+	var ids = slices.Collect(maps.Keys(g.held))
+	g.mu.Unlock()
+
+	for _, id := range ids {
+		g.Release(id)
+	}
+}
+
 // HandleHTTP returns a HTTP path and corresponding [http.HandlerFunc] for the [Gateway], as
 // configured. Most processing for requests happens as part of [Source.ParseHTTP] and
 // [Destination.PushMessages], see the documentation for those functions for more information.
+//
+// Requests are traced under a span continuing any trace given in the W3C 'traceparent' header, with
+// parsing and pushing of messages traced under spans of their own. Each request is assigned a new
+// request ID, which is returned in the 'X-Request-Id' header of the response, and stored in contexts
+// passed to sources and destinations; see [GetRequestID]. Any request ID given by the client in the
+// 'X-Request-Id' header of the request is only recorded alongside the request ID assigned.
+//
+// Requests exceeding the configured rate limit, if any, are rejected with a '429 Too Many Requests'
+// status, and a 'Retry-After' header set to the number of seconds until requests are allowed again.
+//
+// Alert state is tracked for all messages not matching any configured filter, if enabled, and
+// message content is rendered from the configured template, if any.
+//
+// Messages matching any configured filter, or seen previously within the deduplication window (if
+// enabled), are dropped, messages matching any active silence are dropped or held, and the
+// remaining messages are pushed to all destinations selected by configured routes, or to all
+// configured destinations if no routes match, regardless of whether pushing to any one of them
+// fails; messages not selected for any destination are dropped. Destinations are generally expected
+// to accept messages for asynchronous delivery, and successful requests are thus responded to with
+// a '202 Accepted' status, or a '200 OK' status if all messages were dropped. If all destinations
+// fail, a '400 Bad Request' status is returned, whereas partial failures are reported with a
+// '207 Multi-Status' status, with failing destinations listed in the response body.
+//
+// Requests are recorded in the configured [History], if any, along with the messages parsed and
+// the outcome of pushing these to each destination.
 func (g *Gateway) HandleHTTP() (string, http.HandlerFunc) {
 	h := func(w http.ResponseWriter, r *http.Request) {
-		r = r.WithContext(SetSecret(r.Context(), g.secret))
-		if msg, err := g.source.ParseHTTP(r); err != nil || len(msg) == 0 {
+		ctx, span := trace.Start(trace.Extract(r.Context(), r.Header), r.Method+" "+g.Name(), trace.KindServer)
+		defer span.End()
+
+		var id = newRequestID()
+		ctx = SetRequestID(ctx, id)
+		w.Header().Set("X-Request-Id", id)
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("url.path", g.Name())
+		span.SetAttribute("gateway.request_id", id)
+		if clientID := clientRequestID(r); clientID != "" {
+			span.SetAttribute("gateway.client_request_id", clientID)
+		}
+
+		// Record request in history before processing, as destinations may report delivery outcomes
+		// asynchronously.
+		var capture func(*HistoryEntry)
+		if g.history != nil {
+			var entry *HistoryEntry
+			entry, capture = g.history.newEntry(r, id, g.Name(), g.secret)
+			g.history.Add(entry)
+		}
+
+		if g.clientCA != nil {
+			if err := g.clientCA.Verify(r); err != nil {
+				g.record(ctx, span, outcomeForbidden, http.StatusForbidden, err)
+				http.Error(w, "client certificate required", http.StatusForbidden)
+				g.logger.WarnContext(ctx, "Rejected request with missing or invalid client certificate", "path", g.path,
+					"remote-addr", r.RemoteAddr, "error", err.Error())
+				return
+			}
+		}
+
+		if ok, wait := g.allow(r); !ok {
+			g.record(ctx, span, outcomeRateLimited, http.StatusTooManyRequests, nil)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			g.logger.WarnContext(ctx, "Rejected request exceeding rate limit", "path", g.path, "remote-addr", r.RemoteAddr)
+			return
+		}
+
+		ctx = SetPath(SetSecret(ctx, g.secret), g.path)
+		msg, err := g.parse(r.WithContext(ctx))
+		if err != nil || len(msg) == 0 {
+			g.remember(ctx, capture)
 			msg := fmt.Sprintf("failed processing incoming request: %s", err)
+			g.record(ctx, span, outcomeInvalid, http.StatusBadRequest, errors.New(msg))
+			g.activity.Failure(errors.New(msg))
+			span.SetError(errors.New(msg))
 			http.Error(w, msg, http.StatusBadRequest)
-			g.logger.Debug(msg)
+			g.logger.DebugContext(ctx, msg)
 			return
-		} else if err = g.destination.PushMessages(r.Context(), msg...); err != nil {
-			msg := fmt.Sprintf("failed pushing notification messages: %s", err)
+		}
+
+		// Ensure all messages carry a timestamp, even where not provided by the source.
+		var now = time.Now()
+		for _, m := range msg {
+			if m.Timestamp.IsZero() {
+				m.Timestamp = now
+			}
+		}
+
+		// Record messages as parsed, before these are changed further.
+		g.remember(ctx, func(e *HistoryEntry) {
+			capture(e)
+			for _, m := range msg {
+				c := *m
+				e.Messages = append(e.Messages, &c)
+			}
+		})
+
+		msg, seen := g.deduplicate(ctx, g.track(ctx, g.filter(ctx, msg)))
+		msg = g.silence(ctx, g.render(ctx, msg))
+		targets, dropped := routeMessages(g.routes, g.destinations, msg)
+		if len(dropped) > 0 {
+			g.logger.DebugContext(ctx, "No destinations selected for notification messages, dropping", "path", g.path, "count", len(dropped))
+		}
+
+		targets = g.skipDuplicates(ctx, targets, msg, seen)
+
+		span.SetAttribute("gateway.messages", len(msg))
+		if len(targets) == 0 {
+			g.record(ctx, span, outcomeDropped, http.StatusOK, nil)
+			g.activity.Success()
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		var errs []error
+		start := time.Now()
+		for _, t := range targets {
+			err := g.push(ctx, t)
+			g.remember(ctx, func(e *HistoryEntry) {
+				// Keep outcomes already reported by destinations delivering messages asynchronously.
+				d := e.delivery(t.name)
+				d.Messages, d.UpdatedAt = len(t.messages), time.Now()
+				if err != nil {
+					d.Status, d.Error = DeliveryFailed, err.Error()
+				} else if d.Status == "" {
+					d.Status = DeliveryPushed
+				}
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("destination '%s': %w", t.name, err))
+				g.logger.ErrorContext(ctx, "Failed pushing notification messages", "path", g.path, "destination", t.name, "error", err.Error())
+				continue
+			}
+
+			// Only record messages as seen once pushed, so that retried requests are not suppressed
+			// for destinations that failed.
+			if g.dedup != nil {
+				var pushed []*Message
+				for _, m := range t.messages {
+					pushed = append(pushed, seen[m])
+				}
+				if err := g.dedup.Record(t.name, pushed...); err != nil {
+					g.logger.ErrorContext(ctx, "Failed recording messages for deduplication", "path", g.path, "error", err.Error())
+				}
+			}
+		}
+		g.pushLatency.ObserveSince(start)
+
+		if len(errs) > 0 && len(errs) == len(targets) {
+			msg := fmt.Sprintf("failed pushing notification messages: %s", errors.Join(errs...))
+			g.record(ctx, span, outcomeFailed, http.StatusBadRequest, errors.New(msg))
+			g.activity.Failure(errors.New(msg))
+			span.SetError(errors.New(msg))
 			http.Error(w, msg, http.StatusBadRequest)
-			g.logger.Debug(msg)
+			return
+		} else if len(errs) > 0 {
+			msg := fmt.Sprintf("failed pushing notification messages to some destinations: %s", errors.Join(errs...))
+			g.record(ctx, span, outcomePartial, http.StatusMultiStatus, errors.New(msg))
+			g.activity.Failure(errors.New(msg))
+			span.SetError(errors.New(msg))
+			http.Error(w, msg, http.StatusMultiStatus)
 			return
 		}
+
+		g.record(ctx, span, outcomeAccepted, http.StatusAccepted, nil)
+		g.activity.Success()
+		w.WriteHeader(http.StatusAccepted)
 	}
 
 	return g.path, h
 }
 
+// Parse returns messages parsed from the given request via the configured [Source], under a span of
+// its own.
+func (g *Gateway) parse(r *http.Request) ([]*Message, error) {
+	ctx, span := trace.Start(r.Context(), "parse "+g.Name(), trace.KindInternal)
+	defer span.End()
+
+	start := time.Now()
+	msg, err := g.source.ParseHTTP(r.WithContext(ctx))
+	g.parseLatency.ObserveSince(start)
+
+	span.SetAttribute("gateway.messages", len(msg))
+	span.SetError(err)
+
+	return msg, err
+}
+
+// Push pushes messages to the target destination given, under a span of its own.
+func (g *Gateway) push(ctx context.Context, t *target) error {
+	ctx, span := trace.Start(SetDestination(ctx, t.name), "push "+t.name, trace.KindProducer)
+	defer span.End()
+
+	span.SetAttribute("gateway.destination", t.name)
+	span.SetAttribute("gateway.messages", len(t.messages))
+
+	err := t.PushMessages(ctx, t.messages...)
+	span.SetError(err)
+
+	return err
+}
+
+// Record increments the count of requests handled by the [Gateway] for the outcome given, and
+// attaches the outcome and response status given to the request span and history entry, along with
+// the error given, if any.
+func (g *Gateway) record(ctx context.Context, span *trace.Span, outcome, status int, err error) {
+	g.requests[outcome].Add(1)
+	span.SetAttribute("gateway.outcome", outcomeNames[outcome])
+	span.SetAttribute("http.response.status_code", status)
+
+	g.remember(ctx, func(e *HistoryEntry) {
+		e.Status, e.Outcome = status, outcomeNames[outcome]
+		if err != nil {
+			e.Error = err.Error()
+		}
+	})
+}
+
+// Remember applies the given function to the history entry for the request handled, if history is
+// enabled for the [Gateway].
+func (g *Gateway) remember(ctx context.Context, fn func(*HistoryEntry)) {
+	if g.history != nil {
+		g.history.Update(GetRequestID(ctx), fn)
+	}
+}
+
+// NewRequestID returns a new random request ID. Request IDs are always generated by the gateway, as
+// these key request history and delivery outcomes, and cannot be trusted to be unique otherwise.
+func newRequestID() string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// ClientRequestID returns the request ID given in the 'X-Request-Id' header for the request given,
+// if valid, or an empty string otherwise.
+func clientRequestID(r *http.Request) string {
+	if id := r.Header.Get("X-Request-Id"); id != "" && len(id) <= 128 {
+		if !strings.ContainsFunc(id, func(c rune) bool { return c <= ' ' || c > '~' }) {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// Track updates alert state for the messages given, if enabled, attaching the updated state to each
+// message.
+func (g *Gateway) track(ctx context.Context, messages []*Message) []*Message {
+	if g.alerts == nil {
+		return messages
+	}
+
+	for _, msg := range messages {
+		a, err := g.alerts.Track(g.path, g.keyFor(msg), msg)
+		if err != nil {
+			g.logger.ErrorContext(ctx, "Failed tracking alert state", "path", g.path, "error", err.Error())
+		}
+		msg.Alert = a
+	}
+
+	return messages
+}
+
+// KeyFor returns the key identifying the alert the given [Message] refers to, as a hash of the
+// gateway path and the configured alert key labels, or the message fingerprint or labels otherwise.
+// An empty key is returned for messages without any identifying information.
+func (g *Gateway) keyFor(msg *Message) string {
+	var h = sha256.New()
+	fmt.Fprintf(h, "%s", g.path)
+
+	switch {
+	case len(g.alertKey) > 0:
+		for _, k := range g.alertKey {
+			fmt.Fprintf(h, "\x00%s=%s", k, msg.Labels[k])
+		}
+	case msg.Fingerprint != "":
+		fmt.Fprintf(h, "\x00fingerprint\x00%s", msg.Fingerprint)
+	case len(msg.Labels) > 0:
+		for _, k := range slices.Sorted(maps.Keys(msg.Labels)) {
+			fmt.Fprintf(h, "\x00%s=%s", k, msg.Labels[k])
+		}
+	default:
+		return ""
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Render returns the messages given, with content rendered from the configured template, if any.
+// Messages failing to render retain their original content.
+func (g *Gateway) render(ctx context.Context, messages []*Message) []*Message {
+	if g.template == nil {
+		return messages
+	}
+
+	for _, msg := range messages {
+		var buf bytes.Buffer
+		if err := g.template.Execute(&buf, msg); err != nil {
+			g.logger.ErrorContext(ctx, "Failed rendering message template", "path", g.path, "error", err.Error())
+			continue
+		}
+		msg.Content = buf.String()
+	}
+
+	return messages
+}
+
+// Silence returns the messages given, without any messages matching active silences. Messages
+// matching silences with a 'hold' action are held until the silence ends, at which point these are
+// pushed to their destinations in summary form.
+func (g *Gateway) silence(ctx context.Context, messages []*Message) []*Message {
+	if len(g.silences) == 0 {
+		return messages
+	}
+
+	var now = time.Now()
+	var result []*Message
+	for _, msg := range messages {
+		var silence *Silence
+		for _, s := range g.silences {
+			if silence = s.Match(msg, now); silence != nil {
+				break
+			}
+		}
+
+		if silence == nil {
+			result = append(result, msg)
+			continue
+		}
+
+		g.silenced.Add(1)
+		if silence.Action == SilenceHold {
+			g.logger.InfoContext(ctx, "Holding silenced notification message", "path", g.path, "silence", silence.ID, "title", msg.Title)
+			g.hold(silence, msg, now)
+		} else {
+			g.logger.InfoContext(ctx, "Dropped silenced notification message", "path", g.path, "silence", silence.ID, "title", msg.Title)
+		}
+	}
+
+	return result
+}
+
+// Hold adds the given message to those held for the given [Silence], scheduling release of held
+// messages for when the silence ends. Messages are forwarded to the adopting [Gateway], if any.
+func (g *Gateway) hold(silence *Silence, msg *Message, now time.Time) {
+	g.mu.Lock()
+	if to := g.adopted; to != nil {
+		g.mu.Unlock()
+		to.hold(silence, msg, now)
+		return
+	}
+
+	defer g.mu.Unlock()
+	if g.held == nil {
+		g.held = make(map[string]*hold)
+	}
+
+	h, ok := g.held[silence.ID]
+	if !ok {
+		h = &hold{Silence: silence}
+		g.held[silence.ID] = h
+		g.schedule(h, now)
+	}
+
+	h.Messages = append(h.Messages, msg)
+	g.saveHeld()
+}
+
+// Schedule sets release of the given held messages for when the silence these are held for ends,
+// or for immediate release if the silence has already ended. The gateway lock is expected to be
+// held.
+func (g *Gateway) schedule(h *hold, now time.Time) {
+	var id = h.Silence.ID
+	h.timer = time.AfterFunc(max(0, h.Silence.End(now).Sub(now)), func() { g.Release(id) })
+}
+
+// LoadHeld sets the path used for persisting messages held by silences, and loads any messages held
+// in previous runs from it, scheduling release of these. Missing files are not considered an error,
+// and will be created as needed.
+func (g *Gateway) loadHeld(path string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.heldPath = path
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed reading held messages: %w", err)
+	}
+
+	var held map[string]*hold
+	if err := json.Unmarshal(buf, &held); err != nil {
+		return fmt.Errorf("failed parsing held messages: %w", err)
+	}
+
+	if g.held == nil {
+		g.held = make(map[string]*hold)
+	}
+
+	var now = time.Now()
+	for id, h := range held {
+		if _, ok := g.held[id]; ok || h.Silence == nil {
+			continue
+		}
+		g.held[id] = h
+		g.schedule(h, now)
+	}
+
+	return nil
+}
+
+// SaveHeld writes all messages currently held to the configured path, if any, replacing any existing
+// file atomically. The gateway lock is expected to be held.
+func (g *Gateway) saveHeld() {
+	if g.heldPath == "" {
+		return
+	} else if err := writeState(g.heldPath, g.held); err != nil {
+		g.logger.Error("Failed writing held notification messages", "path", g.path, "error", err.Error())
+	}
+}
+
+// Release pushes any messages held for the [Silence] with the ID given to their destinations, as
+// selected by configured routes, summarizing messages for each destination where more than one
+// message is held. Messages are released automatically once silences end, but may be released early
+// where silences are removed before their scheduled end.
+func (g *Gateway) Release(id string) {
+	g.mu.Lock()
+	h, ok := g.held[id]
+	if ok {
+		delete(g.held, id)
+		g.saveHeld()
+	}
+	g.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	h.timer.Stop()
+	targets, _ := routeMessages(g.routes, g.destinations, h.Messages)
+
+	ctx, cancel := context.WithTimeout(context.Background(), holdReleaseTimeout)
+	defer cancel()
+
+	ctx, span := trace.Start(SetPath(ctx, g.path), "release "+g.Name(), trace.KindInternal)
+	defer span.End()
+
+	span.SetAttribute("gateway.silence", id)
+	span.SetAttribute("gateway.messages", len(h.Messages))
+
+	g.logger.InfoContext(ctx, "Releasing held notification messages", "path", g.path, "silence", id, "count", len(h.Messages))
+	for _, t := range targets {
+		if err := t.PushMessages(ctx, h.summarize(t.messages)); err != nil {
+			span.SetError(err)
+			g.logger.ErrorContext(ctx, "Failed pushing held notification messages", "path", g.path, "destination", t.name, "error", err.Error())
+		}
+	}
+}
+
+// Silenced returns the total number of messages dropped or held by silences for the [Gateway].
+func (g *Gateway) Silenced() uint64 {
+	return g.silenced.Load()
+}
+
+// Allow returns whether or not the given request is allowed under the configured rate limit, if
+// any, or the amount of time until requests are allowed again otherwise.
+func (g *Gateway) allow(r *http.Request) (bool, time.Duration) {
+	if g.rateLimit == nil {
+		return true, 0
+	}
+
+	var key string
+	if g.perClient {
+		key = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			key = host
+		}
+	}
+
+	return g.rateLimit.Allow(key)
+}
+
+// Filter returns the messages given, without any messages matching configured filters.
+func (g *Gateway) filter(ctx context.Context, messages []*Message) []*Message {
+	if len(g.filters) == 0 {
+		return messages
+	}
+
+	var result []*Message
+	for _, msg := range messages {
+		var dropped bool
+		for i, f := range g.filters {
+			if f.Match(msg) {
+				g.logger.InfoContext(ctx, "Dropped notification message matching filter", "path", g.path, "filter", i+1, "title", msg.Title)
+				g.filtered.Add(1)
+				dropped = true
+				break
+			}
+		}
+
+		if !dropped {
+			result = append(result, msg)
+		}
+	}
+
+	return result
+}
+
+// Deduplicate returns the messages given, without any messages repeated in the same request, along
+// with copies of the messages returned, as given. Copies are used for identifying messages for
+// deduplication, regardless of any changes made further on, e.g. when rendering templates.
+func (g *Gateway) deduplicate(ctx context.Context, messages []*Message) ([]*Message, map[*Message]*Message) {
+	if g.dedup == nil {
+		return messages, nil
+	}
+
+	var result []*Message
+	var seen = make(map[*Message]*Message, len(messages))
+	var batch, _ = NewDeduplicator(g.dedup.key, g.dedup.window)
+	for _, msg := range messages {
+		if batch.Duplicate("", msg) {
+			g.logger.InfoContext(ctx, "Dropped duplicate notification message", "path", g.path, "title", msg.Title)
+			g.deduplicated.Add(1)
+			continue
+		}
+
+		_ = batch.Record("", msg)
+		c := *msg
+		seen[msg] = &c
+		result = append(result, msg)
+	}
+
+	return result, seen
+}
+
+// SkipDuplicates returns the targets given, without any messages seen previously for the target
+// destination within the deduplication window, as identified by the copies of the messages given.
+// Targets left without any messages are dropped, and messages skipped for all targets selected are
+// counted as duplicates.
+func (g *Gateway) skipDuplicates(ctx context.Context, targets []*target, messages []*Message, seen map[*Message]*Message) []*target {
+	if g.dedup == nil {
+		return targets
+	}
+
+	var result []*target
+	var selected, skipped = make(map[*Message]int), make(map[*Message]int)
+	for _, t := range targets {
+		var pending []*Message
+		for _, msg := range t.messages {
+			selected[msg]++
+			if g.dedup.Duplicate(t.name, seen[msg]) {
+				g.logger.DebugContext(ctx, "Skipped notification message already pushed to destination", "path", g.path,
+					"destination", t.name, "title", msg.Title)
+				skipped[msg]++
+				continue
+			}
+			pending = append(pending, msg)
+		}
+
+		if len(pending) > 0 {
+			t.messages = pending
+			result = append(result, t)
+		}
+	}
+
+	for _, msg := range messages {
+		if n, ok := skipped[msg]; ok && n == selected[msg] {
+			g.logger.InfoContext(ctx, "Dropped duplicate notification message", "path", g.path, "title", msg.Title)
+			g.deduplicated.Add(1)
+		}
+	}
+
+	return result
+}
+
+// Deduplicated returns the total number of messages dropped as duplicates for the [Gateway].
+func (g *Gateway) Deduplicated() uint64 {
+	return g.deduplicated.Load()
+}
+
+// RateLimited returns the total number of requests rejected for exceeding the rate limit configured
+// for the [Gateway].
+func (g *Gateway) RateLimited() uint64 {
+	return g.requests[outcomeRateLimited].Load()
+}
+
+// Outcomes for requests handled by gateways, as reported by [Gateway.Requests].
+const (
+	outcomeAccepted    = iota // Messages were pushed to all destinations selected.
+	outcomePartial            // Messages failed to be pushed to some destinations selected.
+	outcomeFailed             // Messages failed to be pushed to all destinations selected.
+	outcomeDropped            // No messages were left for pushing, e.g. due to filters or silences.
+	outcomeInvalid            // The request failed to be parsed by the source.
+	outcomeRateLimited        // The request was rejected for exceeding the rate limit.
+	outcomeForbidden          // The request was rejected for lacking a valid client certificate.
+	numOutcomes
+)
+
+// Names for request outcomes, as reported by [Gateway.Requests].
+var outcomeNames = [numOutcomes]string{"accepted", "partial", "failed", "dropped", "invalid", "rate-limited", "forbidden"}
+
+// Requests returns the total number of requests handled by the [Gateway], keyed by outcome.
+func (g *Gateway) Requests() map[string]uint64 {
+	var result = make(map[string]uint64, numOutcomes)
+	for i, name := range outcomeNames {
+		result[name] = g.requests[i].Load()
+	}
+
+	return result
+}
+
+// Latency returns snapshots of the time taken, in seconds, for parsing incoming requests via the
+// [Source], and for pushing parsed messages to all destinations selected, respectively.
+func (g *Gateway) Latency() (parse, push metrics.Snapshot) {
+	return g.parseLatency.Snapshot(), g.pushLatency.Snapshot()
+}
+
+// Filtered returns the total number of messages dropped by filters configured for the [Gateway].
+func (g *Gateway) Filtered() uint64 {
+	return g.filtered.Load()
+}
+
+// Healthy returns an error if the [Source] attached to the [Gateway] reports being unhealthy, where
+// the source implements [HealthChecker].
+func (g *Gateway) Healthy(ctx context.Context) error {
+	if c, ok := g.source.(HealthChecker); ok {
+		if err := c.Healthy(ctx); err != nil {
+			return fmt.Errorf("source unhealthy: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Activity returns the [Activity] for requests handled by the [Gateway]. Requests are taken to have
+// failed if these could not be parsed by the [Source], or if pushing messages to any destinations
+// failed.
+func (g *Gateway) Activity() Activity {
+	return g.activity.Activity()
+}
+
+// Name returns a name identifying the [Gateway] for reporting purposes, which is the configured
+// path, where set. Paths derived from the gateway secret are replaced by a hash of the secret, so as
+// not to expose the secret itself.
+func (g *Gateway) Name() string {
+	if g.secret != "" && (g.path == "" || g.path == "/"+g.secret) {
+		sum := sha256.Sum256([]byte(g.secret))
+		return "/secret-" + hex.EncodeToString(sum[:4])
+	}
+
+	return g.path
+}
+
+// Destinations returns the names of all destinations configured for the [Gateway], including those
+// selected by routes, in the order configured and without duplicates. Destinations defined inline
+// are named as assigned by the factory function set via [WithDestinationFactory], if any, or after
+// their type otherwise.
+func (g *Gateway) Destinations() []string {
+	var names []string
+	for _, r := range append([]route{{destinations: g.destinations}}, g.routes...) {
+		for _, d := range r.destinations {
+			if !slices.Contains(names, d.name) {
+				names = append(names, d.name)
+			}
+		}
+	}
+
+	return names
+}
+
+// RequiresClientCert returns whether the [Gateway] requires client certificates for incoming
+// requests, as set by [WithClientCA].
+func (g *Gateway) RequiresClientCert() bool {
+	return g.clientCA != nil
+}
+
 // TomlUmarshaler is defined here to avoid having to import the `toml` package if we don't need to.
 type tomlUnmarshaler interface {
 	UnmarshalTOML(any) error
@@ -177,6 +1172,14 @@ func (g *Gateway) UnmarshalTOML(data any) error {
 		g.path = v
 	}
 
+	if v, ok := conf["client-ca"].(string); ok && v != "" {
+		pool, err := LoadCertPool(v, g.logger)
+		if err != nil {
+			return fmt.Errorf("failed loading client CA for gateway: %w", err)
+		}
+		g.clientCA = pool
+	}
+
 	// Parse source and destination configuration.
 	if v, ok := conf["source"].(map[string]any); ok {
 		name, ok := v["type"].(string)
@@ -196,33 +1199,243 @@ func (g *Gateway) UnmarshalTOML(data any) error {
 		}
 	}
 
-	if v, ok := conf["destination"].(map[string]any); ok {
-		name, ok := v["type"].(string)
-		if !ok || name == "" {
-			return fmt.Errorf("empty or missing destination type in gateway configuration")
-		} else if _, ok = knownDestinations[name]; !ok {
-			return fmt.Errorf("unknown destination type '%s' given in gateway configuration", name)
+	destinations, err := g.parseDestinations(conf["destination"])
+	if err != nil {
+		return err
+	}
+
+	g.destinations = append(g.destinations, destinations...)
+
+	// Parse filter rules, each of which drops messages matching all of its matchers.
+	var filters []map[string]any
+	switch v := conf["filter"].(type) {
+	case map[string]any:
+		filters = append(filters, v)
+	case []map[string]any:
+		filters = v
+	}
+
+	for i, v := range filters {
+		matchers, err := ParseMatchers(v["matchers"])
+		if err != nil {
+			return fmt.Errorf("failed parsing matchers for filter %d in gateway configuration: %w", i+1, err)
+		} else if len(matchers) == 0 {
+			return fmt.Errorf("no matchers given for filter %d in gateway configuration", i+1)
 		}
 
-		g.destination = knownDestinations[name]()
-		if m, ok := g.destination.(tomlUnmarshaler); ok {
-			if v, ok = v[name].(map[string]any); ok {
-				if err := m.UnmarshalTOML(v); err != nil {
-					return fmt.Errorf("failed parsing configuration for destination '%s': %w", name, err)
-				}
+		g.filters = append(g.filters, matchers)
+	}
+
+	// Parse deduplication configuration, enabling deduplication with default settings if given as
+	// a boolean flag.
+	switch v := conf["dedup"].(type) {
+	case bool:
+		if v {
+			g.dedup, _ = NewDeduplicator(DedupContent, 0)
+		}
+	case map[string]any:
+		g.dedup, _ = NewDeduplicator(DedupContent, 0)
+		if err := g.dedup.UnmarshalTOML(v); err != nil {
+			return fmt.Errorf("failed parsing deduplication configuration: %w", err)
+		}
+	}
+
+	// Parse rate limit configuration, applied per client IP address unless specified otherwise.
+	if v, ok := conf["rate-limit"].(map[string]any); ok {
+		limit, err := ParseRateLimit(v)
+		if err != nil {
+			return fmt.Errorf("failed parsing rate limit configuration: %w", err)
+		}
+
+		var perClient = true
+		if c, ok := v["per-client"].(bool); ok {
+			perClient = c
+		}
+
+		if err := WithRateLimit(limit, perClient)(g); err != nil {
+			return err
+		}
+	}
+
+	// Parse alert tracking and templating options.
+	if v, ok := conf["alert-key"].([]any); ok {
+		var labels []string
+		for _, l := range v {
+			if l, ok := l.(string); ok && l != "" {
+				labels = append(labels, l)
+			}
+		}
+		g.alertKey = labels
+	}
+
+	if v, ok := conf["template"].(string); ok && v != "" {
+		if err := WithTemplate(v)(g); err != nil {
+			return err
+		}
+	}
+
+	// Parse silence rules, which apply to this gateway only.
+	var silences []map[string]any
+	switch v := conf["silence"].(type) {
+	case map[string]any:
+		silences = append(silences, v)
+	case []map[string]any:
+		silences = v
+	}
+
+	if len(silences) > 0 {
+		var set = NewSilences()
+		for i, v := range silences {
+			silence, err := ParseSilence(v)
+			if err != nil {
+				return fmt.Errorf("failed parsing silence %d in gateway configuration: %w", i+1, err)
+			} else if err = set.Add(silence); err != nil {
+				return fmt.Errorf("failed adding silence %d in gateway configuration: %w", i+1, err)
 			}
 		}
+
+		g.silences = append(g.silences, set)
+	}
+
+	// Parse routing rules, each of which selects its own destinations for matching messages.
+	var routes []map[string]any
+	switch v := conf["route"].(type) {
+	case map[string]any:
+		routes = append(routes, v)
+	case []map[string]any:
+		routes = v
+	}
+
+	for i, v := range routes {
+		matchers, err := ParseMatchers(v["matchers"])
+		if err != nil {
+			return fmt.Errorf("failed parsing matchers for route %d in gateway configuration: %w", i+1, err)
+		}
+
+		destinations, err := g.parseDestinations(v["destination"])
+		if err != nil {
+			return err
+		} else if len(destinations) == 0 {
+			return fmt.Errorf("no destination given for route %d in gateway configuration", i+1)
+		}
+
+		var r = route{matchers: matchers, destinations: destinations}
+		if c, ok := v["continue"].(bool); ok {
+			r.cont = c
+		}
+
+		g.routes = append(g.routes, r)
 	}
 
 	return nil
 }
 
+// ParseDestinations returns destinations for the TOML configuration given, which can be a single
+// table or name, or an array of tables or names. Tables define destinations inline, whereas names
+// refer to shared destinations, as resolved by the lookup function set for the gateway.
+func (g *Gateway) parseDestinations(data any) ([]namedDestination, error) {
+	var destinations []any
+	switch v := data.(type) {
+	case string, map[string]any:
+		destinations = append(destinations, v)
+	case []map[string]any:
+		for i := range v {
+			destinations = append(destinations, v[i])
+		}
+	case []any:
+		destinations = v
+	}
+
+	var result []namedDestination
+	for _, v := range destinations {
+		switch v := v.(type) {
+		case string:
+			if g.lookup == nil {
+				return nil, fmt.Errorf("no shared destinations available for '%s' in gateway configuration", v)
+			}
+			dest, ok := g.lookup(v)
+			if !ok {
+				return nil, fmt.Errorf("unknown destination '%s' referenced in gateway configuration", v)
+			}
+			result = append(result, namedDestination{name: v, shared: true, Destination: dest})
+		case map[string]any:
+			if g.factory != nil {
+				name, dest, err := g.factory(v)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, namedDestination{name: name, shared: true, Destination: dest})
+				continue
+			}
+
+			dest, err := NewDestination(v)
+			if err != nil {
+				return nil, err
+			}
+
+			// Name destinations after their type, suffixed with a sequence number for any further
+			// destinations of the same type in the gateway, so that these remain distinct.
+			name, _ := v["type"].(string)
+			for n := 2; g.hasDestination(name, result); n++ {
+				name = fmt.Sprintf("%s-%d", v["type"], n)
+			}
+
+			result = append(result, namedDestination{name: name, Destination: dest})
+		default:
+			return nil, fmt.Errorf("invalid destination definition in gateway configuration")
+		}
+	}
+
+	return result, nil
+}
+
+// HasDestination returns whether a destination of the name given is configured for the [Gateway],
+// or is contained in the list of pending destinations given.
+func (g *Gateway) hasDestination(name string, pending []namedDestination) bool {
+	for _, r := range append([]route{{destinations: g.destinations}, {destinations: pending}}, g.routes...) {
+		if slices.ContainsFunc(r.destinations, func(d namedDestination) bool { return d.name == name }) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewDestination instantiates a [Destination] from the TOML configuration given, which is expected
+// to contain a 'type' field naming a registered destination type, as well as an optional table of
+// type-specific configuration, keyed under the type name.
+func NewDestination(conf map[string]any) (Destination, error) {
+	name, ok := conf["type"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("empty or missing destination type in configuration")
+	} else if _, ok = knownDestinations[name]; !ok {
+		return nil, fmt.Errorf("unknown destination type '%s' given in configuration", name)
+	}
+
+	dest := knownDestinations[name]()
+	if m, ok := dest.(tomlUnmarshaler); ok {
+		if v, ok := conf[name].(map[string]any); ok {
+			if err := m.UnmarshalTOML(v); err != nil {
+				return nil, fmt.Errorf("failed parsing configuration for destination '%s': %w", name, err)
+			}
+		}
+	}
+
+	return dest, nil
+}
+
 // ContextKey is a unique type for values stored in contexts.
 type contextKey int
 
 const (
 	// SecretKey is a context key used for storing the gateway secret for use in downstream callers.
 	secretKey contextKey = iota
+	// PathKey is a context key used for storing the gateway path for use in downstream callers.
+	pathKey
+	// RequestIDKey is a context key used for storing the ID of the request being handled.
+	requestIDKey
+	// DestinationKey is a context key used for storing the name of the destination pushed to.
+	destinationKey
 )
 
 // SetSecret returns the given [context.Context] with a secret value stored, as expected by future
@@ -240,6 +1453,53 @@ func GetSecret(ctx context.Context) string {
 	return ""
 }
 
+// SetPath returns the given [context.Context] with the gateway path stored, as expected by future
+// invocations of [GetPath].
+func SetPath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, pathKey, path)
+}
+
+// GetPath returns the path for the gateway handling the current request, as stored in the request
+// context. This is typically used for reporting purposes in [Destination] implementations.
+func GetPath(ctx context.Context) string {
+	if v, ok := ctx.Value(pathKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// SetRequestID returns the given [context.Context] with a request ID stored, as expected by future
+// invocations of [GetRequestID].
+func SetRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// GetRequestID returns the ID of the request handled by the gateway, as stored in the request
+// context, and as carried over to any messages pushed for the request. This is typically used for
+// correlating logs across gateways and destinations.
+func GetRequestID(ctx context.Context) string {
+	if v, ok := ctx.Value(requestIDKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// SetDestination returns the given [context.Context] with a destination name stored, as expected by
+// future invocations of [GetDestination].
+func SetDestination(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, destinationKey, name)
+}
+
+// GetDestination returns the name of the destination messages are being pushed to, as configured
+// for the gateway and stored in the request context. This is typically used by destinations wrapping
+// others for reporting outcomes against the name known to the gateway.
+func GetDestination(ctx context.Context) string {
+	if v, ok := ctx.Value(destinationKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
 // List of registered sources and destinations, by name.
 var (
 	knownSources      = make(map[string]func() Source)
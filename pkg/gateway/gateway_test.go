@@ -0,0 +1,744 @@
+package gateway
+
+import (
+	// Standard library.
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+type testSource struct {
+	messages []*Message
+	err      error
+}
+
+func (s *testSource) ParseHTTP(*http.Request) ([]*Message, error) { return s.messages, s.err }
+func (s *testSource) Init(context.Context) error                  { return nil }
+func (s *testSource) Close(context.Context) error                 { return nil }
+
+type testDestination struct {
+	messages []*Message
+	err      error
+}
+
+func (d *testDestination) PushMessages(_ context.Context, messages ...*Message) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.messages = append(d.messages, messages...)
+	return nil
+}
+
+func (d *testDestination) Init(context.Context) error  { return nil }
+func (d *testDestination) Close(context.Context) error { return nil }
+
+func TestGatewayHandleHTTP(t *testing.T) {
+	var testCases = []struct {
+		descr        string
+		source       *testSource
+		destinations []*testDestination
+
+		status  int
+		outcome string
+		expect  [][]*Message
+	}{
+		{
+			descr:        "source failure",
+			source:       &testSource{err: errors.New("invalid request")},
+			destinations: []*testDestination{{}},
+			status:       http.StatusBadRequest,
+			outcome:      "invalid",
+			expect:       [][]*Message{nil},
+		},
+		{
+			descr:        "push to single destination",
+			source:       &testSource{messages: []*Message{{Content: "Hello", Timestamp: testTime}}},
+			destinations: []*testDestination{{}},
+			status:       http.StatusAccepted,
+			outcome:      "accepted",
+			expect:       [][]*Message{{{Content: "Hello", Timestamp: testTime}}},
+		},
+		{
+			descr:        "push to multiple destinations",
+			source:       &testSource{messages: []*Message{{Content: "Hello", Timestamp: testTime}}},
+			destinations: []*testDestination{{}, {}},
+			status:       http.StatusAccepted,
+			outcome:      "accepted",
+			expect:       [][]*Message{{{Content: "Hello", Timestamp: testTime}}, {{Content: "Hello", Timestamp: testTime}}},
+		},
+		{
+			descr:        "partial failure in multiple destinations",
+			source:       &testSource{messages: []*Message{{Content: "Hello", Timestamp: testTime}}},
+			destinations: []*testDestination{{err: errors.New("connection lost")}, {}},
+			status:       http.StatusMultiStatus,
+			outcome:      "partial",
+			expect:       [][]*Message{nil, {{Content: "Hello", Timestamp: testTime}}},
+		},
+		{
+			descr:        "complete failure in multiple destinations",
+			source:       &testSource{messages: []*Message{{Content: "Hello", Timestamp: testTime}}},
+			destinations: []*testDestination{{err: errors.New("connection lost")}, {err: errors.New("connection lost")}},
+			status:       http.StatusBadRequest,
+			outcome:      "failed",
+			expect:       [][]*Message{nil, nil},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var options = []Option{WithPath("/test"), WithSource(tt.source)}
+			for _, d := range tt.destinations {
+				options = append(options, WithDestination("test", d))
+			}
+
+			g, err := New(options...)
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			_, h := g.HandleHTTP()
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+			if w.Code != tt.status {
+				t.Fatalf("Gateway.HandleHTTP(): want status '%d', have '%d'", tt.status, w.Code)
+			} else if have := g.Requests()[tt.outcome]; have != 1 {
+				t.Fatalf("Gateway.Requests(): want 1 request with outcome '%s', have %d", tt.outcome, have)
+			}
+
+			for i, d := range tt.destinations {
+				if !reflect.DeepEqual(d.messages, tt.expect[i]) {
+					t.Fatalf("Gateway.HandleHTTP(): want messages '%#v' for destination %d, have '%#v'", tt.expect[i], i, d.messages)
+				}
+			}
+		})
+	}
+}
+
+func TestGatewayRouting(t *testing.T) {
+	var (
+		critical = &Message{Content: "Critical", Severity: "critical", Timestamp: testTime}
+		warning  = &Message{Content: "Warning", Severity: "warning", Labels: map[string]string{"team": "ops"}, Timestamp: testTime}
+		info     = &Message{Content: "Info", Severity: "info", Timestamp: testTime}
+	)
+
+	var testCases = []struct {
+		descr  string
+		routes []Route
+		expect map[string][]*Message
+	}{
+		{
+			descr:  "no routes",
+			expect: map[string][]*Message{"default": {critical, warning, info}},
+		},
+		{
+			descr: "first matching route",
+			routes: []Route{
+				{Matchers: Matchers{must(ParseMatcher(`severity="critical"`))}, Destinations: map[string]Destination{"oncall": nil}},
+				{Matchers: Matchers{must(ParseMatcher(`severity=~"critical|warning"`))}, Destinations: map[string]Destination{"alerts": nil}},
+			},
+			expect: map[string][]*Message{"oncall": {critical}, "alerts": {warning}, "default": {info}},
+		},
+		{
+			descr: "continue to subsequent routes",
+			routes: []Route{
+				{Matchers: Matchers{must(ParseMatcher(`severity="critical"`))}, Continue: true, Destinations: map[string]Destination{"oncall": nil}},
+				{Matchers: Matchers{must(ParseMatcher(`severity!="info"`))}, Destinations: map[string]Destination{"alerts": nil, "oncall": nil}},
+			},
+			expect: map[string][]*Message{"oncall": {critical, warning}, "alerts": {critical, warning}, "default": {info}},
+		},
+		{
+			descr: "route matching on labels",
+			routes: []Route{
+				{Matchers: Matchers{must(ParseMatcher(`team="ops"`))}, Destinations: map[string]Destination{"ops": nil}},
+			},
+			expect: map[string][]*Message{"ops": {warning}, "default": {critical, info}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var destinations = map[string]*testDestination{"default": {}}
+			var options = []Option{WithPath("/test"), WithSource(&testSource{messages: []*Message{critical, warning, info}})}
+			options = append(options, WithDestination("default", destinations["default"]))
+
+			for _, r := range tt.routes {
+				for name := range r.Destinations {
+					if _, ok := destinations[name]; !ok {
+						destinations[name] = &testDestination{}
+					}
+					r.Destinations[name] = destinations[name]
+				}
+				options = append(options, WithRoute(r))
+			}
+
+			g, err := New(options...)
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			_, h := g.HandleHTTP()
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+			if w.Code != http.StatusAccepted {
+				t.Fatalf("Gateway.HandleHTTP(): want status '%d', have '%d'", http.StatusAccepted, w.Code)
+			}
+
+			for name, d := range destinations {
+				if !reflect.DeepEqual(d.messages, tt.expect[name]) {
+					t.Fatalf("Gateway.HandleHTTP(): want messages '%v' for destination '%s', have '%v'", tt.expect[name], name, d.messages)
+				}
+			}
+		})
+	}
+}
+
+func TestGatewayFilter(t *testing.T) {
+	var (
+		firing   = &Message{Content: "Firing", Status: StatusFiring, Timestamp: testTime}
+		resolved = &Message{Content: "Resolved", Status: StatusResolved, Timestamp: testTime}
+		test     = &Message{Content: "[TEST] Firing", Status: StatusFiring, Timestamp: testTime}
+	)
+
+	var testCases = []struct {
+		descr    string
+		messages []*Message
+		filters  []Matchers
+
+		status   int
+		expect   []*Message
+		filtered uint64
+	}{
+		{
+			descr:    "no filters",
+			messages: []*Message{firing, resolved, test},
+			status:   http.StatusAccepted,
+			expect:   []*Message{firing, resolved, test},
+		},
+		{
+			descr:    "some messages filtered",
+			messages: []*Message{firing, resolved, test},
+			filters: []Matchers{
+				{must(ParseMatcher(`status="resolved"`))},
+				{must(ParseMatcher(`content=~"\\[TEST\\].*"`))},
+			},
+			status:   http.StatusAccepted,
+			expect:   []*Message{firing},
+			filtered: 2,
+		},
+		{
+			descr:    "all messages filtered",
+			messages: []*Message{resolved},
+			filters:  []Matchers{{must(ParseMatcher(`status="resolved"`))}},
+			status:   http.StatusOK,
+			filtered: 1,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var d = &testDestination{}
+			var options = []Option{WithPath("/test"), WithSource(&testSource{messages: tt.messages}), WithDestination("test", d)}
+			for _, f := range tt.filters {
+				options = append(options, WithFilter(f))
+			}
+
+			g, err := New(options...)
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			_, h := g.HandleHTTP()
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+			if w.Code != tt.status {
+				t.Fatalf("Gateway.HandleHTTP(): want status '%d', have '%d'", tt.status, w.Code)
+			} else if !reflect.DeepEqual(d.messages, tt.expect) {
+				t.Fatalf("Gateway.HandleHTTP(): want messages '%v', have '%v'", tt.expect, d.messages)
+			} else if g.Filtered() != tt.filtered {
+				t.Fatalf("Gateway.Filtered(): want '%d', have '%d'", tt.filtered, g.Filtered())
+			}
+		})
+	}
+}
+
+func TestGatewayDeduplicate(t *testing.T) {
+	var source = &testSource{messages: []*Message{
+		{Content: "Hello", Timestamp: testTime},
+		{Content: "Hello", Timestamp: testTime},
+	}}
+
+	var d = &testDestination{err: errors.New("connection lost")}
+	g, err := New(WithPath("/test"), WithSource(source), WithDestination("test", d), WithDeduplicator(must(NewDeduplicator(DedupContent, time.Hour))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	_, h := g.HandleHTTP()
+	var expect = []struct {
+		status   int
+		messages int
+	}{
+		{status: http.StatusBadRequest, messages: 0}, // Failed requests are not recorded.
+		{status: http.StatusAccepted, messages: 1},   // Repeated messages in request are dropped.
+		{status: http.StatusOK, messages: 1},         // Repeated requests are dropped.
+	}
+
+	for i, e := range expect {
+		if i > 0 {
+			d.err = nil
+		}
+
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+		if w.Code != e.status {
+			t.Fatalf("Gateway.HandleHTTP(): want status '%d' for request %d, have '%d'", e.status, i+1, w.Code)
+		} else if len(d.messages) != e.messages {
+			t.Fatalf("Gateway.HandleHTTP(): want %d messages for request %d, have %d", e.messages, i+1, len(d.messages))
+		}
+	}
+
+	if g.Deduplicated() != 4 {
+		t.Fatalf("Gateway.Deduplicated(): want '4', have '%d'", g.Deduplicated())
+	}
+}
+
+func TestGatewayDeduplicatePartial(t *testing.T) {
+	var source = &testSource{messages: []*Message{{Content: "Hello", Timestamp: testTime}}}
+	var ok, failing = &testDestination{}, &testDestination{err: errors.New("connection lost")}
+
+	g, err := New(WithPath("/test"), WithSource(source), WithDestination("ok", ok), WithDestination("failing", failing),
+		WithDeduplicator(must(NewDeduplicator(DedupContent, time.Hour))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	_, h := g.HandleHTTP()
+	var expect = []struct {
+		status   int
+		ok       int
+		failing  int
+		failures bool
+	}{
+		{status: http.StatusMultiStatus, ok: 1, failing: 0, failures: true}, // Partial failures are recorded per destination.
+		{status: http.StatusAccepted, ok: 1, failing: 1},                    // Retried requests skip destinations already pushed to.
+		{status: http.StatusOK, ok: 1, failing: 1},                          // Repeated requests are dropped.
+	}
+
+	for i, e := range expect {
+		if !e.failures {
+			failing.err = nil
+		}
+
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+		if w.Code != e.status {
+			t.Fatalf("Gateway.HandleHTTP(): want status '%d' for request %d, have '%d'", e.status, i+1, w.Code)
+		} else if len(ok.messages) != e.ok || len(failing.messages) != e.failing {
+			t.Fatalf("Gateway.HandleHTTP(): want %d and %d messages for request %d, have %d and %d", e.ok, e.failing, i+1,
+				len(ok.messages), len(failing.messages))
+		}
+	}
+
+	if g.Deduplicated() != 1 {
+		t.Fatalf("Gateway.Deduplicated(): want '1', have '%d'", g.Deduplicated())
+	}
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	var testCases = []struct {
+		descr     string
+		perClient bool
+		addrs     []string
+
+		status []int
+	}{
+		{
+			descr:  "shared limit",
+			addrs:  []string{"192.0.2.1:1234", "192.0.2.1:1234", "192.0.2.2:1234"},
+			status: []int{http.StatusAccepted, http.StatusAccepted, http.StatusTooManyRequests},
+		},
+		{
+			descr:     "per-client limit",
+			perClient: true,
+			addrs:     []string{"192.0.2.1:1234", "192.0.2.1:5678", "192.0.2.1:1234", "192.0.2.2:1234"},
+			status:    []int{http.StatusAccepted, http.StatusAccepted, http.StatusTooManyRequests, http.StatusAccepted},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			g, err := New(
+				WithPath("/test"),
+				WithSource(&testSource{messages: []*Message{{Content: "Hello", Timestamp: testTime}}}),
+				WithDestination("test", &testDestination{}),
+				WithRateLimit(RateLimit{Rate: 1.0 / 60, Burst: 2}, tt.perClient),
+			)
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			_, h := g.HandleHTTP()
+			var limited uint64
+			for i, addr := range tt.addrs {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest("POST", "/test", strings.NewReader(""))
+				r.RemoteAddr = addr
+				h(w, r)
+
+				if w.Code != tt.status[i] {
+					t.Fatalf("Gateway.HandleHTTP(): want status '%d' for request %d, have '%d'", tt.status[i], i+1, w.Code)
+				} else if w.Code == http.StatusTooManyRequests {
+					limited++
+					if have := w.Header().Get("Retry-After"); have != "60" {
+						t.Fatalf("Gateway.HandleHTTP(): want Retry-After '60', have '%s'", have)
+					}
+				}
+			}
+
+			if g.RateLimited() != limited {
+				t.Fatalf("Gateway.RateLimited(): want '%d', have '%d'", limited, g.RateLimited())
+			}
+		})
+	}
+}
+
+func TestGatewaySilence(t *testing.T) {
+	var (
+		critical = &Message{Content: "Critical", Severity: "critical", Timestamp: testTime}
+		warning  = &Message{Content: "Warning", Title: "Disk full", Status: StatusFiring, Severity: "warning", Timestamp: testTime}
+		info     = &Message{Content: "Info", Title: "Disk usage", Status: StatusResolved, Severity: "info", Timestamp: testTime}
+	)
+
+	var silences = NewSilences()
+	for _, v := range []*Silence{
+		{ID: "hold", Action: SilenceHold, Matchers: Matchers{must(ParseMatcher(`severity=~"warning|info"`))}, EndsAt: time.Now().Add(time.Hour), Comment: "Maintenance"},
+		{ID: "drop", Action: SilenceDrop, EndsAt: time.Now().Add(time.Hour)},
+	} {
+		if err := silences.Add(v); err != nil {
+			t.Fatalf("Silences.Add(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	var d = &testDestination{}
+	var source = &testSource{messages: []*Message{critical, warning, info}}
+	g, err := New(WithPath("/test"), WithSource(source), WithDestination("test", d), WithSilences(silences))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	_, h := g.HandleHTTP()
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Gateway.HandleHTTP(): want status '%d', have '%d'", http.StatusOK, w.Code)
+	} else if len(d.messages) != 0 {
+		t.Fatalf("Gateway.HandleHTTP(): want no messages pushed, have %d", len(d.messages))
+	} else if g.Silenced() != 3 {
+		t.Fatalf("Gateway.Silenced(): want '3', have '%d'", g.Silenced())
+	}
+
+	g.Release("hold")
+
+	var expect = []*Message{{
+		Content:   "2 notifications were held during silence 'Maintenance':\n- [FIRING] Disk full\n- [RESOLVED] Disk usage",
+		Title:     "2 notifications held during silence",
+		Body:      "2 notifications were held during silence 'Maintenance':\n- [FIRING] Disk full\n- [RESOLVED] Disk usage",
+		Status:    StatusFiring,
+		Timestamp: testTime,
+	}}
+
+	if !reflect.DeepEqual(d.messages, expect) {
+		t.Fatalf("Gateway.Release(): want messages '%#v', have '%#v'", expect, d.messages)
+	}
+}
+
+func TestGatewayHoldPersist(t *testing.T) {
+	schedule, err := ParseSchedule(map[string]any{"time": "00:00-24:00", "weekdays": "mon-sun", "timezone": "Etc/GMT-2"})
+	if err != nil {
+		t.Fatalf("ParseSchedule(): want error 'nil', have '%s'", err)
+	}
+
+	var testCases = []struct {
+		descr   string
+		silence *Silence
+	}{
+		{
+			descr:   "silence with fixed end",
+			silence: &Silence{ID: "hold", Action: SilenceHold, EndsAt: time.Now().Add(time.Hour)},
+		},
+		{
+			descr:   "silence with schedule",
+			silence: &Silence{ID: "hold", Action: SilenceHold, Schedule: schedule},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var dir = t.TempDir()
+			var silences = NewSilences()
+			if err := silences.Add(tt.silence); err != nil {
+				t.Fatalf("Silences.Add(): want error 'nil', have '%s'", err)
+			}
+
+			var newGateway = func(d *testDestination, source *testSource) *Gateway {
+				g, err := New(WithPath("/test"), WithSource(source), WithDestination("test", d), WithSilences(silences), WithStateDir(dir))
+				if err != nil {
+					t.Fatalf("New(): want error 'nil', have '%s'", err)
+				} else if err := g.Init(context.Background()); err != nil {
+					t.Fatalf("Gateway.Init(): want error 'nil', have '%s'", err)
+				}
+				return g
+			}
+
+			// Messages held are kept for future runs on closing, rather than being released.
+			var prev = &testDestination{}
+			g := newGateway(prev, &testSource{messages: []*Message{{Content: "Hello"}}})
+
+			_, h := g.HandleHTTP()
+			h(httptest.NewRecorder(), httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+			if err := g.Close(context.Background()); err != nil {
+				t.Fatalf("Gateway.Close(): want error 'nil', have '%s'", err)
+			} else if len(prev.messages) != 0 {
+				t.Fatalf("Gateway.Close(): want no messages released, have '%v'", prev.messages)
+			}
+
+			// Messages held in previous runs are loaded, and are adopted by replacement gateways along
+			// with any messages held since.
+			var loaded, next, source = &testDestination{}, &testDestination{}, &testSource{messages: []*Message{{Content: "World"}}}
+			g = newGateway(loaded, source)
+			n := newGateway(next, &testSource{})
+
+			_, h = g.HandleHTTP()
+			h(httptest.NewRecorder(), httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+			n.Adopt(g)
+			source.messages = []*Message{{Content: "!"}}
+			h(httptest.NewRecorder(), httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+			if err := g.Close(context.Background()); err != nil {
+				t.Fatalf("Gateway.Close(): want error 'nil', have '%s'", err)
+			}
+
+			n.Release("hold")
+
+			var want = "3 notifications were held during silence:\n- Hello\n- World\n- !"
+			if len(loaded.messages) != 0 {
+				t.Fatalf("Gateway.Release(): want no messages released for adopted gateway, have '%v'", loaded.messages)
+			} else if len(next.messages) != 1 || next.messages[0].Content != want {
+				t.Fatalf("Gateway.Release(): want summary with content '%s', have '%v'", want, next.messages)
+			}
+		})
+	}
+}
+
+func TestGatewayAlertTracking(t *testing.T) {
+	var source = &testSource{}
+	var d = &testDestination{}
+	g, err := New(
+		WithPath("/test"),
+		WithSource(source),
+		WithDestination("test", d),
+		WithAlertTracker(NewAlertTracker(0)),
+		WithAlertKey("alertname"),
+		WithTemplate(`{{.Title}}{{with .Alert}}{{if eq .Status "resolved"}} (resolved after {{.Duration}}){{end}}{{end}}`),
+	)
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	var start = time.Now().Add(-12 * time.Minute)
+	var expect = []struct {
+		message *Message
+		content string
+	}{
+		{
+			message: &Message{Title: "Disk full", Status: StatusFiring, Labels: map[string]string{"alertname": "DiskFull", "value": "95"}, Timestamp: start},
+			content: "Disk full",
+		},
+		{
+			message: &Message{Title: "Disk full", Status: StatusResolved, Labels: map[string]string{"alertname": "DiskFull", "value": "80"}, Timestamp: start},
+			content: "Disk full (resolved after 12m0s)",
+		},
+	}
+
+	_, h := g.HandleHTTP()
+	for i, e := range expect {
+		source.messages = []*Message{e.message}
+		h(httptest.NewRecorder(), httptest.NewRequest("POST", "/test", strings.NewReader("")))
+
+		if len(d.messages) != i+1 {
+			t.Fatalf("Gateway.HandleHTTP(): want %d messages, have %d", i+1, len(d.messages))
+		} else if msg := d.messages[i]; msg.Alert == nil || msg.Alert.Gateway != "/test" {
+			t.Fatalf("Gateway.HandleHTTP(): want alert state for message %d, have '%+v'", i+1, msg.Alert)
+		} else if msg.Content != e.content {
+			t.Fatalf("Gateway.HandleHTTP(): want content '%s', have '%s'", e.content, msg.Content)
+		}
+	}
+
+	if d.messages[0].Alert.Key != d.messages[1].Alert.Key {
+		t.Fatalf("Gateway.HandleHTTP(): want same alert key for messages, have '%s' and '%s'", d.messages[0].Alert.Key, d.messages[1].Alert.Key)
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestGatewayUnmarshalTOML(t *testing.T) {
+	RegisterDestination("test", func() Destination { return &testDestination{} })
+
+	var testCases = []struct {
+		descr string
+		data  any
+
+		destinations int
+		routes       int
+		names        []string
+		err          error
+	}{
+		{
+			descr:        "single destination table",
+			data:         map[string]any{"destination": map[string]any{"type": "test"}},
+			destinations: 1,
+			names:        []string{"test"},
+		},
+		{
+			descr: "multiple destination tables",
+			data: map[string]any{"destination": []map[string]any{
+				{"type": "test"},
+				{"type": "test"},
+			}},
+			destinations: 2,
+			names:        []string{"test", "test-2"},
+		},
+		{
+			descr: "unknown destination type",
+			data: map[string]any{"destination": []map[string]any{
+				{"type": "test"},
+				{"type": "foo"},
+			}},
+			err: errors.New("unknown destination type 'foo' given in configuration"),
+		},
+		{
+			descr:        "shared destination reference",
+			data:         map[string]any{"destination": "shared"},
+			destinations: 1,
+			names:        []string{"shared"},
+		},
+		{
+			descr:        "mixed shared and inline destinations",
+			data:         map[string]any{"destination": []any{"shared", map[string]any{"type": "test"}}},
+			destinations: 2,
+			names:        []string{"shared", "test"},
+		},
+		{
+			descr: "unknown destination reference",
+			data:  map[string]any{"destination": []any{"shared", "unknown"}},
+			err:   errors.New("unknown destination 'unknown' referenced in gateway configuration"),
+		},
+		{
+			descr: "routes with shared and inline destinations",
+			data: map[string]any{
+				"destination": "shared",
+				"route": []map[string]any{
+					{"matchers": []any{`severity="critical"`}, "destination": "shared", "continue": true},
+					{"matchers": `team=~"ops|infra"`, "destination": map[string]any{"type": "test"}},
+				},
+			},
+			destinations: 1,
+			routes:       2,
+			names:        []string{"shared", "test"},
+		},
+		{
+			descr: "routes with inline destinations of same type",
+			data: map[string]any{
+				"destination": map[string]any{"type": "test"},
+				"route": []map[string]any{
+					{"matchers": []any{`severity="critical"`}, "destination": map[string]any{"type": "test"}},
+				},
+			},
+			destinations: 1,
+			routes:       1,
+			names:        []string{"test", "test-2"},
+		},
+		{
+			descr: "route with invalid matcher",
+			data: map[string]any{
+				"route": []map[string]any{{"matchers": []any{`severity`}, "destination": "shared"}},
+			},
+			err: errors.New("failed parsing matchers for route 1 in gateway configuration: invalid matcher expression 'severity'"),
+		},
+		{
+			descr: "route without destination",
+			data: map[string]any{
+				"route": []map[string]any{{"matchers": []any{`severity="critical"`}}},
+			},
+			err: errors.New("no destination given for route 1 in gateway configuration"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			g := &Gateway{lookup: func(name string) (Destination, bool) {
+				if name == "shared" {
+					return &testDestination{}, true
+				}
+				return nil, false
+			}}
+
+			err := g.UnmarshalTOML(tt.data)
+			if (err != nil && tt.err == nil) || (err == nil && tt.err != nil) {
+				t.Fatalf("Gateway.UnmarshalTOML(): want error '%v', have '%v'", tt.err, err)
+			} else if err != nil && tt.err != nil && err.Error() != tt.err.Error() {
+				t.Fatalf("Gateway.UnmarshalTOML(): want error '%s', have '%s'", tt.err.Error(), err.Error())
+			} else if err == nil && len(g.destinations) != tt.destinations {
+				t.Fatalf("Gateway.UnmarshalTOML(): want %d destinations, have %d", tt.destinations, len(g.destinations))
+			} else if err == nil && len(g.routes) != tt.routes {
+				t.Fatalf("Gateway.UnmarshalTOML(): want %d routes, have %d", tt.routes, len(g.routes))
+			} else if err == nil && !reflect.DeepEqual(g.Destinations(), tt.names) {
+				t.Fatalf("Gateway.Destinations(): want '%v', have '%v'", tt.names, g.Destinations())
+			}
+		})
+	}
+}
+
+func TestGatewayDestinationFactory(t *testing.T) {
+	var created int
+	factory := func(conf map[string]any) (string, Destination, error) {
+		created++
+		return fmt.Sprintf("inline-%d", created), &testDestination{}, nil
+	}
+
+	g, err := New(WithDestinationFactory(factory))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	err = g.UnmarshalTOML(map[string]any{"destination": []map[string]any{{"type": "test"}, {"type": "test"}}})
+	if err != nil {
+		t.Fatalf("Gateway.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	}
+
+	// Destinations are named as assigned by the factory, rather than after their type.
+	if want := []string{"inline-1", "inline-2"}; !reflect.DeepEqual(g.Destinations(), want) {
+		t.Fatalf("Gateway.Destinations(): want '%v', have '%v'", want, g.Destinations())
+	}
+}
@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	// Standard library.
+	"context"
+	"sync"
+	"time"
+)
+
+// A HealthChecker is any [Source] or [Destination] able to report on its own health, e.g. whether
+// connections to remote endpoints are currently established. Implementing this interface is
+// optional, and sources or destinations that do not are assumed to always be healthy.
+type HealthChecker interface {
+	Healthy(context.Context) error
+}
+
+// An Activity represents the outcome of the most recent successful and failed operations for some
+// component, e.g. requests handled by a [Gateway].
+type Activity struct {
+	LastSuccess time.Time // The time of the most recent successful operation, if any.
+	LastFailure time.Time // The time of the most recent failed operation, if any.
+	LastError   string    // The error for the most recent failed operation, if any.
+}
+
+// An ActivityTracker records the outcome of operations for some component, as reported by
+// [Activity]. The zero value is ready for use, and trackers are safe for concurrent use.
+type ActivityTracker struct {
+	mu       sync.Mutex
+	activity Activity
+}
+
+// Success records a successful operation at the current time.
+func (t *ActivityTracker) Success() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.activity.LastSuccess = time.Now()
+}
+
+// Failure records a failed operation at the current time, for the error given.
+func (t *ActivityTracker) Failure(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.activity.LastFailure, t.activity.LastError = time.Now(), err.Error()
+}
+
+// Activity returns the [Activity] for all operations recorded.
+func (t *ActivityTracker) Activity() Activity {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.activity
+}
@@ -0,0 +1,408 @@
+package gateway
+
+import (
+	// Standard library.
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default options for [History] instances.
+const (
+	defaultHistorySize    = 100
+	defaultHistoryMaxBody = 64 * 1024
+)
+
+// Placeholder for values redacted from request history.
+const redacted = "[REDACTED]"
+
+// Sensitive headers, and parts of header names denoting sensitive headers, the values of which are
+// redacted from request history.
+var (
+	redactedHeaders     = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	redactedHeaderParts = []string{"token", "secret", "signature", "password", "auth", "key"}
+)
+
+// A HistoryEntry represents a single request handled by a [Gateway], as recorded in [History],
+// along with the outcome of processing the request, and of delivering any messages produced.
+type HistoryEntry struct {
+	ID            string              `json:"id"`
+	ClientID      string              `json:"client_request_id,omitempty"` // As given in 'X-Request-Id', if any.
+	Gateway       string              `json:"gateway"`
+	ReceivedAt    time.Time           `json:"received_at"`
+	RemoteAddr    string              `json:"remote_addr"`
+	Method        string              `json:"method"`
+	URL           string              `json:"url"`
+	Headers       map[string][]string `json:"headers"`
+	Body          string              `json:"body,omitempty"`
+	BodyTruncated bool                `json:"body_truncated,omitempty"`
+	Status        int                 `json:"status,omitempty"`
+	Outcome       string              `json:"outcome,omitempty"`
+	Error         string              `json:"error,omitempty"`
+	Messages      []*Message          `json:"messages,omitempty"`   // Messages as parsed by the source.
+	Deliveries    []*HistoryDelivery  `json:"deliveries,omitempty"` // Outcomes for each destination selected.
+}
+
+// A HistoryDelivery represents the outcome of pushing messages for a [HistoryEntry] to a single
+// destination, and of delivering these, where destinations report delivery outcomes separately.
+type HistoryDelivery struct {
+	Destination string    `json:"destination"`
+	Messages    int       `json:"messages"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Statuses for [HistoryDelivery] values.
+const (
+	DeliveryPushed    = "pushed"    // Messages were accepted by the destination.
+	DeliveryFailed    = "failed"    // Messages were rejected by the destination.
+	DeliveryDelivered = "delivered" // Messages were delivered, e.g. after being queued.
+	DeliveryRetrying  = "retrying"  // Delivery failed, and is to be retried.
+	DeliveryDropped   = "dropped"   // Delivery failed, and was not retried further.
+)
+
+// Clone returns a copy of the [HistoryEntry], safe for use outside of the [History] it belongs to.
+func (e *HistoryEntry) clone() *HistoryEntry {
+	var c = *e
+	c.Deliveries = make([]*HistoryDelivery, len(e.Deliveries))
+	for i, d := range e.Deliveries {
+		v := *d
+		c.Deliveries[i] = &v
+	}
+	return &c
+}
+
+// Delivery returns the [HistoryDelivery] for the destination name given, adding one if needed.
+func (e *HistoryEntry) delivery(destination string) *HistoryDelivery {
+	for _, d := range e.Deliveries {
+		if d.Destination == destination {
+			return d
+		}
+	}
+
+	d := &HistoryDelivery{Destination: destination}
+	e.Deliveries = append(e.Deliveries, d)
+	return d
+}
+
+// History represents a bounded store of recent requests handled by gateways, keeping a fixed number
+// of entries for each gateway, and evicting the oldest entries first. History can optionally be
+// persisted to a file, to which entries are appended as these change, and which is compacted
+// periodically; writes are not synchronized to disk, and recent entries may be lost on crashes.
+type History struct {
+	size    int
+	maxBody int
+
+	// Internal fields.
+	mu      sync.Mutex
+	entries map[string][]*HistoryEntry // Entries for each gateway, oldest first.
+	byID    map[string]*HistoryEntry
+	path    string
+	file    *os.File
+	writes  int // Number of records written since the history file was last compacted.
+}
+
+// NewHistory returns a [History] store keeping the given number of entries for each gateway, and
+// recording request bodies up to the maximum size given, in bytes. Sizes that are not positive are
+// replaced with defaults.
+func NewHistory(size, maxBody int) *History {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	if maxBody <= 0 {
+		maxBody = defaultHistoryMaxBody
+	}
+
+	return &History{
+		size:    size,
+		maxBody: maxBody,
+		entries: make(map[string][]*HistoryEntry),
+		byID:    make(map[string]*HistoryEntry),
+	}
+}
+
+// Load reads entries persisted in the file at the path given, if any, and persists any further
+// changes to the same file.
+func (h *History) Load(path string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		h.path = path
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed opening history file: %w", err)
+	}
+
+	defer f.Close()
+
+	// Later records for any entry replace earlier ones, and are kept in their original order.
+	var scanner = bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.ID == "" {
+			continue
+		} else if prev, ok := h.byID[e.ID]; ok {
+			*prev = e
+			continue
+		}
+		h.insert(&e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed reading history file: %w", err)
+	}
+
+	h.path = path
+	return h.compact()
+}
+
+// Close closes the history file, if any.
+func (h *History) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.file == nil {
+		return nil
+	}
+
+	err := h.file.Close()
+	h.file = nil
+	return err
+}
+
+// Add records the given [HistoryEntry], evicting the oldest entry for the same gateway if the
+// maximum number of entries has been reached. The entry is owned by the history once added, and
+// should only be changed via [History.Update].
+func (h *History) Add(e *HistoryEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.insert(e)
+	h.persist(e)
+}
+
+// Update applies the given function to the entry for the ID given, if any.
+func (h *History) Update(id string, fn func(*HistoryEntry)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e, ok := h.byID[id]
+	if !ok {
+		return
+	}
+
+	fn(e)
+	h.persist(e)
+}
+
+// RecordDelivery updates the delivery outcome for the entry and destination given, as reported by
+// destinations delivering messages asynchronously. Delivery errors are given for failed attempts.
+func (h *History) RecordDelivery(id, destination, status string, attempts int, err error) {
+	if id == "" {
+		return
+	}
+
+	h.Update(id, func(e *HistoryEntry) {
+		d := e.delivery(destination)
+		d.Status, d.Attempts, d.Error, d.UpdatedAt = status, attempts, "", time.Now()
+		if err != nil {
+			d.Error = err.Error()
+		}
+	})
+}
+
+// List returns copies of all entries recorded for the gateway name given, or for all gateways if
+// the name is empty, most recent first, and up to the limit given, if positive.
+func (h *History) List(gateway string, limit int) []*HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var result []*HistoryEntry
+	for name, entries := range h.entries {
+		if gateway != "" && name != gateway {
+			continue
+		}
+		for _, e := range entries {
+			result = append(result, e.clone())
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b *HistoryEntry) int { return b.ReceivedAt.Compare(a.ReceivedAt) })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+// Get returns a copy of the entry for the ID given, if any.
+func (h *History) Get(id string) (*HistoryEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if e, ok := h.byID[id]; ok {
+		return e.clone(), true
+	}
+
+	return nil, false
+}
+
+// Insert adds the given entry, evicting the oldest entry for the same gateway if needed. The
+// history lock is expected to be held.
+func (h *History) insert(e *HistoryEntry) {
+	// Entries sharing an ID with existing entries replace these.
+	if prev, ok := h.byID[e.ID]; ok {
+		h.entries[prev.Gateway] = slices.DeleteFunc(h.entries[prev.Gateway], func(v *HistoryEntry) bool { return v == prev })
+	}
+
+	var entries = append(h.entries[e.Gateway], e)
+	if len(entries) > h.size {
+		for _, v := range entries[:len(entries)-h.size] {
+			if h.byID[v.ID] == v {
+				delete(h.byID, v.ID)
+			}
+		}
+		entries = slices.Clone(entries[len(entries)-h.size:])
+	}
+
+	h.entries[e.Gateway], h.byID[e.ID] = entries, e
+}
+
+// Persist appends the given entry to the history file, if any, compacting the file once it has grown
+// to twice the size needed for all entries currently held. Errors in persisting entries are not
+// reported, as history is kept on a best-effort basis. The history lock is expected to be held.
+func (h *History) persist(e *HistoryEntry) {
+	if h.path == "" {
+		return
+	} else if h.writes >= 2*max(len(h.byID), h.size) {
+		_ = h.compact()
+		return
+	}
+
+	if h.file == nil {
+		if err := os.MkdirAll(filepath.Dir(h.path), 0o750); err != nil {
+			return
+		}
+		f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return
+		}
+		h.file = f
+	}
+
+	if buf, err := json.Marshal(e); err == nil {
+		_, _ = h.file.Write(append(buf, '\n'))
+		h.writes++
+	}
+}
+
+// Compact rewrites the history file to only contain entries currently held. The history lock is
+// expected to be held.
+func (h *History) compact() error {
+	if h.file != nil {
+		_ = h.file.Close()
+		h.file = nil
+	}
+
+	var buf strings.Builder
+	for _, entries := range h.entries {
+		for _, e := range entries {
+			if b, err := json.Marshal(e); err == nil {
+				buf.Write(b)
+				buf.WriteByte('\n')
+			}
+		}
+	}
+
+	if err := writeFile(h.path, []byte(buf.String())); err != nil {
+		return fmt.Errorf("failed writing history file: %w", err)
+	}
+
+	h.writes = 0
+	return nil
+}
+
+// NewHistoryEntry returns a [HistoryEntry] for the request given, with sensitive headers, and any
+// occurrences of the gateway secret given, redacted. The request body is captured as it is read,
+// up to the maximum size configured for the [History], and is attached to the entry once the
+// function returned is called.
+func (h *History) newEntry(r *http.Request, id, gateway, secret string) (*HistoryEntry, func(*HistoryEntry)) {
+	var redact = func(s string) string {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+		return s
+	}
+
+	var e = &HistoryEntry{
+		ID:         id,
+		ClientID:   clientRequestID(r),
+		Gateway:    gateway,
+		ReceivedAt: time.Now(),
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		URL:        gateway,
+		Headers:    make(map[string][]string, len(r.Header)),
+	}
+
+	if r.URL.RawQuery != "" {
+		e.URL += "?" + redact(r.URL.RawQuery)
+	}
+
+	for name, values := range r.Header {
+		var sensitive = slices.Contains(redactedHeaders, name)
+		for _, part := range redactedHeaderParts {
+			sensitive = sensitive || strings.Contains(strings.ToLower(name), part)
+		}
+		for _, v := range values {
+			if sensitive {
+				v = redacted
+			}
+			e.Headers[name] = append(e.Headers[name], redact(v))
+		}
+	}
+
+	var capture = &captureReader{ReadCloser: r.Body, max: h.maxBody}
+	r.Body = capture
+
+	return e, func(e *HistoryEntry) {
+		e.Body, e.BodyTruncated = redact(capture.buf.String()), capture.truncated
+	}
+}
+
+// A CaptureReader wraps an [io.ReadCloser], capturing data read from it up to a maximum size.
+type captureReader struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+// Read reads from the underlying reader, capturing any data read, up to the maximum size.
+func (c *captureReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if room := c.max - c.buf.Len(); n > room {
+		c.buf.Write(p[:max(room, 0)])
+		c.truncated = true
+	} else {
+		c.buf.Write(p[:n])
+	}
+
+	return n, err
+}
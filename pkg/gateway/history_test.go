@@ -0,0 +1,255 @@
+package gateway
+
+import (
+	// Standard library.
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A BodySource returns a single message with the full request body as content.
+type bodySource struct {
+	testSource
+}
+
+func (s *bodySource) ParseHTTP(r *http.Request) ([]*Message, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return []*Message{{Content: string(body)}}, nil
+}
+
+func TestGatewayHistory(t *testing.T) {
+	var testCases = []struct {
+		descr  string
+		body   string
+		header http.Header
+		dest   *testDestination
+
+		wantStatus  int
+		wantOutcome string
+		wantBody    string
+		wantHeaders http.Header
+		wantError   string
+		wantDeliver []HistoryDelivery
+	}{
+		{
+			descr:       "accepted request",
+			body:        `{"title": "test"}`,
+			header:      http.Header{"Content-Type": {"application/json"}},
+			dest:        &testDestination{},
+			wantStatus:  http.StatusAccepted,
+			wantOutcome: "accepted",
+			wantBody:    `{"title": "test"}`,
+			wantHeaders: http.Header{"Content-Type": {"application/json"}},
+			wantDeliver: []HistoryDelivery{{Destination: "test", Messages: 1, Status: DeliveryPushed}},
+		},
+		{
+			descr: "sensitive headers and secret redacted",
+			body:  `{"token": "s3cr3t"}`,
+			header: http.Header{
+				"Authorization":   {"Bearer s3cr3t"},
+				"X-Api-Key":       {"abc"},
+				"X-Forwarded-For": {"s3cr3t.example.com"},
+			},
+			dest:        &testDestination{},
+			wantStatus:  http.StatusAccepted,
+			wantOutcome: "accepted",
+			wantBody:    `{"token": "[REDACTED]"}`,
+			wantHeaders: http.Header{
+				"Authorization":   {"[REDACTED]"},
+				"X-Api-Key":       {"[REDACTED]"},
+				"X-Forwarded-For": {"[REDACTED].example.com"},
+			},
+			wantDeliver: []HistoryDelivery{{Destination: "test", Messages: 1, Status: DeliveryPushed}},
+		},
+		{
+			descr:       "truncated body",
+			body:        strings.Repeat("a", 40),
+			dest:        &testDestination{},
+			wantStatus:  http.StatusAccepted,
+			wantOutcome: "accepted",
+			wantBody:    strings.Repeat("a", 32),
+			wantHeaders: http.Header{},
+			wantDeliver: []HistoryDelivery{{Destination: "test", Messages: 1, Status: DeliveryPushed}},
+		},
+		{
+			descr:       "failed destination",
+			body:        "test",
+			dest:        &testDestination{err: errors.New("connection refused")},
+			wantStatus:  http.StatusBadRequest,
+			wantOutcome: "failed",
+			wantBody:    "test",
+			wantHeaders: http.Header{},
+			wantError:   "failed pushing notification messages: destination 'test': connection refused",
+			wantDeliver: []HistoryDelivery{{Destination: "test", Messages: 1, Status: DeliveryFailed, Error: "connection refused"}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var history = NewHistory(10, 32)
+			g, err := New(
+				WithSecret("s3cr3t"),
+				WithSource(&bodySource{}),
+				WithDestination("test", tt.dest),
+				WithHistory(history),
+			)
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			} else if err := g.Init(context.Background()); err != nil {
+				t.Fatalf("Gateway.Init(): want error 'nil', have '%s'", err)
+			}
+
+			r := httptest.NewRequest("POST", "/s3cr3t", strings.NewReader(tt.body))
+			r.Header = tt.header.Clone()
+			if r.Header == nil {
+				r.Header = make(http.Header)
+			}
+			r.Header.Set("X-Request-Id", "abc-123")
+			w := httptest.NewRecorder()
+
+			_, h := g.HandleHTTP()
+			h(w, r)
+
+			// Entries are recorded under the request ID generated, rather than the one given.
+			e, ok := history.Get(w.Header().Get("X-Request-Id"))
+			if !ok {
+				t.Fatalf("History.Get(): want entry for request, have none")
+			} else if _, ok := history.Get("abc-123"); ok {
+				t.Fatalf("History.Get(): want no entry for client request ID, have one")
+			} else if e.ClientID != "abc-123" {
+				t.Fatalf("History.Get(): want client request ID 'abc-123', have '%s'", e.ClientID)
+			} else if e.Gateway != g.Name() || e.URL != g.Name() {
+				t.Fatalf("History.Get(): want gateway '%s', have '%s' with URL '%s'", g.Name(), e.Gateway, e.URL)
+			} else if e.Status != tt.wantStatus || e.Outcome != tt.wantOutcome || e.Error != tt.wantError {
+				t.Fatalf("History.Get(): want status '%d', outcome '%s' and error '%s', have '%d', '%s' and '%s'",
+					tt.wantStatus, tt.wantOutcome, tt.wantError, e.Status, e.Outcome, e.Error)
+			} else if e.Body != tt.wantBody || e.BodyTruncated != (len(tt.body) > 32) {
+				t.Fatalf("History.Get(): want body '%s', have '%s' (truncated: %v)", tt.wantBody, e.Body, e.BodyTruncated)
+			} else if len(e.Messages) != 1 || e.Messages[0].Content != tt.body {
+				t.Fatalf("History.Get(): want parsed message with content '%s', have '%+v'", tt.body, e.Messages)
+			}
+
+			tt.wantHeaders.Set("X-Request-Id", "abc-123")
+			if have, want := fmt.Sprint(e.Headers), fmt.Sprint(map[string][]string(tt.wantHeaders)); have != want {
+				t.Fatalf("History.Get(): want headers '%s', have '%s'", want, have)
+			} else if len(e.Deliveries) != len(tt.wantDeliver) {
+				t.Fatalf("History.Get(): want %d deliveries, have %d", len(tt.wantDeliver), len(e.Deliveries))
+			}
+
+			for i, want := range tt.wantDeliver {
+				have := *e.Deliveries[i]
+				have.UpdatedAt = want.UpdatedAt
+				if have != want {
+					t.Fatalf("History.Get(): want delivery '%+v', have '%+v'", want, have)
+				}
+			}
+		})
+	}
+}
+
+func TestHistoryRecordDelivery(t *testing.T) {
+	var history = NewHistory(10, 0)
+	history.Add(&HistoryEntry{ID: "abc", Gateway: "/test", Deliveries: []*HistoryDelivery{{Destination: "test", Status: DeliveryPushed}}})
+
+	history.RecordDelivery("abc", "test", DeliveryRetrying, 1, errors.New("timeout"))
+	history.RecordDelivery("abc", "other", DeliveryDelivered, 1, nil)
+	history.RecordDelivery("unknown", "test", DeliveryDelivered, 1, nil)
+
+	e, _ := history.Get("abc")
+	if len(e.Deliveries) != 2 {
+		t.Fatalf("History.RecordDelivery(): want 2 deliveries, have %d", len(e.Deliveries))
+	} else if d := e.Deliveries[0]; d.Status != DeliveryRetrying || d.Attempts != 1 || d.Error != "timeout" {
+		t.Fatalf("History.RecordDelivery(): want retrying delivery for 'test', have '%+v'", d)
+	} else if d := e.Deliveries[1]; d.Destination != "other" || d.Status != DeliveryDelivered || d.Error != "" {
+		t.Fatalf("History.RecordDelivery(): want delivered delivery for 'other', have '%+v'", d)
+	}
+
+	// Entries returned are copies, and are not changed by further updates.
+	history.RecordDelivery("abc", "test", DeliveryDelivered, 2, nil)
+	if d := e.Deliveries[0]; d.Status != DeliveryRetrying {
+		t.Fatalf("History.Get(): want copy of entry, have delivery updated to '%s'", d.Status)
+	}
+}
+
+func TestHistoryList(t *testing.T) {
+	var history, now = NewHistory(3, 0), time.Now()
+	for i := range 5 {
+		history.Add(&HistoryEntry{ID: fmt.Sprintf("a%d", i), Gateway: "/a", ReceivedAt: now.Add(time.Duration(i) * time.Second)})
+	}
+
+	// Entries with repeated IDs replace existing entries.
+	history.Add(&HistoryEntry{ID: "b0", Gateway: "/b", ReceivedAt: now.Add(5 * time.Second)})
+	history.Add(&HistoryEntry{ID: "a4", Gateway: "/a", ReceivedAt: now.Add(6 * time.Second)})
+
+	var testCases = []struct {
+		gateway string
+		limit   int
+		want    []string
+	}{
+		{gateway: "/a", want: []string{"a4", "a3", "a2"}},
+		{gateway: "/a", limit: 2, want: []string{"a4", "a3"}},
+		{gateway: "/b", want: []string{"b0"}},
+		{gateway: "/c", want: nil},
+		{want: []string{"a4", "b0", "a3", "a2"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%s/%d", tt.gateway, tt.limit), func(t *testing.T) {
+			var have []string
+			for _, e := range history.List(tt.gateway, tt.limit) {
+				have = append(have, e.ID)
+			}
+			if fmt.Sprint(have) != fmt.Sprint(tt.want) {
+				t.Fatalf("History.List(): want entries '%v', have '%v'", tt.want, have)
+			}
+		})
+	}
+
+	if _, ok := history.Get("a0"); ok {
+		t.Fatalf("History.Get(): want evicted entry to be removed, have entry")
+	}
+}
+
+func TestHistoryLoad(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "history.log")
+
+	var history = NewHistory(2, 0)
+	if err := history.Load(path); err != nil {
+		t.Fatalf("History.Load(): want error 'nil', have '%s'", err)
+	}
+
+	for i := range 3 {
+		history.Add(&HistoryEntry{ID: fmt.Sprintf("a%d", i), Gateway: "/a", ReceivedAt: time.Now().Add(time.Duration(i) * time.Second)})
+	}
+	history.RecordDelivery("a2", "test", DeliveryDelivered, 1, nil)
+	if err := history.Close(); err != nil {
+		t.Fatalf("History.Close(): want error 'nil', have '%s'", err)
+	}
+
+	var loaded = NewHistory(2, 0)
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("History.Load(): want error 'nil', have '%s'", err)
+	}
+	defer loaded.Close()
+
+	var have []string
+	for _, e := range loaded.List("", 0) {
+		have = append(have, e.ID)
+	}
+
+	if want := []string{"a2", "a1"}; fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Fatalf("History.Load(): want entries '%v', have '%v'", want, have)
+	} else if e, _ := loaded.Get("a2"); len(e.Deliveries) != 1 || e.Deliveries[0].Status != DeliveryDelivered {
+		t.Fatalf("History.Load(): want latest record for entry, have '%+v'", e)
+	}
+}
@@ -0,0 +1,47 @@
+package gateway
+
+import (
+	// Standard library.
+	"context"
+	"log/slog"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/trace"
+)
+
+// A LogHandler is a [slog.Handler] attaching request and trace identifiers, as stored in contexts
+// given to logging functions, to all log records handled.
+type logHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler returns a [slog.Handler] wrapping the handler given, and attaching the request ID
+// (as stored via [SetRequestID]) and the trace and span IDs (as stored via [trace.Start]) for the
+// context given to logging functions, where set, to all log records. Only context-aware logging
+// functions, e.g. [slog.Logger.InfoContext], are able to attach these identifiers.
+func NewLogHandler(h slog.Handler) slog.Handler {
+	return &logHandler{h}
+}
+
+// Handle attaches identifiers for the context given to the record given, and passes it on to the
+// underlying handler.
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := GetRequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request-id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace-id", sc.TraceID.String()), slog.String("span-id", sc.SpanID.String()))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler wrapping the underlying handler with the attributes given.
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler wrapping the underlying handler with the group name given.
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{h.Handler.WithGroup(name)}
+}
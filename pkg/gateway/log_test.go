@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	// Standard library.
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/trace"
+)
+
+// A LoggingDestination logs a line for every push made to it, using the context given.
+type loggingDestination struct {
+	testDestination
+	logger *slog.Logger
+}
+
+func (d *loggingDestination) PushMessages(ctx context.Context, messages ...*Message) error {
+	d.logger.InfoContext(ctx, "Pushed messages", "count", len(messages))
+	return d.testDestination.PushMessages(ctx, messages...)
+}
+
+func TestLogHandler(t *testing.T) {
+	var testCases = []struct {
+		descr     string
+		requestID string
+
+		want []string
+	}{
+		{
+			descr:     "request ID given",
+			requestID: "abc-123",
+			want:      []string{"trace-id=4bf92f3577b34da6a3ce929d0e0e4736", "span-id="},
+		},
+		{
+			descr:     "invalid request ID given",
+			requestID: "abc 123",
+			want:      []string{"trace-id=4bf92f3577b34da6a3ce929d0e0e4736"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var buf strings.Builder
+			var logger = slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil))).With("component", "test")
+			var dest = &loggingDestination{logger: logger}
+
+			g, err := New(
+				WithPath("/test"),
+				WithSource(&testSource{messages: []*Message{{Title: "test"}}}),
+				WithDestination("test", dest),
+				WithLogger(logger),
+			)
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			r := httptest.NewRequest("POST", "/test", nil)
+			r.Header.Set("X-Request-Id", tt.requestID)
+			r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			w := httptest.NewRecorder()
+
+			_, h := g.HandleHTTP()
+			h(w, r)
+
+			var id = w.Header().Get("X-Request-Id")
+			if w.Code != http.StatusAccepted {
+				t.Fatalf("Gateway.HandleHTTP(): want status '%d', have '%d'", http.StatusAccepted, w.Code)
+			} else if len(id) != 16 {
+				t.Fatalf("Gateway.HandleHTTP(): want generated request ID, have '%s'", id)
+			}
+
+			var line = buf.String()
+			if !strings.Contains(line, "request-id="+id) {
+				t.Fatalf("NewLogHandler(): want request ID '%s' in log, have '%s'", id, line)
+			}
+			for _, want := range append(tt.want, "component=test") {
+				if !strings.Contains(line, want) {
+					t.Fatalf("NewLogHandler(): want '%s' in log, have '%s'", want, line)
+				}
+			}
+		})
+	}
+
+	// Logs without request or trace identifiers in context are passed through as-is.
+	var buf strings.Builder
+	slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil))).InfoContext(context.Background(), "Test")
+	if strings.Contains(buf.String(), "request-id") || strings.Contains(buf.String(), "trace-id") {
+		t.Fatalf("NewLogHandler(): want no identifiers in log, have '%s'", buf.String())
+	}
+
+	// Spans started without a remote parent are logged against new traces.
+	buf.Reset()
+	ctx, span := trace.Start(context.Background(), "test", trace.KindInternal)
+	slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil))).InfoContext(ctx, "Test")
+	if want := "trace-id=" + span.Context().TraceID.String(); !strings.Contains(buf.String(), want) {
+		t.Fatalf("NewLogHandler(): want '%s' in log, have '%s'", want, buf.String())
+	}
+}
@@ -0,0 +1,184 @@
+package gateway
+
+import (
+	// Standard library.
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A MatchType represents the kind of comparison made by a [Matcher].
+type MatchType string
+
+// Match types supported in [Matcher] definitions.
+const (
+	MatchEqual     MatchType = "="  // Field value is equal to matcher value.
+	MatchNotEqual  MatchType = "!=" // Field value is not equal to matcher value.
+	MatchRegexp    MatchType = "=~" // Field value matches regular expression in matcher value.
+	MatchNotRegexp MatchType = "!~" // Field value does not match regular expression in matcher value.
+)
+
+// A Matcher compares a single field of a [Message] against a value, either by equality or regular
+// expression, and optionally negated. Fields are referred to by name, with 'status', 'severity',
+// 'source', 'title', 'body', and 'content' referring to the corresponding [Message] fields, and any
+// other name referring to a message label; labels sharing a name with a field can be referred to
+// explicitly with a 'labels.' prefix, e.g. 'labels.status'. Missing labels are taken to be empty.
+type Matcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+
+	// Internal fields.
+	re *regexp.Regexp
+}
+
+// NewMatcher returns a [Matcher] for the field name, match type, and value given. Values for
+// regular expression matchers are anchored, and must match field values in full.
+func NewMatcher(name string, typ MatchType, value string) (*Matcher, error) {
+	var m = Matcher{Name: name, Type: typ, Value: value}
+	switch typ {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression '%s' in matcher: %w", value, err)
+		}
+		m.re = re
+	default:
+		return nil, fmt.Errorf("unknown match type '%s' in matcher", typ)
+	}
+
+	return &m, nil
+}
+
+// Matcher expressions are in the form of '<name><type><value>', with optional whitespace between
+// components, e.g. 'severity="critical"' or 'team =~ ops|infra'.
+var matcherExpr = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_.\-]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`)
+
+// ParseMatcher returns a [Matcher] for the expression given, in the form of '<name><type><value>',
+// e.g. 'severity="critical"'. Values may optionally be enclosed in double quotes, in which case any
+// escape sequences in the value are processed as for Go string literals.
+func ParseMatcher(expr string) (*Matcher, error) {
+	parts := matcherExpr.FindStringSubmatch(expr)
+	if parts == nil {
+		return nil, fmt.Errorf("invalid matcher expression '%s'", expr)
+	}
+
+	var value = parts[3]
+	if strings.HasPrefix(value, `"`) {
+		v, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted value in matcher expression '%s'", expr)
+		}
+		value = v
+	}
+
+	return NewMatcher(parts[1], MatchType(parts[2]), value)
+}
+
+// Match returns whether or not the given [Message] matches.
+func (m *Matcher) Match(msg *Message) bool {
+	var value = m.field(msg)
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+
+	return false
+}
+
+// Field returns the value for the field referred to by the [Matcher] in the given [Message].
+func (m *Matcher) field(msg *Message) string {
+	switch m.Name {
+	case "status":
+		return string(msg.Status)
+	case "severity":
+		return msg.Severity
+	case "source":
+		return msg.Source
+	case "title":
+		return msg.Title
+	case "body":
+		return msg.Body
+	case "content":
+		return msg.Content
+	}
+
+	return msg.Labels[strings.TrimPrefix(m.Name, "labels.")]
+}
+
+// String returns the [Matcher] in expression form, as accepted by [ParseMatcher].
+func (m *Matcher) String() string {
+	return m.Name + string(m.Type) + strconv.Quote(m.Value)
+}
+
+// MarshalText returns the [Matcher] in expression form, as per [Matcher.String].
+func (m *Matcher) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText sets the [Matcher] from the expression given, as accepted by [ParseMatcher].
+func (m *Matcher) UnmarshalText(text []byte) error {
+	v, err := ParseMatcher(string(text))
+	if err != nil {
+		return err
+	}
+
+	*m = *v
+	return nil
+}
+
+// Matchers represents a list of [Matcher] instances, all of which need to match for a [Message] to
+// match. An empty list matches all messages.
+type Matchers []*Matcher
+
+// Match returns whether or not the given [Message] matches all matchers.
+func (ms Matchers) Match(msg *Message) bool {
+	for _, m := range ms {
+		if !m.Match(msg) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseMatchers returns [Matchers] for matcher expressions given in TOML configuration, either as a
+// single string, or as an array of strings. See [ParseMatcher] for the syntax used.
+func ParseMatchers(data any) (Matchers, error) {
+	var exprs []any
+	switch v := data.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		exprs = append(exprs, v)
+	case []any:
+		exprs = v
+	default:
+		return nil, fmt.Errorf("invalid matcher definition, expected string or array of strings")
+	}
+
+	var ms Matchers
+	for _, v := range exprs {
+		expr, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid matcher definition, expected string or array of strings")
+		}
+
+		m, err := ParseMatcher(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
@@ -0,0 +1,68 @@
+package gateway
+
+import (
+	// Standard library.
+	"errors"
+	"testing"
+)
+
+func TestParseMatcher(t *testing.T) {
+	var testCases = []struct {
+		expr   string
+		expect string
+		err    error
+	}{
+		{expr: `severity="critical"`, expect: `severity="critical"`},
+		{expr: ` team =~ ops|infra `, expect: `team=~"ops|infra"`},
+		{expr: `env!=""`, expect: `env!=""`},
+		{expr: `labels.status!~"firing|resolved"`, expect: `labels.status!~"firing|resolved"`},
+		{expr: `severity`, err: errors.New(`invalid matcher expression 'severity'`)},
+		{expr: `severity="critical`, err: errors.New(`invalid quoted value in matcher expression 'severity="critical'`)},
+		{expr: `team=~"(ops"`, err: errors.New("invalid regular expression '(ops' in matcher: error parsing regexp: missing closing ): `^(?:(ops)$`")},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.expr, func(t *testing.T) {
+			m, err := ParseMatcher(tt.expr)
+			if (err != nil && tt.err == nil) || (err == nil && tt.err != nil) {
+				t.Fatalf("ParseMatcher(): want error '%v', have '%v'", tt.err, err)
+			} else if err != nil && tt.err != nil && err.Error() != tt.err.Error() {
+				t.Fatalf("ParseMatcher(): want error '%s', have '%s'", tt.err.Error(), err.Error())
+			} else if err == nil && m.String() != tt.expect {
+				t.Fatalf("ParseMatcher(): want matcher '%s', have '%s'", tt.expect, m.String())
+			}
+		})
+	}
+}
+
+func TestMatchersMatch(t *testing.T) {
+	var msg = &Message{
+		Status:   StatusFiring,
+		Severity: "critical",
+		Source:   "grafana",
+		Labels:   map[string]string{"team": "ops", "status": "custom"},
+	}
+
+	var testCases = []struct {
+		exprs  []any
+		expect bool
+	}{
+		{exprs: nil, expect: true},
+		{exprs: []any{`severity="critical"`}, expect: true},
+		{exprs: []any{`severity="critical"`, `status="resolved"`}, expect: false},
+		{exprs: []any{`source!="cloudflare-notifications"`, `team=~"ops|infra"`}, expect: true},
+		{exprs: []any{`team!~"ops|infra"`}, expect: false},
+		{exprs: []any{`env=""`}, expect: true},
+		{exprs: []any{`labels.status="custom"`}, expect: true},
+		{exprs: []any{`team=~"op"`}, expect: false},
+	}
+
+	for _, tt := range testCases {
+		ms, err := ParseMatchers(tt.exprs)
+		if err != nil {
+			t.Fatalf("ParseMatchers(): want error 'nil', have '%s'", err)
+		} else if ms.Match(msg) != tt.expect {
+			t.Fatalf("Matchers.Match(%v): want '%v', have '%v'", tt.exprs, tt.expect, !tt.expect)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package gateway
+
+import (
+	// Standard library.
+	"maps"
+	"time"
+)
+
+// A Message represents a notification, as parsed in by a [Source], and provided to a [Destination].
+// Messages contain fully-formatted content, as well as any structured information made available by
+// the source, which destinations may use in formatting or routing messages further.
+type Message struct {
+	Content string `json:"content"` // The full, formatted content for the message.
+
+	Title       string            `json:"title,omitempty"`       // A short summary for the message.
+	Body        string            `json:"body,omitempty"`        // The main content for the message, without title.
+	Status      Status            `json:"status,omitempty"`      // The status of the alert the message refers to, if any.
+	Severity    string            `json:"severity,omitempty"`    // The severity of the alert the message refers to, if any.
+	Labels      map[string]string `json:"labels,omitempty"`      // Arbitrary key-value pairs attached to the message.
+	Links       []Link            `json:"links,omitempty"`       // Links to external resources related to the message.
+	Source      string            `json:"source,omitempty"`      // The name of the source type the message originated from.
+	Fingerprint string            `json:"fingerprint,omitempty"` // A stable identifier for the alert the message refers to, if any.
+	Timestamp   time.Time         `json:"timestamp"`             // The time the originating event occurred.
+	Alert       *Alert            `json:"alert,omitempty"`       // The tracked state of the alert the message refers to, if any.
+}
+
+// A Status represents the state of an alert a [Message] refers to.
+type Status string
+
+// Alert states commonly used in sources.
+const (
+	StatusFiring   Status = "firing"   // The alert is currently active.
+	StatusResolved Status = "resolved" // The alert is no longer active.
+)
+
+// A Link represents a titled reference to an external resource related to a [Message], e.g. a
+// dashboard for the originating alert.
+type Link struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+// Summarize returns a [Message] standing in for all messages given, e.g. for use in digests. Fields
+// shared by all messages are retained, the earliest timestamp is used, and the status is set to
+// 'firing' if any message is firing, or to 'resolved' if all messages are resolved. Content and
+// title are left for callers to set.
+func Summarize(messages []*Message) *Message {
+	if len(messages) == 0 {
+		return &Message{}
+	}
+
+	var msg = &Message{
+		Labels:    maps.Clone(messages[0].Labels),
+		Source:    messages[0].Source,
+		Severity:  messages[0].Severity,
+		Timestamp: messages[0].Timestamp,
+	}
+
+	var firing, resolved int
+	for _, m := range messages {
+		switch m.Status {
+		case StatusFiring:
+			firing++
+		case StatusResolved:
+			resolved++
+		}
+
+		if m.Source != msg.Source {
+			msg.Source = ""
+		}
+		if m.Severity != msg.Severity {
+			msg.Severity = ""
+		}
+		if m.Timestamp.Before(msg.Timestamp) {
+			msg.Timestamp = m.Timestamp
+		}
+		maps.DeleteFunc(msg.Labels, func(k, v string) bool { return m.Labels[k] != v })
+	}
+
+	if firing > 0 {
+		msg.Status = StatusFiring
+	} else if resolved == len(messages) {
+		msg.Status = StatusResolved
+	}
+
+	return msg
+}
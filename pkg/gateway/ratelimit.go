@@ -0,0 +1,196 @@
+package gateway
+
+import (
+	// Standard library.
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interval after which idle keys are pruned from [KeyedRateLimiter] instances.
+const rateLimitPruneInterval = time.Minute
+
+// A RateCoster is any [Destination] producing more than one outgoing message for each [Message]
+// pushed, e.g. one for each recipient, and able to report the number of outgoing messages produced,
+// as charged against rate limits. Implementing this interface is optional, and destinations that do
+// not are charged one event per message.
+type RateCoster interface {
+	Cost(...*Message) int
+}
+
+// A RateLimit represents the rate at which events are allowed, alongside the number of events
+// allowed to happen at once, in excess of the rate given.
+type RateLimit struct {
+	Rate  float64 // The number of events allowed per second.
+	Burst int     // The maximum number of events allowed at once.
+}
+
+// ParseRateLimit returns a [RateLimit] for the TOML configuration given, which is expected to be a
+// table containing a 'rate' value, formatted as a number of events per unit of time, e.g. "10/s",
+// "60/m", or "5/30s", and an optional 'burst' value, which defaults to one event.
+func ParseRateLimit(data any) (RateLimit, error) {
+	conf, ok := data.(map[string]any)
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit configuration")
+	}
+
+	v, ok := conf["rate"].(string)
+	if !ok || v == "" {
+		return RateLimit{}, fmt.Errorf("empty or missing rate in rate limit configuration")
+	}
+
+	count, per, ok := strings.Cut(v, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate '%s', expected format '<count>/<interval>'", v)
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(count), 64)
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid count in rate '%s'", v)
+	}
+
+	per = strings.TrimSpace(per)
+	if per != "" && (per[0] < '0' || per[0] > '9') {
+		per = "1" + per
+	}
+
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid interval in rate '%s'", v)
+	}
+
+	var limit = RateLimit{Rate: n / d.Seconds(), Burst: 1}
+	if b, ok := conf["burst"].(int64); ok {
+		if b <= 0 {
+			return RateLimit{}, fmt.Errorf("invalid burst '%d' in rate limit configuration", b)
+		}
+		limit.Burst = int(b)
+	}
+
+	return limit, nil
+}
+
+// A RateLimiter implements a token-bucket rate limiter, where tokens are added to a bucket of size
+// equal to the burst set, at the rate set, and events are allowed only as long as tokens remain.
+type RateLimiter struct {
+	limit RateLimit
+
+	// Internal fields.
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a [RateLimiter] for the [RateLimit] given, with a full bucket of tokens.
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{limit: limit, tokens: float64(limit.Burst), last: time.Now()}
+}
+
+// Allow takes a single token from the bucket, if one is available, and returns true. Otherwise, no
+// tokens are taken, and the amount of time until a token becomes available is returned.
+func (l *RateLimiter) Allow() (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+
+	return false, l.delay(1)
+}
+
+// Wait blocks until n tokens can be taken from the bucket, or until the given [context.Context] is
+// cancelled, in which case no tokens are taken and the context error is returned. Waiting for more
+// tokens than the configured burst is allowed, and takes correspondingly longer.
+func (l *RateLimiter) Wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	l.refill(time.Now())
+	l.tokens -= float64(n)
+	var wait = l.delay(0)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	var timer = time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Refill adds tokens accrued since the last refill, up to the configured burst. The limiter lock is
+// expected to be held.
+func (l *RateLimiter) refill(now time.Time) {
+	l.tokens = min(float64(l.limit.Burst), l.tokens+now.Sub(l.last).Seconds()*l.limit.Rate)
+	l.last = now
+}
+
+// Delay returns the amount of time until the bucket holds the given number of tokens. The limiter
+// lock is expected to be held.
+func (l *RateLimiter) delay(n float64) time.Duration {
+	if l.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - l.tokens) / l.limit.Rate * float64(time.Second))
+}
+
+// Full returns whether or not the bucket is full, i.e. whether or not the [RateLimiter] has been
+// idle for long enough to have no effect on subsequent events.
+func (l *RateLimiter) full(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+	return l.tokens >= float64(l.limit.Burst)
+}
+
+// A KeyedRateLimiter maintains separate [RateLimiter] instances for distinct keys, e.g. client IP
+// addresses, with the same [RateLimit] applied to each. Idle keys are pruned periodically.
+type KeyedRateLimiter struct {
+	limit RateLimit
+
+	// Internal fields.
+	mu       sync.Mutex
+	limiters map[string]*RateLimiter
+	pruned   time.Time
+}
+
+// NewKeyedRateLimiter returns a [KeyedRateLimiter] for the [RateLimit] given.
+func NewKeyedRateLimiter(limit RateLimit) *KeyedRateLimiter {
+	return &KeyedRateLimiter{limit: limit, limiters: make(map[string]*RateLimiter), pruned: time.Now()}
+}
+
+// Allow takes a single token from the bucket for the given key, as per [RateLimiter.Allow].
+func (k *KeyedRateLimiter) Allow(key string) (bool, time.Duration) {
+	var now = time.Now()
+
+	k.mu.Lock()
+	if now.Sub(k.pruned) >= rateLimitPruneInterval {
+		maps.DeleteFunc(k.limiters, func(_ string, l *RateLimiter) bool { return l.full(now) })
+		k.pruned = now
+	}
+
+	l, ok := k.limiters[key]
+	if !ok {
+		l = NewRateLimiter(k.limit)
+		k.limiters[key] = l
+	}
+	k.mu.Unlock()
+
+	return l.Allow()
+}
@@ -0,0 +1,98 @@
+package gateway
+
+import (
+	// Standard library.
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	var testCases = []struct {
+		descr string
+		data  any
+
+		limit RateLimit
+		err   error
+	}{
+		{
+			descr: "rate per second",
+			data:  map[string]any{"rate": "10/s"},
+			limit: RateLimit{Rate: 10, Burst: 1},
+		},
+		{
+			descr: "rate per minute with burst",
+			data:  map[string]any{"rate": "30/m", "burst": int64(5)},
+			limit: RateLimit{Rate: 0.5, Burst: 5},
+		},
+		{
+			descr: "rate per interval",
+			data:  map[string]any{"rate": "5 / 10s"},
+			limit: RateLimit{Rate: 0.5, Burst: 1},
+		},
+		{
+			descr: "missing rate",
+			data:  map[string]any{"burst": int64(5)},
+			err:   errors.New("empty or missing rate in rate limit configuration"),
+		},
+		{
+			descr: "invalid format",
+			data:  map[string]any{"rate": "10"},
+			err:   errors.New("invalid rate '10', expected format '<count>/<interval>'"),
+		},
+		{
+			descr: "invalid interval",
+			data:  map[string]any{"rate": "10/fortnight"},
+			err:   errors.New("invalid interval in rate '10/fortnight'"),
+		},
+		{
+			descr: "invalid burst",
+			data:  map[string]any{"rate": "10/s", "burst": int64(0)},
+			err:   errors.New("invalid burst '0' in rate limit configuration"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			limit, err := ParseRateLimit(tt.data)
+			if tt.err != nil {
+				if err == nil || err.Error() != tt.err.Error() {
+					t.Fatalf("ParseRateLimit(): want error '%v', have '%v'", tt.err, err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("ParseRateLimit(): want error 'nil', have '%s'", err)
+			} else if limit != tt.limit {
+				t.Fatalf("ParseRateLimit(): want limit '%+v', have '%+v'", tt.limit, limit)
+			}
+		})
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	var l = NewRateLimiter(RateLimit{Rate: 100, Burst: 2})
+
+	var start = time.Now()
+	if err := l.Wait(context.Background(), 2); err != nil {
+		t.Fatalf("RateLimiter.Wait(): want error 'nil', have '%s'", err)
+	} else if d := time.Since(start); d > 5*time.Millisecond {
+		t.Fatalf("RateLimiter.Wait(): want no delay within burst, have '%s'", d)
+	}
+
+	start = time.Now()
+	if err := l.Wait(context.Background(), 2); err != nil {
+		t.Fatalf("RateLimiter.Wait(): want error 'nil', have '%s'", err)
+	} else if d := time.Since(start); d < 15*time.Millisecond {
+		t.Fatalf("RateLimiter.Wait(): want delay of at least '20ms', have '%s'", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := l.Wait(ctx, 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RateLimiter.Wait(): want error '%s', have '%v'", context.Canceled, err)
+	} else if ok, _ := l.Allow(); ok {
+		t.Fatalf("RateLimiter.Allow(): want no tokens available, have tokens")
+	}
+}
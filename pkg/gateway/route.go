@@ -0,0 +1,95 @@
+package gateway
+
+import (
+	// Standard library.
+	"maps"
+	"slices"
+)
+
+// A Route represents a rule for selecting destinations for messages, based on whether or not these
+// match a set of [Matchers]. Routes are evaluated in order, with the first matching route selecting
+// destinations for a [Message], unless the route is marked as continuing, in which case evaluation
+// also continues to subsequent routes.
+type Route struct {
+	Matchers     Matchers               // Matchers for messages to route; empty lists match all messages.
+	Continue     bool                   // Whether or not evaluation continues to subsequent routes after a match.
+	Destinations map[string]Destination // Destinations selected for matching messages, by name.
+}
+
+// A Route is the internal representation of a [Route], with destinations in a stable order.
+type route struct {
+	matchers     Matchers
+	cont         bool
+	destinations []namedDestination
+}
+
+// A Target represents a [Destination] selected for a number of messages.
+type target struct {
+	namedDestination
+	messages []*Message
+}
+
+// Route returns the destinations selected for each of the messages given, in the order these were
+// first selected, along with any messages not selected for any destination. Messages not matching
+// any route are selected for the default destinations given; messages are only ever selected once
+// for each destination, regardless of how many routes select the destination.
+func routeMessages(routes []route, defaults []namedDestination, messages []*Message) ([]*target, []*Message) {
+	var targets []*target
+	var dropped []*Message
+
+	add := func(d namedDestination, msg *Message) {
+		for _, t := range targets {
+			if t.Destination == d.Destination {
+				if len(t.messages) == 0 || t.messages[len(t.messages)-1] != msg {
+					t.messages = append(t.messages, msg)
+				}
+				return
+			}
+		}
+		targets = append(targets, &target{namedDestination: d, messages: []*Message{msg}})
+	}
+
+	for _, msg := range messages {
+		var selected = defaults
+		if len(routes) > 0 {
+			selected = nil
+			var matched bool
+			for _, r := range routes {
+				if !r.matchers.Match(msg) {
+					continue
+				}
+
+				matched, selected = true, append(selected, r.destinations...)
+				if !r.cont {
+					break
+				}
+			}
+
+			if !matched {
+				selected = defaults
+			}
+		}
+
+		if len(selected) == 0 {
+			dropped = append(dropped, msg)
+			continue
+		}
+
+		for _, d := range selected {
+			add(d, msg)
+		}
+	}
+
+	return targets, dropped
+}
+
+// NewRoute returns the internal representation for the given [Route], with destinations sorted by
+// name and treated as shared.
+func newRoute(r Route) route {
+	var destinations []namedDestination
+	for _, name := range slices.Sorted(maps.Keys(r.Destinations)) {
+		destinations = append(destinations, namedDestination{name: name, shared: true, Destination: r.Destinations[name]})
+	}
+
+	return route{matchers: r.Matchers, cont: r.Continue, destinations: destinations}
+}
@@ -0,0 +1,512 @@
+package gateway
+
+import (
+	// Standard library.
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum amount of time allowed for pushing messages held by silences, once released.
+const holdReleaseTimeout = 30 * time.Second
+
+// A SilenceAction determines what happens to messages matching an active [Silence].
+type SilenceAction string
+
+// Actions supported for silenced messages.
+const (
+	SilenceDrop SilenceAction = "drop" // Messages are dropped.
+	SilenceHold SilenceAction = "hold" // Messages are held, and pushed as a summary once the silence ends.
+)
+
+// A Silence represents a rule for suppressing messages matching a set of [Matchers], either for a
+// fixed period of time, or on a recurring [Schedule].
+type Silence struct {
+	ID        string        `json:"id"`
+	Matchers  Matchers      `json:"matchers"`
+	Action    SilenceAction `json:"action"`
+	Schedule  *Schedule     `json:"schedule,omitempty"`
+	StartsAt  time.Time     `json:"starts_at"`
+	EndsAt    time.Time     `json:"ends_at"`
+	Comment   string        `json:"comment,omitempty"`
+	CreatedBy string        `json:"created_by,omitempty"`
+}
+
+// ParseSilence returns a [Silence] for the TOML configuration given, which is expected to contain
+// either a 'schedule' table, for recurring silences, or an 'ends-at' time, for ad-hoc silences.
+func ParseSilence(conf map[string]any) (*Silence, error) {
+	matchers, err := ParseMatchers(conf["matchers"])
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing matchers: %w", err)
+	}
+
+	var s = &Silence{Matchers: matchers, Action: SilenceDrop}
+	if v, ok := conf["action"].(string); ok {
+		s.Action = SilenceAction(v)
+	}
+	if v, ok := conf["comment"].(string); ok {
+		s.Comment = v
+	}
+
+	if v, ok := conf["schedule"].(map[string]any); ok {
+		if s.Schedule, err = ParseSchedule(v); err != nil {
+			return nil, fmt.Errorf("failed parsing schedule: %w", err)
+		}
+	}
+
+	for key, t := range map[string]*time.Time{"starts-at": &s.StartsAt, "ends-at": &s.EndsAt} {
+		switch v := conf[key].(type) {
+		case time.Time:
+			*t = v
+		case string:
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				return nil, fmt.Errorf("invalid time '%s' for '%s': %w", v, key, err)
+			}
+		}
+	}
+
+	return s, s.validate()
+}
+
+// Validate returns an error if the [Silence] is not configured correctly.
+func (s *Silence) validate() error {
+	switch s.Action {
+	case SilenceDrop, SilenceHold:
+	default:
+		return fmt.Errorf("unknown silence action '%s'", s.Action)
+	}
+
+	if s.Schedule != nil && (!s.StartsAt.IsZero() || !s.EndsAt.IsZero()) {
+		return fmt.Errorf("silences cannot have both a schedule and start or end times")
+	} else if s.Schedule == nil && s.EndsAt.IsZero() {
+		return fmt.Errorf("silences require either a schedule or an end time")
+	} else if s.Schedule == nil && !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("silence end time must be after start time")
+	}
+
+	return nil
+}
+
+// Active returns whether or not the [Silence] is in effect at the time given.
+func (s *Silence) Active(t time.Time) bool {
+	return !s.End(t).IsZero()
+}
+
+// End returns the time the [Silence] stops being in effect, if in effect at the time given, or the
+// zero time otherwise.
+func (s *Silence) End(t time.Time) time.Time {
+	if s.Schedule != nil {
+		return s.Schedule.end(t)
+	} else if !t.Before(s.StartsAt) && t.Before(s.EndsAt) {
+		return s.EndsAt
+	}
+
+	return time.Time{}
+}
+
+// Expired returns whether or not the [Silence] will never be in effect after the time given.
+func (s *Silence) Expired(t time.Time) bool {
+	return s.Schedule == nil && !s.EndsAt.After(t)
+}
+
+// A Schedule represents a recurring daily window of time, optionally restricted to specific days of
+// the week, and evaluated in a specific time zone. Windows ending before they start are taken to
+// extend past midnight, and belong to the day they start on.
+type Schedule struct {
+	Weekdays []time.Weekday // The days of the week the window applies to; empty lists apply to all days.
+	Start    time.Duration  // The start of the window, as an offset from midnight.
+	End      time.Duration  // The end of the window, as an offset from midnight.
+	Location *time.Location // The time zone the window is evaluated in.
+}
+
+// Names for weekdays, as accepted in [Schedule] configuration.
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseSchedule returns a [Schedule] for the TOML configuration given, which is expected to contain
+// a 'time' range, e.g. "22:00-07:00", and optionally a list of 'weekdays' or weekday ranges, e.g.
+// ["mon-fri"], and a 'timezone' name, e.g. "Europe/London", defaulting to the local time zone.
+func ParseSchedule(conf map[string]any) (*Schedule, error) {
+	var s = &Schedule{Location: time.Local}
+
+	v, ok := conf["time"].(string)
+	if !ok || v == "" {
+		return nil, fmt.Errorf("empty or missing time range in schedule")
+	}
+
+	start, end, ok := strings.Cut(v, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid time range '%s', expected format 'HH:MM-HH:MM'", v)
+	}
+
+	var err error
+	if s.Start, err = parseClock(start); err != nil {
+		return nil, err
+	} else if s.End, err = parseClock(end); err != nil {
+		return nil, err
+	}
+
+	if v, ok := conf["timezone"].(string); ok && v != "" {
+		if s.Location, err = time.LoadLocation(v); err != nil {
+			return nil, fmt.Errorf("invalid timezone '%s': %w", v, err)
+		}
+	}
+
+	var days []any
+	switch v := conf["weekdays"].(type) {
+	case string:
+		days = append(days, v)
+	case []any:
+		days = v
+	}
+
+	for _, v := range days {
+		d, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday definition, expected string or array of strings")
+		}
+
+		from, to, isRange := strings.Cut(d, "-")
+		first, err := parseWeekday(from)
+		if err != nil {
+			return nil, err
+		}
+
+		last := first
+		if isRange {
+			if last, err = parseWeekday(to); err != nil {
+				return nil, err
+			}
+		}
+
+		for day := first; ; day = (day + 1) % 7 {
+			if !slices.Contains(s.Weekdays, day) {
+				s.Weekdays = append(s.Weekdays, day)
+			}
+			if day == last {
+				break
+			}
+		}
+	}
+
+	slices.Sort(s.Weekdays)
+	return s, nil
+}
+
+// ParseClock returns the offset from midnight for the time of day given, in 'HH:MM' format. The
+// value '24:00' is accepted for referring to the end of the day.
+func parseClock(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	if v == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day '%s', expected format 'HH:MM'", v)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseWeekday returns the [time.Weekday] for the name given, either in full or abbreviated form.
+func parseWeekday(v string) (time.Weekday, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	for i, name := range weekdayNames {
+		if v == name || v == strings.ToLower(time.Weekday(i).String()) {
+			return time.Weekday(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown weekday '%s'", v)
+}
+
+// Active returns whether or not the [Schedule] window is in effect at the time given.
+func (s *Schedule) Active(t time.Time) bool {
+	return !s.end(t).IsZero()
+}
+
+// End returns the end of the [Schedule] window in effect at the time given, or the zero time if no
+// window is in effect.
+func (s *Schedule) end(t time.Time) time.Time {
+	t = t.In(s.Location)
+	var day = t.Weekday()
+
+	// Returns the time for the offset given, on the day relative to the time given. Offsets are
+	// applied to wall-clock time, in order to account for daylight saving time transitions.
+	clock := func(days int, offset time.Duration) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d+days, 0, int(offset/time.Minute), 0, 0, s.Location)
+	}
+
+	if s.Start < s.End {
+		if s.weekday(day) && !t.Before(clock(0, s.Start)) && t.Before(clock(0, s.End)) {
+			return clock(0, s.End)
+		}
+		return time.Time{}
+	}
+
+	// Windows extending past midnight may have started either on the current day or the day before.
+	if s.weekday(day) && !t.Before(clock(0, s.Start)) {
+		return clock(1, s.End)
+	} else if s.weekday((day+6)%7) && t.Before(clock(0, s.End)) {
+		return clock(0, s.End)
+	}
+
+	return time.Time{}
+}
+
+// Weekday returns whether or not the [Schedule] applies to the day given.
+func (s *Schedule) weekday(day time.Weekday) bool {
+	return len(s.Weekdays) == 0 || slices.Contains(s.Weekdays, day)
+}
+
+// String returns a human-readable representation of the [Schedule], e.g. 'mon,tue 22:00-07:00
+// Europe/London'.
+func (s *Schedule) String() string {
+	var days []string
+	for _, d := range s.Weekdays {
+		days = append(days, weekdayNames[d])
+	}
+
+	var clock = func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+
+	var result = clock(s.Start) + "-" + clock(s.End) + " " + s.Location.String()
+	if len(days) > 0 {
+		result = strings.Join(days, ",") + " " + result
+	}
+
+	return result
+}
+
+// MarshalText returns the [Schedule] in human-readable form, as per [Schedule.String].
+func (s *Schedule) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses the [Schedule] from the human-readable form produced by [Schedule.String].
+func (s *Schedule) UnmarshalText(text []byte) error {
+	var fields = strings.Fields(string(text))
+	if len(fields) < 2 || len(fields) > 3 {
+		return fmt.Errorf("invalid schedule '%s', expected format '[days] HH:MM-HH:MM timezone'", text)
+	}
+
+	var conf = map[string]any{"time": fields[len(fields)-2], "timezone": fields[len(fields)-1]}
+	if len(fields) == 3 {
+		var days []any
+		for _, d := range strings.Split(fields[0], ",") {
+			days = append(days, d)
+		}
+		conf["weekdays"] = days
+	}
+
+	v, err := ParseSchedule(conf)
+	if err != nil {
+		return err
+	}
+
+	*s = *v
+	return nil
+}
+
+// Silences represents a set of [Silence] rules, safe for concurrent use. Expired silences are
+// removed as they are encountered. Sets of silences are optionally persisted to a file, allowing for
+// silences added at runtime to remain in effect across restarts.
+type Silences struct {
+	mu       sync.Mutex
+	silences []*Silence
+	path     string
+}
+
+// NewSilences returns an empty set of [Silences].
+func NewSilences() *Silences {
+	return &Silences{}
+}
+
+// Load sets the path used for persisting the set of silences, and loads any existing silences from
+// it; expired silences are dropped. Missing files are not considered an error, and will be created
+// as needed.
+func (s *Silences) Load(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed reading silences: %w", err)
+	}
+
+	var silences []*Silence
+	if err := json.Unmarshal(buf, &silences); err != nil {
+		return fmt.Errorf("failed parsing silences: %w", err)
+	}
+
+	for _, v := range silences {
+		if !slices.ContainsFunc(s.silences, func(e *Silence) bool { return e.ID == v.ID }) {
+			s.silences = append(s.silences, v)
+		}
+	}
+
+	s.prune(time.Now())
+	return nil
+}
+
+// Add adds the given [Silence] to the set, assigning a random ID if none is set, and setting the
+// start time to the current time for ad-hoc silences without one.
+func (s *Silences) Add(silence *Silence) error {
+	if silence.Schedule == nil && silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now()
+	}
+	if silence.Action == "" {
+		silence.Action = SilenceDrop
+	}
+
+	if err := silence.validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if silence.ID == "" {
+		silence.ID = newSilenceID()
+	} else if slices.ContainsFunc(s.silences, func(v *Silence) bool { return v.ID == silence.ID }) {
+		return fmt.Errorf("silence with ID '%s' already exists", silence.ID)
+	}
+
+	s.silences = append(s.silences, silence)
+	if err := s.save(); err != nil {
+		s.silences = s.silences[:len(s.silences)-1]
+		return err
+	}
+
+	return nil
+}
+
+// Remove removes the [Silence] with the ID given from the set, returning false if no silence was
+// found for the ID. An error is returned if the set could not be persisted.
+func (s *Silences) Remove(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n = len(s.silences)
+	s.silences = slices.DeleteFunc(s.silences, func(v *Silence) bool { return v.ID == id })
+	if len(s.silences) == n {
+		return false, nil
+	}
+
+	return true, s.save()
+}
+
+// Replace replaces all silences in the set with the silences in the set given, e.g. when applying
+// silences defined in reloaded configuration.
+func (s *Silences) Replace(other *Silences) error {
+	var silences = other.List()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.silences = silences
+	return s.save()
+}
+
+// List returns all silences in the set that have not yet expired, in the order these were added.
+func (s *Silences) List() []*Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(time.Now())
+	return slices.Clone(s.silences)
+}
+
+// Match returns the first [Silence] in the set in effect at the time given and matching the given
+// [Message], if any.
+func (s *Silences) Match(msg *Message, t time.Time) *Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(t)
+	for _, v := range s.silences {
+		if v.Active(t) && v.Matchers.Match(msg) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// Prune removes silences expired by the time given. The silences lock is expected to be held.
+func (s *Silences) prune(t time.Time) {
+	s.silences = slices.DeleteFunc(s.silences, func(v *Silence) bool { return v.Expired(t) })
+}
+
+// Save writes the set of silences to the configured path, if any, replacing any existing file
+// atomically. The silences lock is expected to be held.
+func (s *Silences) save() error {
+	if s.path == "" {
+		return nil
+	} else if err := writeState(s.path, s.silences); err != nil {
+		return fmt.Errorf("failed writing silences: %w", err)
+	}
+	return nil
+}
+
+// NewSilenceID returns a random, hex-encoded identifier for silences.
+func newSilenceID() string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// A Hold represents messages held for an active [Silence], pending release once the silence ends.
+// Holds are persisted alongside other gateway state, where a state directory is configured.
+type hold struct {
+	Silence  *Silence   `json:"silence"`
+	Messages []*Message `json:"messages"`
+	timer    *time.Timer
+}
+
+// Summarize returns the messages to push in place of the held messages given, which is a single
+// summary message for more than one held message, or the held message itself otherwise.
+func (h *hold) summarize(messages []*Message) *Message {
+	if len(messages) == 1 {
+		return messages[0]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d notifications were held during silence", len(messages))
+	if h.Silence.Comment != "" {
+		fmt.Fprintf(&b, " '%s'", h.Silence.Comment)
+	}
+
+	b.WriteString(":")
+	for _, m := range messages {
+		b.WriteString("\n- ")
+		if m.Status != "" {
+			fmt.Fprintf(&b, "[%s] ", strings.ToUpper(string(m.Status)))
+		}
+		if m.Title != "" {
+			b.WriteString(m.Title)
+		} else {
+			b.WriteString(m.Content)
+		}
+	}
+
+	var msg = Summarize(messages)
+	msg.Title = fmt.Sprintf("%d notifications held during silence", len(messages))
+	msg.Content = b.String()
+	msg.Body = msg.Content
+
+	return msg
+}
@@ -0,0 +1,172 @@
+package gateway
+
+import (
+	// Standard library.
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduleEnd(t *testing.T) {
+	// A Monday, in UTC.
+	var monday = time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	var testCases = []struct {
+		descr string
+		conf  map[string]any
+		at    time.Time
+
+		end time.Time
+	}{
+		{
+			descr: "within daytime window",
+			conf:  map[string]any{"time": "09:00-17:00", "timezone": "UTC"},
+			at:    monday.Add(10 * time.Hour),
+			end:   monday.Add(17 * time.Hour),
+		},
+		{
+			descr: "outside daytime window",
+			conf:  map[string]any{"time": "09:00-17:00", "timezone": "UTC"},
+			at:    monday.Add(17 * time.Hour),
+		},
+		{
+			descr: "overnight window, before midnight",
+			conf:  map[string]any{"time": "22:00-07:00", "timezone": "UTC"},
+			at:    monday.Add(23 * time.Hour),
+			end:   monday.Add(31 * time.Hour),
+		},
+		{
+			descr: "overnight window, after midnight",
+			conf:  map[string]any{"time": "22:00-07:00", "timezone": "UTC"},
+			at:    monday.Add(6 * time.Hour),
+			end:   monday.Add(7 * time.Hour),
+		},
+		{
+			descr: "overnight window, started on excluded weekday",
+			conf:  map[string]any{"time": "22:00-07:00", "weekdays": "mon-fri", "timezone": "UTC"},
+			at:    monday.Add(6 * time.Hour),
+		},
+		{
+			descr: "overnight window, started on included weekday",
+			conf:  map[string]any{"time": "22:00-07:00", "weekdays": []any{"fri-mon"}, "timezone": "UTC"},
+			at:    monday.Add(6 * time.Hour),
+			end:   monday.Add(7 * time.Hour),
+		},
+		{
+			descr: "whole day window",
+			conf:  map[string]any{"time": "00:00-24:00", "weekdays": []any{"monday"}, "timezone": "UTC"},
+			at:    monday.Add(12 * time.Hour),
+			end:   monday.Add(24 * time.Hour),
+		},
+		{
+			descr: "window in other time zone",
+			conf:  map[string]any{"time": "09:00-17:00", "timezone": "Etc/GMT-2"},
+			at:    monday.Add(8 * time.Hour),
+			end:   monday.Add(15 * time.Hour),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			s, err := ParseSchedule(tt.conf)
+			if err != nil {
+				t.Fatalf("ParseSchedule(): want error 'nil', have '%s'", err)
+			} else if end := s.end(tt.at); !end.Equal(tt.end) {
+				t.Fatalf("Schedule.end(): want '%s', have '%s'", tt.end, end)
+			} else if s.Active(tt.at) != !tt.end.IsZero() {
+				t.Fatalf("Schedule.Active(): want '%v', have '%v'", !tt.end.IsZero(), s.Active(tt.at))
+			}
+
+			// Schedules are expected to be parsed back from their string form, e.g. as persisted.
+			var v Schedule
+			if err := v.UnmarshalText([]byte(s.String())); err != nil {
+				t.Fatalf("Schedule.UnmarshalText(): want error 'nil', have '%s'", err)
+			} else if v.String() != s.String() || !v.end(tt.at).Equal(tt.end) {
+				t.Fatalf("Schedule.UnmarshalText(): want schedule '%s', have '%s'", s, &v)
+			}
+		})
+	}
+}
+
+func TestParseSilence(t *testing.T) {
+	var testCases = []struct {
+		descr string
+		conf  map[string]any
+
+		err error
+	}{
+		{
+			descr: "recurring silence",
+			conf: map[string]any{
+				"matchers": []any{`severity!="critical"`},
+				"action":   "hold",
+				"schedule": map[string]any{"time": "22:00-07:00"},
+			},
+		},
+		{
+			descr: "ad-hoc silence",
+			conf:  map[string]any{"matchers": `alertname="DiskFull"`, "ends-at": "2030-01-01T00:00:00Z"},
+		},
+		{
+			descr: "unknown action",
+			conf:  map[string]any{"action": "ignore", "ends-at": "2030-01-01T00:00:00Z"},
+			err:   errors.New("unknown silence action 'ignore'"),
+		},
+		{
+			descr: "schedule and end time",
+			conf: map[string]any{
+				"schedule": map[string]any{"time": "22:00-07:00"},
+				"ends-at":  "2030-01-01T00:00:00Z",
+			},
+			err: errors.New("silences cannot have both a schedule and start or end times"),
+		},
+		{
+			descr: "end time before start time",
+			conf:  map[string]any{"starts-at": "2030-01-01T00:00:00Z", "ends-at": "2029-01-01T00:00:00Z"},
+			err:   errors.New("silence end time must be after start time"),
+		},
+		{
+			descr: "invalid schedule",
+			conf:  map[string]any{"schedule": map[string]any{"time": "22:00-07:00", "weekdays": "someday"}},
+			err:   errors.New("failed parsing schedule: unknown weekday 'someday'"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			_, err := ParseSilence(tt.conf)
+			if (err != nil && tt.err == nil) || (err == nil && tt.err != nil) {
+				t.Fatalf("ParseSilence(): want error '%v', have '%v'", tt.err, err)
+			} else if err != nil && tt.err != nil && err.Error() != tt.err.Error() {
+				t.Fatalf("ParseSilence(): want error '%s', have '%s'", tt.err.Error(), err.Error())
+			}
+		})
+	}
+}
+
+func TestSilencesMatch(t *testing.T) {
+	var now = time.Now()
+	var s = NewSilences()
+
+	for _, v := range []*Silence{
+		{ID: "expired", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)},
+		{ID: "pending", StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+		{ID: "warning", Matchers: Matchers{must(ParseMatcher(`severity="warning"`))}, StartsAt: now, EndsAt: now.Add(time.Hour)},
+	} {
+		if err := s.Add(v); err != nil {
+			t.Fatalf("Silences.Add(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	if v := s.Match(&Message{Severity: "warning"}, now); v == nil || v.ID != "warning" {
+		t.Fatalf("Silences.Match(): want silence 'warning', have '%v'", v)
+	} else if v := s.Match(&Message{Severity: "critical"}, now); v != nil {
+		t.Fatalf("Silences.Match(): want no silence, have '%s'", v.ID)
+	} else if n := len(s.List()); n != 2 {
+		t.Fatalf("Silences.List(): want 2 silences, have %d", n)
+	} else if ok, _ := s.Remove("warning"); !ok {
+		t.Fatalf("Silences.Remove(): want silence removed, have none")
+	} else if ok, _ := s.Remove("warning"); ok {
+		t.Fatalf("Silences.Remove(): want silence removed exactly once")
+	}
+}
@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	// Standard library.
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// WriteState encodes the given value as JSON, and writes it to the path given, replacing any
+// existing file atomically. Parent directories are created as needed.
+func writeState(path string, v any) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(path, buf)
+}
+
+// WriteFile writes the given data to the path given, replacing any existing file atomically.
+// Parent directories are created as needed.
+func writeFile(path string, buf []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	} else if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
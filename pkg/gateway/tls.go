@@ -0,0 +1,207 @@
+package gateway
+
+import (
+	// Standard library.
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Minimum interval between checks for changes in files containing certificates.
+const certCheckInterval = 10 * time.Second
+
+// A CertPool is a set of CA certificates, as loaded from a file containing PEM-encoded certificates,
+// and used for verifying client certificates presented in incoming requests. Certificates are
+// reloaded automatically when the file changes, allowing for rotation without restarts.
+type CertPool struct {
+	path string
+
+	// Internal fields.
+	pool     atomic.Pointer[x509.CertPool]
+	reloader *fileReloader
+}
+
+// LoadCertPool returns a [CertPool] for the PEM-encoded certificates in the file given, returning an
+// error if the file could not be read, or if no valid certificates were found. Failures in reloading
+// certificates are reported to the [slog.Logger] given, or to the default logger if nil.
+func LoadCertPool(path string, logger *slog.Logger) (*CertPool, error) {
+	var p = &CertPool{path: path}
+	r, err := newFileReloader("CA certificates", p.load, logger, path)
+	if err != nil {
+		return nil, err
+	}
+
+	p.reloader = r
+	return p, nil
+}
+
+// Load reads certificates from the file set for the [CertPool], replacing any existing certificates.
+func (p *CertPool) load() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("failed reading CA certificates: %w", err)
+	}
+
+	var pool = x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("no valid CA certificates found in '%s'", p.path)
+	}
+
+	p.pool.Store(pool)
+	return nil
+}
+
+// Pool returns the [x509.CertPool] for the [CertPool], reloading certificates if the underlying file
+// has changed since last checked. Certificates that fail to reload are logged, and the previously
+// loaded certificates are returned instead.
+func (p *CertPool) Pool() *x509.CertPool {
+	p.reloader.check()
+	return p.pool.Load()
+}
+
+// Verify checks that the given [http.Request] was made over TLS, with a client certificate signed
+// by any of the certificates in the [CertPool].
+func (p *CertPool) Verify(r *http.Request) error {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return errors.New("no client certificate given")
+	}
+
+	var intermediates = x509.NewCertPool()
+	for _, c := range r.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+
+	_, err := r.TLS.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         p.Pool(),
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid client certificate: %w", err)
+	}
+
+	return nil
+}
+
+// A KeyPair is a TLS certificate and private key pair, as loaded from PEM-encoded files, and used for
+// serving HTTPS requests. Key pairs are reloaded automatically when either file changes, allowing for
+// rotation without restarts.
+type KeyPair struct {
+	certFile string
+	keyFile  string
+
+	// Internal fields.
+	cert     atomic.Pointer[tls.Certificate]
+	reloader *fileReloader
+}
+
+// LoadKeyPair returns a [KeyPair] for the certificate and key files given, returning an error if
+// either file could not be read or parsed. Failures in reloading key pairs are reported to the
+// [slog.Logger] given, or to the default logger if nil.
+func LoadKeyPair(certFile, keyFile string, logger *slog.Logger) (*KeyPair, error) {
+	var k = &KeyPair{certFile: certFile, keyFile: keyFile}
+	r, err := newFileReloader("TLS certificate", k.load, logger, certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	k.reloader = r
+	return k, nil
+}
+
+// Load reads the certificate and key from the files set, replacing any existing certificate.
+func (k *KeyPair) load() error {
+	cert, err := tls.LoadX509KeyPair(k.certFile, k.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed loading TLS certificate: %w", err)
+	}
+
+	k.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate returns the current [tls.Certificate], reloading the key pair if either of the
+// underlying files has changed since last checked, and is intended for use in [tls.Config]. Errors
+// in reloading key pairs are logged, and the previously loaded certificate is returned instead.
+func (k *KeyPair) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	k.reloader.check()
+	return k.cert.Load(), nil
+}
+
+// A FileReloader calls the given function for loading data from a set of files, calling it again
+// whenever any of the files change, as checked at most once per interval. Errors in reloading data
+// are logged, and are expected to leave any previously loaded data in place.
+type fileReloader struct {
+	descr string // A description of the data loaded, for use in logs.
+	paths []string
+	load  func() error
+
+	// Internal fields.
+	mu       sync.Mutex
+	modTime  time.Time
+	checked  time.Time
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewFileReloader returns a [fileReloader] for the files given, having loaded data from these once,
+// and returning any errors in doing so.
+func newFileReloader(descr string, load func() error, logger *slog.Logger, paths ...string) (*fileReloader, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	var r = &fileReloader{descr: descr, paths: paths, load: load, interval: certCheckInterval, logger: logger}
+	modTime, err := r.lastModified()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading %s: %w", descr, err)
+	} else if err := load(); err != nil {
+		return nil, err
+	}
+
+	r.modTime, r.checked = modTime, time.Now()
+	return r, nil
+}
+
+// Check loads data again if any of the underlying files have changed since last loaded, unless
+// already checked within the configured interval.
+func (r *fileReloader) check() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < r.interval {
+		return
+	}
+
+	r.checked = time.Now()
+	if modTime, err := r.lastModified(); err != nil || modTime.Equal(r.modTime) {
+		return
+	} else if err := r.load(); err != nil {
+		r.logger.Error("Failed reloading "+r.descr+", keeping existing "+r.descr, "path", r.paths[0], "error", err.Error())
+	} else {
+		r.modTime = modTime
+		r.logger.Info("Reloaded "+r.descr, "path", r.paths[0])
+	}
+}
+
+// LastModified returns the latest modification time for the underlying files.
+func (r *fileReloader) lastModified() (time.Time, error) {
+	var last time.Time
+	for _, path := range r.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		} else if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+
+	return last, nil
+}
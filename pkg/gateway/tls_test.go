@@ -0,0 +1,154 @@
+package gateway
+
+import (
+	// Standard library.
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/internal/testcert"
+)
+
+// WriteTestCert writes the given certificate to the path given, in PEM format.
+func writeTestCert(t *testing.T, path string, cert *x509.Certificate) {
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("os.WriteFile(): want error 'nil', have '%s'", err)
+	}
+}
+
+func TestCertPoolVerify(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "ca.pem")
+	ca, other := testcert.New(t, "Test CA", nil), testcert.New(t, "Other CA", nil)
+	writeTestCert(t, path, ca.Cert)
+
+	client, untrusted := testcert.New(t, "client", ca).Cert, testcert.New(t, "client", other).Cert
+
+	var testCases = []struct {
+		descr string
+		state *tls.ConnectionState
+
+		valid bool
+	}{
+		{
+			descr: "no TLS",
+		},
+		{
+			descr: "no client certificate",
+			state: &tls.ConnectionState{},
+		},
+		{
+			descr: "trusted client certificate",
+			state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}},
+			valid: true,
+		},
+		{
+			descr: "untrusted client certificate",
+			state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{untrusted}},
+		},
+	}
+
+	pool, err := LoadCertPool(path, nil)
+	if err != nil {
+		t.Fatalf("LoadCertPool(): want error 'nil', have '%s'", err)
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", nil)
+			r.TLS = tt.state
+
+			if err := pool.Verify(r); tt.valid && err != nil {
+				t.Fatalf("CertPool.Verify(): want error 'nil', have '%s'", err)
+			} else if !tt.valid && err == nil {
+				t.Fatalf("CertPool.Verify(): want error, have 'nil'")
+			}
+		})
+	}
+}
+
+func TestCertPoolReload(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "ca.pem")
+	ca, next := testcert.New(t, "Test CA", nil), testcert.New(t, "Next CA", nil)
+	writeTestCert(t, path, ca.Cert)
+
+	pool, err := LoadCertPool(path, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("LoadCertPool(): want error 'nil', have '%s'", err)
+	}
+
+	pool.reloader.interval = 0
+	client := testcert.New(t, "client", next).Cert
+	if _, err := client.Verify(x509.VerifyOptions{Roots: pool.Pool(), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err == nil {
+		t.Fatalf("Certificate.Verify(): want error for certificate signed by unknown CA, have 'nil'")
+	}
+
+	// Invalid files are ignored, keeping existing certificates.
+	if err := os.WriteFile(path, []byte("invalid"), 0644); err != nil {
+		t.Fatalf("os.WriteFile(): want error 'nil', have '%s'", err)
+	} else if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("os.Chtimes(): want error 'nil', have '%s'", err)
+	}
+
+	existing := testcert.New(t, "client", ca).Cert
+	if _, err := existing.Verify(x509.VerifyOptions{Roots: pool.Pool(), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+		t.Fatalf("Certificate.Verify(): want error 'nil' for existing CA, have '%s'", err)
+	}
+
+	// Valid files replace existing certificates.
+	writeTestCert(t, path, next.Cert)
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)); err != nil {
+		t.Fatalf("os.Chtimes(): want error 'nil', have '%s'", err)
+	}
+
+	if _, err := client.Verify(x509.VerifyOptions{Roots: pool.Pool(), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+		t.Fatalf("Certificate.Verify(): want error 'nil' for reloaded CA, have '%s'", err)
+	}
+}
+
+func TestKeyPairReload(t *testing.T) {
+	var dir = t.TempDir()
+	var ca = testcert.New(t, "Test CA", nil)
+	var first, second = testcert.New(t, "first", ca), testcert.New(t, "second", ca)
+
+	certFile, keyFile := first.Write(t, dir, "server")
+	k, err := LoadKeyPair(certFile, keyFile, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("LoadKeyPair(): want error 'nil', have '%s'", err)
+	}
+
+	k.reloader.interval = 0
+	if have, _ := k.GetCertificate(nil); have.Leaf.Subject.CommonName != "first" {
+		t.Fatalf("KeyPair.GetCertificate(): want certificate 'first', have '%s'", have.Leaf.Subject.CommonName)
+	}
+
+	// Mismatched certificate and key, e.g. as partially written, are ignored.
+	_, _ = second.Write(t, dir, "next")
+	if err := os.Rename(filepath.Join(dir, "next.pem"), certFile); err != nil {
+		t.Fatalf("os.Rename(): want error 'nil', have '%s'", err)
+	} else if err := os.Chtimes(certFile, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("os.Chtimes(): want error 'nil', have '%s'", err)
+	}
+
+	if have, _ := k.GetCertificate(nil); have.Leaf.Subject.CommonName != "first" {
+		t.Fatalf("KeyPair.GetCertificate(): want certificate 'first', have '%s'", have.Leaf.Subject.CommonName)
+	}
+
+	if err := os.Rename(filepath.Join(dir, "next.key"), keyFile); err != nil {
+		t.Fatalf("os.Rename(): want error 'nil', have '%s'", err)
+	} else if err := os.Chtimes(keyFile, time.Now(), time.Now().Add(2*time.Minute)); err != nil {
+		t.Fatalf("os.Chtimes(): want error 'nil', have '%s'", err)
+	}
+
+	if have, _ := k.GetCertificate(nil); have.Leaf.Subject.CommonName != "second" {
+		t.Fatalf("KeyPair.GetCertificate(): want certificate 'second', have '%s'", have.Leaf.Subject.CommonName)
+	}
+}
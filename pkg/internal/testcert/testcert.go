@@ -0,0 +1,86 @@
+// Package testcert contains helpers for generating certificates and private keys for use in tests.
+package testcert
+
+import (
+	// Standard library.
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// A Cert is a certificate and private key pair, as generated for tests.
+type Cert struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+}
+
+// New returns a certificate for the name given, signed by the parent given, or self-signed as a CA
+// certificate if no parent is given. Certificates are valid for both server and client
+// authentication, with servers expected to listen on the loopback address.
+func New(t *testing.T, name string, parent *Cert) *Cert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey(): want error 'nil', have '%s'", err)
+	}
+
+	var template = &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	var signer = parent
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage = x509.KeyUsageCertSign
+		signer = &Cert{Cert: template, Key: key}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer.Cert, &key.PublicKey, signer.Key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate(): want error 'nil', have '%s'", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate(): want error 'nil', have '%s'", err)
+	}
+
+	return &Cert{Cert: cert, Key: key}
+}
+
+// Write writes the certificate and key to files under the directory given, in PEM format, returning
+// the paths for either file.
+func (c *Cert) Write(t *testing.T, dir, name string) (string, string) {
+	key, err := x509.MarshalECPrivateKey(c.Key)
+	if err != nil {
+		t.Fatalf("x509.MarshalECPrivateKey(): want error 'nil', have '%s'", err)
+	}
+
+	var certFile, keyFile = filepath.Join(dir, name+".pem"), filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw}), 0644); err != nil {
+		t.Fatalf("os.WriteFile(): want error 'nil', have '%s'", err)
+	} else if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key}), 0600); err != nil {
+		t.Fatalf("os.WriteFile(): want error 'nil', have '%s'", err)
+	}
+
+	return certFile, keyFile
+}
+
+// TLS returns a [tls.Certificate] for the certificate and key.
+func (c *Cert) TLS() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.Cert.Raw}, PrivateKey: c.Key, Leaf: c.Cert}
+}
@@ -0,0 +1,194 @@
+// Package metrics implements a minimal set of primitives for collecting metrics, and for reporting
+// these in the Prometheus text exposition format.
+package metrics
+
+import (
+	// Standard library.
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default histogram buckets, in seconds, suitable for measuring the duration of network operations.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// A Histogram counts observations in buckets of configurable upper bounds, alongside the total
+// count and sum of all observations. Histograms are safe for concurrent use, and the zero value is
+// a usable histogram with [DefaultBuckets].
+type Histogram struct {
+	buckets []float64
+
+	// Internal fields.
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram returns a [Histogram] for the bucket upper bounds given, or for [DefaultBuckets] if
+// no bounds are given. Bounds are sorted in increasing order, and an implicit bucket with an upper
+// bound of positive infinity is always added.
+func NewHistogram(buckets ...float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	buckets = slices.Sorted(slices.Values(buckets))
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe records the value given in the [Histogram].
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.buckets == nil {
+		h.buckets, h.counts = DefaultBuckets, make([]uint64, len(DefaultBuckets))
+	}
+
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.counts) {
+		h.counts[i]++
+	}
+
+	h.count++
+	h.sum += v
+}
+
+// ObserveSince records the time elapsed since the time given, in seconds, in the [Histogram].
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// A Snapshot is a point-in-time copy of [Histogram] values, with bucket counts being cumulative,
+// i.e. containing the count of all observations less than or equal to the bucket upper bound.
+type Snapshot struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+// Snapshot returns a point-in-time copy of values recorded in the [Histogram].
+func (h *Histogram) Snapshot() Snapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.buckets == nil {
+		h.buckets, h.counts = DefaultBuckets, make([]uint64, len(DefaultBuckets))
+	}
+
+	var s = Snapshot{Buckets: h.buckets, Counts: make([]uint64, len(h.counts)), Count: h.count, Sum: h.sum}
+	var total uint64
+	for i, n := range h.counts {
+		total += n
+		s.Counts[i] = total
+	}
+
+	return s
+}
+
+// Metric types, as supported in the Prometheus text exposition format.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// A Writer writes metrics in the Prometheus text exposition format. Metric families are expected
+// to be declared via [Writer.Family] ahead of writing any samples for these, and samples for each
+// family are expected to be written together. Any errors in writing are deferred until calling
+// [Writer.Flush].
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter returns a [Writer] for the [io.Writer] given.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Family writes the help text and type for the metric family of the name given.
+func (w *Writer) Family(name, kind, help string) {
+	fmt.Fprintf(w.w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+}
+
+// Value writes a single sample for the metric given, with labels given as alternating names and
+// values.
+func (w *Writer) Value(name string, v float64, labels ...string) {
+	fmt.Fprintf(w.w, "%s%s %s\n", name, formatLabels(labels), formatFloat(v))
+}
+
+// Histogram writes samples for the [Snapshot] given, for the metric given, with labels given as
+// alternating names and values.
+func (w *Writer) Histogram(name string, s Snapshot, labels ...string) {
+	for i, le := range s.Buckets {
+		w.Value(name+"_bucket", float64(s.Counts[i]), append(slices.Clip(labels), "le", formatFloat(le))...)
+	}
+
+	w.Value(name+"_bucket", float64(s.Count), append(slices.Clip(labels), "le", "+Inf")...)
+	w.Value(name+"_sum", s.Sum, labels...)
+	w.Value(name+"_count", float64(s.Count), labels...)
+}
+
+// Flush writes any buffered data to the underlying [io.Writer], returning any errors encountered.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// FormatLabels returns the label set for the alternating label names and values given, or an empty
+// string if no labels are given.
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(labels[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+// FormatFloat returns the textual representation for the value given, as expected in samples.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// EscapeHelp escapes backslashes and line-feeds in the help text given.
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// EscapeLabel escapes backslashes, line-feeds, and double quotes in the label value given.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	// Standard library.
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	var testCases = []struct {
+		descr   string
+		buckets []float64
+		values  []float64
+
+		counts []uint64
+		count  uint64
+		sum    float64
+	}{
+		{
+			descr:   "no observations",
+			buckets: []float64{1, 2},
+			counts:  []uint64{0, 0},
+		},
+		{
+			descr:   "cumulative counts",
+			buckets: []float64{1, 2, 5},
+			values:  []float64{0.5, 1, 1.5, 3, 10},
+			counts:  []uint64{2, 3, 4},
+			count:   5,
+			sum:     16,
+		},
+		{
+			descr:   "unsorted buckets",
+			buckets: []float64{5, 1},
+			values:  []float64{2},
+			counts:  []uint64{0, 1},
+			count:   1,
+			sum:     2,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			h := NewHistogram(tt.buckets...)
+			for _, v := range tt.values {
+				h.Observe(v)
+			}
+
+			s := h.Snapshot()
+			if !slices.Equal(s.Counts, tt.counts) {
+				t.Fatalf("Histogram.Snapshot(): want counts '%v', have '%v'", tt.counts, s.Counts)
+			} else if s.Count != tt.count {
+				t.Fatalf("Histogram.Snapshot(): want count '%d', have '%d'", tt.count, s.Count)
+			} else if s.Sum != tt.sum {
+				t.Fatalf("Histogram.Snapshot(): want sum '%v', have '%v'", tt.sum, s.Sum)
+			}
+		})
+	}
+
+	// Zero-value histograms use default buckets.
+	var h Histogram
+	h.Observe(0.2)
+	if s := h.Snapshot(); !slices.Equal(s.Buckets, DefaultBuckets) || s.Count != 1 {
+		t.Fatalf("Histogram.Snapshot(): want default buckets with one observation, have '%v'", s)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var b strings.Builder
+	var w = NewWriter(&b)
+
+	w.Family("test_total", TypeCounter, "Test counter,\nwith \\ special characters.")
+	w.Value("test_total", 3, "name", `a "quoted"`+"\n"+`\value`, "kind", "b")
+	w.Value("test_total", math.Inf(1))
+
+	h := NewHistogram(0.5, 1)
+	h.Observe(0.25)
+	h.Observe(2)
+
+	w.Family("test_seconds", TypeHistogram, "Test histogram.")
+	w.Histogram("test_seconds", h.Snapshot(), "name", "a")
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Writer.Flush(): want error 'nil', have '%s'", err)
+	}
+
+	want := `# HELP test_total Test counter,\nwith \\ special characters.
+# TYPE test_total counter
+test_total{name="a \"quoted\"\n\\value",kind="b"} 3
+test_total +Inf
+# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{name="a",le="0.5"} 1
+test_seconds_bucket{name="a",le="1"} 1
+test_seconds_bucket{name="a",le="+Inf"} 2
+test_seconds_sum{name="a"} 2.25
+test_seconds_count{name="a"} 2
+`
+
+	if have := b.String(); have != want {
+		t.Fatalf("Writer: want output:\n%s\nhave:\n%s", want, have)
+	}
+}
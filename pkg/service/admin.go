@@ -0,0 +1,198 @@
+package service
+
+import (
+	// Standard library.
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+// Maximum size for request bodies accepted by admin API endpoints.
+const maxAdminRequestSize = 64 * 1024
+
+// WithAdminToken sets the token used for authenticating requests against the admin API, which is
+// only made available if a token is set. Tokens are expected in the 'Authorization' header of
+// requests, as bearer tokens.
+func WithAdminToken(token string) Option {
+	return func(s *Service) error {
+		s.adminToken = token
+		return nil
+	}
+}
+
+// Authorize wraps the given [http.HandlerFunc], rejecting any requests not carrying the configured
+// admin token.
+func (s *Service) authorize(h http.HandlerFunc) http.HandlerFunc {
+	var expected = []byte(s.adminToken)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, expected) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="webhook-gateway"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+// Authorized returns whether the given [http.Request] carries the expected token as a bearer token
+// in its 'Authorization' header. Requests are never authorized against empty tokens.
+func authorized(r *http.Request, expected []byte) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && len(expected) > 0 && subtle.ConstantTimeCompare([]byte(token), expected) == 1
+}
+
+// HandleAdmin sets up request handlers for the admin API against the [serveMux] given, if an admin
+// token has been configured.
+func (s *Service) handleAdmin(mux serveMux) error {
+	if s.adminToken == "" {
+		return nil
+	}
+
+	var handlers = map[string]http.HandlerFunc{
+		"GET /_admin/silences":         s.handleListSilences,
+		"POST /_admin/silences":        s.handleCreateSilence,
+		"DELETE /_admin/silences/{id}": s.handleExpireSilence,
+	}
+
+	// Request history is only available where enabled in configuration.
+	if s.history != nil {
+		handlers["GET /_admin/history"] = s.handleListHistory
+		handlers["GET /_admin/history/{id}"] = s.handleGetHistory
+		handlers["GET /_admin/history/export"] = s.handleExportHistory
+	}
+
+	for _, pattern := range slices.Sorted(maps.Keys(handlers)) {
+		if err := mux.Handle(pattern, s.authorize(handlers[pattern])); err != nil {
+			return fmt.Errorf("failed setting up request handler for '%s': %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// A SilenceResponse represents a [gateway.Silence], as returned by the admin API. Silences defined
+// in configuration are marked as read-only, and cannot be expired via the admin API.
+type silenceResponse struct {
+	*gateway.Silence
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+// HandleListSilences is an HTTP handler returning all silences currently defined, whether in
+// effect or not, as a JSON array.
+func (s *Service) handleListSilences(w http.ResponseWriter, _ *http.Request) {
+	var result = []silenceResponse{}
+	for _, v := range s.silences.List() {
+		result = append(result, silenceResponse{Silence: v, ReadOnly: true})
+	}
+	for _, v := range s.adminSilences.List() {
+		result = append(result, silenceResponse{Silence: v})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// A SilenceRequest represents a request for creating a [gateway.Silence] via the admin API. Silences
+// end either after the duration given, or at the end time given.
+type silenceRequest struct {
+	Matchers  []string              `json:"matchers"`
+	Action    gateway.SilenceAction `json:"action"`
+	Duration  string                `json:"duration"`
+	StartsAt  time.Time             `json:"starts_at"`
+	EndsAt    time.Time             `json:"ends_at"`
+	CreatedBy string                `json:"created_by"`
+	Comment   string                `json:"comment"`
+}
+
+// HandleCreateSilence is an HTTP handler creating a silence for the JSON-encoded [silenceRequest]
+// given, and returning the silence created.
+func (s *Service) handleCreateSilence(w http.ResponseWriter, r *http.Request) {
+	var req silenceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminRequestSize)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("failed parsing request: %s", err), http.StatusBadRequest)
+		return
+	} else if len(req.Matchers) == 0 {
+		http.Error(w, "no matchers given for silence", http.StatusBadRequest)
+		return
+	}
+
+	var silence = &gateway.Silence{
+		Action:    req.Action,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		CreatedBy: req.CreatedBy,
+		Comment:   req.Comment,
+	}
+
+	for _, expr := range req.Matchers {
+		m, err := gateway.ParseMatcher(expr)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed parsing matchers: %s", err), http.StatusBadRequest)
+			return
+		}
+		silence.Matchers = append(silence.Matchers, m)
+	}
+
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration '%s'", req.Duration), http.StatusBadRequest)
+			return
+		} else if silence.StartsAt.IsZero() {
+			silence.StartsAt = time.Now()
+		}
+		silence.EndsAt = silence.StartsAt.Add(d)
+	}
+
+	if err := s.adminSilences.Add(silence); err != nil {
+		http.Error(w, fmt.Sprintf("failed creating silence: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	s.logger.Info("Created silence", "id", silence.ID, "created-by", silence.CreatedBy, "ends-at", silence.EndsAt)
+	writeJSON(w, http.StatusCreated, silenceResponse{Silence: silence})
+}
+
+// HandleExpireSilence is an HTTP handler removing the silence with the ID given, and releasing any
+// messages held by the silence in all gateways.
+func (s *Service) handleExpireSilence(w http.ResponseWriter, r *http.Request) {
+	var id = r.PathValue("id")
+	ok, err := s.adminSilences.Remove(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed expiring silence: %s", err), http.StatusInternalServerError)
+		return
+	} else if !ok {
+		if slices.ContainsFunc(s.silences.List(), func(v *gateway.Silence) bool { return v.ID == id }) {
+			http.Error(w, "silences defined in configuration cannot be expired", http.StatusConflict)
+			return
+		}
+		http.Error(w, "silence not found", http.StatusNotFound)
+		return
+	}
+
+	s.mu.RLock()
+	var gateways = s.gateway
+	s.mu.RUnlock()
+
+	for _, g := range gateways {
+		g.Release(id)
+	}
+
+	s.logger.Info("Expired silence", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WriteJSON writes the given value to the [http.ResponseWriter] as JSON, with the status given.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
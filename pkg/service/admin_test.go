@@ -0,0 +1,138 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testHandler struct {
+	*http.ServeMux
+}
+
+func (h *testHandler) Handle(pattern string, fn http.HandlerFunc) error {
+	h.HandleFunc(pattern, fn)
+	return nil
+}
+
+func (h *testHandler) Init(context.Context) error  { return nil }
+func (h *testHandler) Close(context.Context) error { return nil }
+
+func TestServiceAdminSilences(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "silences.json")
+	var h = http.NewServeMux()
+
+	s, err := New(WithAdminToken("secret"))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	} else if err := s.adminSilences.Load(path); err != nil {
+		t.Fatalf("Silences.Load(): want error 'nil', have '%s'", err)
+	} else if err := s.handleAdmin(serveMux{h}); err != nil {
+		t.Fatalf("Service.handleAdmin(): want error 'nil', have '%s'", err)
+	}
+
+	request := func(method, target, token, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	var testCases = []struct {
+		descr  string
+		method string
+		target string
+		token  string
+		body   string
+
+		status int
+	}{
+		{
+			descr:  "missing token",
+			method: "GET",
+			target: "/_admin/silences",
+			status: http.StatusUnauthorized,
+		},
+		{
+			descr:  "invalid token",
+			method: "GET",
+			target: "/_admin/silences",
+			token:  "invalid",
+			status: http.StatusUnauthorized,
+		},
+		{
+			descr:  "silence without matchers",
+			method: "POST",
+			target: "/_admin/silences",
+			token:  "secret",
+			body:   `{"duration": "1h"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			descr:  "silence without duration",
+			method: "POST",
+			target: "/_admin/silences",
+			token:  "secret",
+			body:   `{"matchers": ["alertname=\"DiskFull\""]}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			descr:  "unknown silence",
+			method: "DELETE",
+			target: "/_admin/silences/unknown",
+			token:  "secret",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			if w := request(tt.method, tt.target, tt.token, tt.body); w.Code != tt.status {
+				t.Fatalf("%s %s: want status '%d', have '%d'", tt.method, tt.target, tt.status, w.Code)
+			}
+		})
+	}
+
+	// Create silence, and ensure it is listed and persisted.
+	w := request("POST", "/_admin/silences", "secret", `{"matchers": ["alertname=\"DiskFull\""], "duration": "1h", "created_by": "ops", "comment": "Flapping"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /_admin/silences: want status '%d', have '%d'", http.StatusCreated, w.Code)
+	}
+
+	var created silenceResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("POST /_admin/silences: want error 'nil', have '%s'", err)
+	} else if created.ID == "" || created.CreatedBy != "ops" || created.Matchers[0].String() != `alertname="DiskFull"` {
+		t.Fatalf("POST /_admin/silences: want created silence, have '%+v'", created.Silence)
+	}
+
+	var listed []silenceResponse
+	w = request("GET", "/_admin/silences", "secret", "")
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("GET /_admin/silences: want error 'nil', have '%s'", err)
+	} else if len(listed) != 1 || listed[0].ID != created.ID {
+		t.Fatalf("GET /_admin/silences: want silence '%s', have '%v'", created.ID, listed)
+	}
+
+	loaded, _ := New()
+	if err := loaded.adminSilences.Load(path); err != nil {
+		t.Fatalf("Silences.Load(): want error 'nil', have '%s'", err)
+	} else if v := loaded.adminSilences.List(); len(v) != 1 || v[0].ID != created.ID {
+		t.Fatalf("Silences.Load(): want silence '%s', have '%v'", created.ID, v)
+	}
+
+	// Expire silence, and ensure it is no longer listed.
+	if w := request("DELETE", "/_admin/silences/"+created.ID, "secret", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /_admin/silences: want status '%d', have '%d'", http.StatusNoContent, w.Code)
+	} else if v := s.adminSilences.List(); len(v) != 0 {
+		t.Fatalf("DELETE /_admin/silences: want no silences, have '%v'", v)
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+// Number of recent requests shown for each gateway on the dashboard.
+const dashboardHistorySize = 10
+
+// DashboardTemplate is the HTML template for the dashboard, embedded at build time, and rendered
+// against [dashboardData] values.
+//
+//go:embed dashboard.html
+var dashboardTemplate string
+
+// Dashboard is the parsed dashboard template.
+var dashboard = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.Local().Format(time.DateTime) },
+}).Parse(dashboardTemplate))
+
+// WithDashboard enables the web dashboard for the [Service], which requires the admin token to be
+// set; see [WithAdminToken] for more information.
+func WithDashboard() Option {
+	return func(s *Service) error {
+		s.dashboard = true
+		return nil
+	}
+}
+
+// DashboardData represents the state of the [Service], as rendered on the dashboard.
+type dashboardData struct {
+	Gateways     []dashboardGateway
+	Destinations []dashboardDestination
+	Alerts       []*gateway.Alert
+	Silences     []silenceResponse
+	History      bool   // Whether request history is enabled.
+	Notice       string // The outcome of the last action taken, if any.
+	Failed       bool   // Whether the last action taken failed.
+}
+
+// A DashboardGateway represents a [gateway.Gateway], as rendered on the dashboard.
+type dashboardGateway struct {
+	componentStatus
+	Source       string
+	Destinations []string
+	Requests     map[string]uint64
+	Recent       []*gateway.HistoryEntry
+}
+
+// A DashboardDestination represents a shared or inline [gateway.Destination], as rendered on the
+// dashboard.
+type dashboardDestination struct {
+	componentStatus
+	Type string
+}
+
+// HandleDashboard sets up request handlers for the web dashboard against the [serveMux] given, if
+// enabled. The dashboard requires the admin token, given either as a bearer token, or as the
+// password for HTTP basic authentication, as prompted for by browsers.
+func (s *Service) handleDashboard(mux serveMux) error {
+	if !s.dashboard || s.adminToken == "" {
+		return nil
+	}
+
+	var handlers = map[string]http.HandlerFunc{
+		"GET /_dashboard":       s.handleShowDashboard,
+		"POST /_dashboard/test": s.handleTestMessage,
+	}
+
+	for _, pattern := range slices.Sorted(maps.Keys(handlers)) {
+		if err := mux.Handle(pattern, s.authorizeDashboard(handlers[pattern])); err != nil {
+			return fmt.Errorf("failed setting up request handler for '%s': %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// AuthorizeDashboard wraps the given [http.HandlerFunc], rejecting any requests not carrying the
+// configured admin token, as well as any requests with side-effects made from other origins, as
+// browsers attach credentials for basic authentication to these automatically.
+func (s *Service) authorizeDashboard(h http.HandlerFunc) http.HandlerFunc {
+	var expected = []byte(s.adminToken)
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			_, token, ok = r.BasicAuth()
+		}
+
+		if !ok || subtle.ConstantTimeCompare([]byte(token), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="webhook-gateway", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		} else if r.Method != "GET" && !sameOrigin(r) {
+			http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+// SameOrigin returns whether the given request was made from the same origin as the request target,
+// as reported by browsers; requests made outside of browsers are taken to be same-origin.
+func sameOrigin(r *http.Request) bool {
+	if v := r.Header.Get("Sec-Fetch-Site"); v != "" {
+		return v == "same-origin" || v == "none"
+	} else if v := r.Header.Get("Origin"); v != "" {
+		u, err := url.Parse(v)
+		return err == nil && u.Host == r.Host
+	}
+
+	return true
+}
+
+// HandleShowDashboard is an HTTP handler rendering the dashboard, showing all gateways and
+// destinations currently running, along with recent requests for each gateway (where request
+// history is enabled), tracked alerts, and silences.
+func (s *Service) handleShowDashboard(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	var data = dashboardData{
+		History: s.history != nil,
+		Notice:  r.URL.Query().Get("notice"),
+		Failed:  r.URL.Query().Has("failed"),
+	}
+
+	s.mu.RLock()
+	var gateways, destinations, gatewayConf, destinationConf = s.gateway, s.destinations, s.gatewayConf, s.destinationConf
+	s.mu.RUnlock()
+
+	for _, g := range gateways {
+		var v = dashboardGateway{
+			componentStatus: newComponentStatus(g.Name(), g.Healthy(ctx), true, g.Activity()),
+			Destinations:    g.Destinations(),
+			Requests:        g.Requests(),
+		}
+		if src, ok := gatewayConf[g]["source"].(map[string]any); ok {
+			v.Source, _ = src["type"].(string)
+		}
+		if s.history != nil {
+			v.Recent = s.history.List(g.Name(), dashboardHistorySize)
+		}
+		data.Gateways = append(data.Gateways, v)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(destinations)) {
+		var v = dashboardDestination{componentStatus: s.destinationStatus(ctx, name, destinations[name], destinationConf[name])}
+		v.Type, _ = destinationConf[name]["type"].(string)
+		data.Destinations = append(data.Destinations, v)
+	}
+
+	data.Alerts = s.alerts.List()
+	for _, v := range s.silences.List() {
+		data.Silences = append(data.Silences, silenceResponse{Silence: v, ReadOnly: true})
+	}
+	for _, v := range s.adminSilences.List() {
+		data.Silences = append(data.Silences, silenceResponse{Silence: v})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+	if err := dashboard.Execute(w, data); err != nil {
+		s.logger.Error("Failed rendering dashboard", "error", err.Error())
+	}
+}
+
+// HandleTestMessage is an HTTP handler pushing a test message to the destination named in the
+// 'destination' form value, and redirecting back to the dashboard with the outcome.
+func (s *Service) handleTestMessage(w http.ResponseWriter, r *http.Request) {
+	var name = r.PostFormValue("destination")
+
+	s.mu.RLock()
+	d, ok := s.destinations[name]
+	s.mu.RUnlock()
+
+	var notice = url.Values{}
+	if !ok {
+		notice.Set("notice", fmt.Sprintf("Unknown destination '%s'", name))
+		notice.Set("failed", "")
+		http.Redirect(w, r, "/_dashboard?"+notice.Encode(), http.StatusSeeOther)
+		return
+	}
+
+	var msg = &gateway.Message{
+		Title:     "Test message",
+		Body:      "This is a test message, sent from the webhook-gateway dashboard.",
+		Content:   "Test message: This is a test message, sent from the webhook-gateway dashboard.",
+		Source:    "dashboard",
+		Timestamp: time.Now(),
+	}
+
+	if err := d.PushMessages(gateway.SetPath(r.Context(), "/_dashboard"), msg); err != nil {
+		s.logger.Error("Failed pushing test message", "destination", name, "error", err.Error())
+		notice.Set("notice", fmt.Sprintf("Failed sending test message to '%s': %s", name, err))
+		notice.Set("failed", "")
+	} else {
+		s.logger.Info("Pushed test message", "destination", name)
+		notice.Set("notice", fmt.Sprintf("Sent test message to '%s'", name))
+	}
+
+	http.Redirect(w, r, "/_dashboard?"+notice.Encode(), http.StatusSeeOther)
+}
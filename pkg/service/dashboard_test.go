@@ -0,0 +1,147 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+func TestServiceDashboard(t *testing.T) {
+	var h = &testHandler{http.NewServeMux()}
+	s, err := New(WithHandler(h), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	var data = map[string]any{
+		"admin":       map[string]any{"token": "secret", "dashboard": true},
+		"history":     map[string]any{},
+		"destination": map[string]any{"shared": map[string]any{"type": "test"}},
+		"silence":     []map[string]any{{"matchers": []any{`alertname="DiskFull"`}, "ends-at": time.Now().Add(time.Hour)}},
+		"gateway":     []map[string]any{{"path": "/test", "source": map[string]any{"type": "test"}, "destination": "shared"}},
+	}
+
+	if err := s.UnmarshalTOML(data); err != nil {
+		t.Fatalf("Service.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	} else if err := s.Init(context.Background()); err != nil {
+		t.Fatalf("Service.Init(): want error 'nil', have '%s'", err)
+	}
+
+	request := func(method, target, password string, header http.Header, form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		if form != nil {
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if password != "" {
+			r.SetBasicAuth("admin", password)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := request("POST", "/test", "", nil, nil); w.Code != http.StatusAccepted {
+		t.Fatalf("POST /test: want status '%d', have '%d'", http.StatusAccepted, w.Code)
+	} else if _, err := s.alerts.Track("/test", "disk-full", &gateway.Message{Title: "Disk full", Status: gateway.StatusFiring}); err != nil {
+		t.Fatalf("AlertTracker.Track(): want error 'nil', have '%s'", err)
+	}
+
+	var testCases = []struct {
+		descr    string
+		method   string
+		target   string
+		password string
+		header   http.Header
+		form     url.Values
+
+		status int
+		want   []string
+	}{
+		{
+			descr:  "missing credentials",
+			method: "GET",
+			target: "/_dashboard",
+			status: http.StatusUnauthorized,
+		},
+		{
+			descr:    "invalid credentials",
+			method:   "GET",
+			target:   "/_dashboard",
+			password: "invalid",
+			status:   http.StatusUnauthorized,
+		},
+		{
+			descr:    "show dashboard",
+			method:   "GET",
+			target:   "/_dashboard?notice=Sent+test+message",
+			password: "secret",
+			status:   http.StatusOK,
+			want: []string{"<code>/test</code>", "<code>shared</code>", "accepted: 1", "<div>Hello</div>", "Sent test message",
+				`value="shared"`, "Disk full", "Configuration"},
+		},
+		{
+			descr:    "cross-origin test message",
+			method:   "POST",
+			target:   "/_dashboard/test",
+			password: "secret",
+			header:   http.Header{"Sec-Fetch-Site": {"cross-site"}},
+			form:     url.Values{"destination": {"shared"}},
+			status:   http.StatusForbidden,
+		},
+		{
+			descr:    "test message for unknown destination",
+			method:   "POST",
+			target:   "/_dashboard/test",
+			password: "secret",
+			form:     url.Values{"destination": {"unknown"}},
+			status:   http.StatusSeeOther,
+			want:     []string{"failed="},
+		},
+		{
+			descr:    "test message",
+			method:   "POST",
+			target:   "/_dashboard/test",
+			password: "secret",
+			header:   http.Header{"Origin": {"http://example.com"}},
+			form:     url.Values{"destination": {"shared"}},
+			status:   http.StatusSeeOther,
+			want:     []string{"notice=Sent+test+message"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			w := request(tt.method, tt.target, tt.password, tt.header, tt.form)
+			if w.Code != tt.status {
+				t.Fatalf("%s %s: want status '%d', have '%d'", tt.method, tt.target, tt.status, w.Code)
+			}
+
+			var body = w.Body.String() + w.Header().Get("Location")
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Fatalf("%s %s: want response containing '%s', have '%s'", tt.method, tt.target, want, body)
+				}
+			}
+		})
+	}
+
+	var dest, _ = unwrap[*testDestination](s.destinations["shared"])
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Service.Close(): want error 'nil', have '%s'", err)
+	} else if len(dest.messages) != 2 || dest.messages[1].Source != "dashboard" {
+		t.Fatalf("POST /_dashboard/test: want test message delivered, have '%+v'", dest.messages)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	// Standard library.
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+// NewHistory returns a [gateway.History] for the history configuration given. Persisting history
+// requires a state directory to be configured, with history kept in a file under the directory.
+func (s *Service) newHistory(conf map[string]any) (*gateway.History, error) {
+	var size, maxBody int
+	switch v := conf["size"].(type) {
+	case nil:
+	case int64:
+		if v <= 0 {
+			return nil, fmt.Errorf("invalid size '%d', expected positive number", v)
+		}
+		size = int(v)
+	default:
+		return nil, fmt.Errorf("invalid size '%v', expected number", v)
+	}
+
+	switch v := conf["max-body-size"].(type) {
+	case nil:
+	case int64:
+		if v <= 0 {
+			return nil, fmt.Errorf("invalid maximum body size '%d', expected positive number", v)
+		}
+		maxBody = int(v)
+	default:
+		return nil, fmt.Errorf("invalid maximum body size '%v', expected number", v)
+	}
+
+	switch v := conf["persist"].(type) {
+	case nil:
+	case bool:
+		if v && s.stateDir == "" {
+			return nil, fmt.Errorf("persisting history requires a state directory")
+		}
+	default:
+		return nil, fmt.Errorf("invalid value '%v' for 'persist', expected boolean", v)
+	}
+
+	return gateway.NewHistory(size, maxBody), nil
+}
+
+// HandleListHistory is an HTTP handler returning entries recorded in request history as a JSON
+// array, most recent first. Entries can be limited to those for a specific gateway with the
+// 'gateway' query parameter, and to a maximum number with the 'limit' query parameter.
+func (s *Service) handleListHistory(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit '%s'", v), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	var result = []*gateway.HistoryEntry{}
+	result = append(result, s.history.List(r.URL.Query().Get("gateway"), limit)...)
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// HandleGetHistory is an HTTP handler returning the request history entry with the ID given.
+func (s *Service) handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	e, ok := s.history.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "history entry not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, e)
+}
+
+// HandleExportHistory is an HTTP handler returning all entries recorded in request history as a
+// JSON array, as a file attachment suitable for inclusion in bug reports. Entries can be limited to
+// those for a specific gateway with the 'gateway' query parameter.
+func (s *Service) handleExportHistory(w http.ResponseWriter, r *http.Request) {
+	var result = []*gateway.HistoryEntry{}
+	result = append(result, s.history.List(r.URL.Query().Get("gateway"), 0)...)
+
+	var name = "webhook-gateway-history-" + time.Now().UTC().Format("20060102T150405Z") + ".json"
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+func TestServiceHistory(t *testing.T) {
+	var dir = t.TempDir()
+	var h = &testHandler{http.NewServeMux()}
+
+	s, err := New(WithHandler(h), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	var data = map[string]any{
+		"state-dir":   dir,
+		"admin":       map[string]any{"token": "secret"},
+		"history":     map[string]any{"size": int64(10), "persist": true},
+		"destination": map[string]any{"shared": map[string]any{"type": "test"}},
+		"gateway": []map[string]any{{
+			"path":        "/test",
+			"source":      map[string]any{"type": "test"},
+			"destination": []any{"shared", map[string]any{"type": "test"}},
+		}},
+	}
+
+	if err := s.UnmarshalTOML(data); err != nil {
+		t.Fatalf("Service.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	} else if err := s.Init(context.Background()); err != nil {
+		t.Fatalf("Service.Init(): want error 'nil', have '%s'", err)
+	}
+
+	request := func(method, target, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(`{"status": "firing"}`))
+		r.Header.Set("X-Request-Id", "abc-123")
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := request("POST", "/test", "hook-token")
+	var id = w.Header().Get("X-Request-Id")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST /test: want status '%d', have '%d'", http.StatusAccepted, w.Code)
+	} else if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Service.Close(): want error 'nil', have '%s'", err)
+	}
+
+	var testCases = []struct {
+		descr  string
+		target string
+		token  string
+
+		status int
+		count  int
+	}{
+		{descr: "missing token", target: "/_admin/history", status: http.StatusUnauthorized},
+		{descr: "list entries", target: "/_admin/history", token: "secret", status: http.StatusOK, count: 1},
+		{descr: "list entries for gateway", target: "/_admin/history?gateway=/test&limit=1", token: "secret", status: http.StatusOK, count: 1},
+		{descr: "list entries for unknown gateway", target: "/_admin/history?gateway=/unknown", token: "secret", status: http.StatusOK},
+		{descr: "invalid limit", target: "/_admin/history?limit=none", token: "secret", status: http.StatusBadRequest},
+		{descr: "export entries", target: "/_admin/history/export", token: "secret", status: http.StatusOK, count: 1},
+		{descr: "unknown entry", target: "/_admin/history/unknown", token: "secret", status: http.StatusNotFound},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			w := request("GET", tt.target, tt.token)
+			if w.Code != tt.status {
+				t.Fatalf("GET %s: want status '%d', have '%d'", tt.target, tt.status, w.Code)
+			} else if w.Code != http.StatusOK {
+				return
+			}
+
+			var entries []*gateway.HistoryEntry
+			if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+				t.Fatalf("GET %s: want error 'nil', have '%s'", tt.target, err)
+			} else if len(entries) != tt.count {
+				t.Fatalf("GET %s: want %d entries, have %d", tt.target, tt.count, len(entries))
+			}
+		})
+	}
+
+	if w := request("GET", "/_admin/history/export", "secret"); !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment;") {
+		t.Fatalf("GET /_admin/history/export: want attachment, have '%s'", w.Header().Get("Content-Disposition"))
+	}
+
+	// Entries are expected to record the request, with sensitive headers redacted, and delivery
+	// outcomes for each destination, as reported by delivery queues under the name known to the
+	// gateway.
+	var e gateway.HistoryEntry
+	if w := request("GET", "/_admin/history/"+id, "secret"); w.Code != http.StatusOK {
+		t.Fatalf("GET /_admin/history/%s: want status '%d', have '%d'", id, http.StatusOK, w.Code)
+	} else if err := json.NewDecoder(w.Body).Decode(&e); err != nil {
+		t.Fatalf("GET /_admin/history/%s: want error 'nil', have '%s'", id, err)
+	}
+
+	if e.ID != id || e.ClientID != "abc-123" {
+		t.Fatalf("GET /_admin/history/%s: want entry with client request ID 'abc-123', have '%+v'", id, e)
+	} else if e.Gateway != "/test" || e.Status != http.StatusAccepted || e.Outcome != "accepted" || len(e.Messages) != 1 {
+		t.Fatalf("GET /_admin/history/%s: want accepted request, have '%+v'", id, e)
+	} else if have := e.Headers["Authorization"]; len(have) != 1 || have[0] != "[REDACTED]" {
+		t.Fatalf("GET /_admin/history/%s: want redacted authorization header, have '%v'", id, have)
+	} else if len(e.Deliveries) != 2 {
+		t.Fatalf("GET /_admin/history/%s: want 2 deliveries, have '%+v'", id, e.Deliveries)
+	}
+
+	for _, d := range e.Deliveries {
+		if d.Status != gateway.DeliveryDelivered || d.Attempts != 1 || d.Messages != 1 {
+			t.Fatalf("GET /_admin/history/%s: want delivered messages for '%s', have '%+v'", id, d.Destination, d)
+		}
+	}
+
+	// Persisted entries are expected to be loaded back on restart.
+	var history = gateway.NewHistory(10, 0)
+	if err := history.Load(filepath.Join(dir, "history.log")); err != nil {
+		t.Fatalf("History.Load(): want error 'nil', have '%s'", err)
+	} else if e, ok := history.Get(id); !ok || len(e.Deliveries) != 2 {
+		t.Fatalf("History.Load(): want persisted entry, have '%+v'", e)
+	}
+}
@@ -3,20 +3,31 @@ package service
 import (
 	// Standard library.
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
 	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
 )
 
-// HTTP represents a basic HTTP server, currently only able to serve plain HTTP requests.
+// HTTP represents a basic HTTP server, able to serve plain HTTP requests, or HTTPS requests where a
+// certificate is configured, with optional verification of client certificates.
 type HTTP struct {
 	// Configuration options.
-	host string
-	port string
+	host        string
+	port        string
+	certificate *gateway.KeyPair
+	clientCA    *gateway.CertPool
 
 	// Internal fields.
-	server *http.Server
+	server            *http.Server
+	requestClientCert bool // Whether client certificates are requested, for verification elsewhere.
+	logger            *slog.Logger
 }
 
 // NewHTTP instantiates a new HTTP server for the given options.
@@ -26,6 +37,7 @@ func NewHTTP(options ...HTTPOption) (*HTTP, error) {
 			Handler:           http.NewServeMux(),
 			ReadHeaderTimeout: time.Second * 1,
 		},
+		logger: slog.Default(),
 	}
 
 	for _, fn := range options {
@@ -56,29 +68,47 @@ func WithHTTPPort(port string) HTTPOption {
 	}
 }
 
+// WithHTTPCertificate sets the PEM-encoded certificate and private key files used for serving HTTPS
+// requests. Certificates are reloaded automatically when either file changes, e.g. on renewal.
+func WithHTTPCertificate(certFile, keyFile string) HTTPOption {
+	return func(h *HTTP) error {
+		c, err := gateway.LoadKeyPair(certFile, keyFile, h.logger)
+		if err != nil {
+			return err
+		}
+		h.certificate = c
+		return nil
+	}
+}
+
+// WithHTTPClientCA sets the [gateway.CertPool] used for verifying client certificates, which are
+// then required for all incoming requests. Client certificates are only verified where a server
+// certificate is also set, see [WithHTTPCertificate].
+func WithHTTPClientCA(pool *gateway.CertPool) HTTPOption {
+	return func(h *HTTP) error {
+		h.clientCA = pool
+		return nil
+	}
+}
+
+// WithHTTPLogger sets the given [slog.Logger] as the log handler for the HTTP server.
+func WithHTTPLogger(l *slog.Logger) HTTPOption {
+	return func(h *HTTP) error {
+		h.logger = l
+		return nil
+	}
+}
+
 // Handle registers the given [http.HandlerFunc] for the given HTTP method and path pattern. Any
 // errors caught will be returned verbatim; check documentation for [http.ServeMux] for more
 // information.
-func (h *HTTP) Handle(pattern string, handler http.HandlerFunc) (err error) {
-	defer func() {
-		if v := recover(); v == nil {
-			return
-		} else if s, ok := v.(string); ok {
-			err = errors.New(s)
-		} else if e, ok := v.(error); ok {
-			err = e
-		} else {
-			err = errors.New("unknown error in setting up HTTP handler")
-		}
-	}()
-
-	h.server.Handler.(*http.ServeMux).HandleFunc(pattern, handler)
-	return err
+func (h *HTTP) Handle(pattern string, handler http.HandlerFunc) error {
+	return serveMux{h.server.Handler.(*http.ServeMux)}.Handle(pattern, handler)
 }
 
 // Init ensures the HTTP server is configured correctly, and listens on the configured hostname and
-// port, ensuring that the listener is correctly set up before returning.
-// TODO: Ensure context cancellation causes graceful shutdown.
+// port, ensuring that the listener is correctly set up before returning. Connections are served over
+// TLS if a certificate is configured.
 func (h *HTTP) Init(ctx context.Context) error {
 	// Start internal TCP socket listener.
 	ln, err := net.Listen("tcp", net.JoinHostPort(h.host, h.port))
@@ -86,6 +116,14 @@ func (h *HTTP) Init(ctx context.Context) error {
 		return err
 	}
 
+	// Report errors in accepting connections, e.g. failed TLS handshakes, via the configured logger.
+	h.server.ErrorLog = slog.NewLogLogger(h.logger.Handler(), slog.LevelWarn)
+
+	if h.certificate != nil {
+		h.server.TLSConfig = h.tlsConfig()
+		ln = tls.NewListener(ln, h.server.TLSConfig)
+	}
+
 	// Wait for HTTP server to begin listening for connections before returning, in order to ensure
 	// that subsequent calls to receiver functions can complete successfully.
 	wait := make(chan error, 1)
@@ -101,3 +139,64 @@ func (h *HTTP) Init(ctx context.Context) error {
 
 	return <-wait
 }
+
+// TLSConfig returns the [tls.Config] used for serving HTTPS requests. Client certificates are
+// required and verified against the configured CA certificates, if any, which are reloaded on each
+// handshake where changed. Otherwise, client certificates are requested, but not verified, where
+// required for verification by individual gateways.
+func (h *HTTP) tlsConfig() *tls.Config {
+	var conf = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: h.certificate.GetCertificate,
+	}
+
+	if h.clientCA != nil {
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+		conf.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			var c = conf.Clone()
+			c.GetConfigForClient, c.ClientCAs = nil, h.clientCA.Pool()
+			return c, nil
+		}
+	} else if h.requestClientCert {
+		conf.ClientAuth = tls.RequestClientCert
+	}
+
+	return conf
+}
+
+// Close stops the HTTP server from accepting any new connections, and waits for any requests in
+// progress to complete, or until the given [context.Context] is cancelled, in which case any open
+// connections are closed forcibly.
+func (h *HTTP) Close(ctx context.Context) error {
+	if err := h.server.Shutdown(ctx); err != nil {
+		_ = h.server.Close()
+		return fmt.Errorf("failed shutting down HTTP server: %w", err)
+	}
+
+	return nil
+}
+
+// A ServeMux wraps a [http.ServeMux], returning errors for invalid or conflicting patterns given
+// when registering handlers, rather than panicking.
+type serveMux struct {
+	*http.ServeMux
+}
+
+// Handle registers the given [http.HandlerFunc] for the given HTTP method and path pattern.
+func (m serveMux) Handle(pattern string, handler http.HandlerFunc) (err error) {
+	defer func() {
+		if v := recover(); v == nil {
+			return
+		} else if s, ok := v.(string); ok {
+			err = errors.New(s)
+		} else if e, ok := v.(error); ok {
+			err = e
+		} else {
+			err = errors.New("unknown error in setting up HTTP handler")
+		}
+	}()
+
+	m.HandleFunc(pattern, handler)
+	return err
+}
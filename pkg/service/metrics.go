@@ -0,0 +1,116 @@
+package service
+
+import (
+	// Standard library.
+	"maps"
+	"net/http"
+	"slices"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+	"go.deuill.org/webhook-gateway/pkg/metrics"
+)
+
+// Prefix for names of all metrics reported by the service.
+const metricsPrefix = "webhook_gateway_"
+
+// HandleMetrics is an HTTP handler returning metrics for all gateways and destinations currently
+// running, in the Prometheus text exposition format. Requests require the admin token, if one is
+// configured.
+func (s *Service) handleMetrics() (string, http.HandlerFunc) {
+	var h = func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m := metrics.NewWriter(w)
+		s.writeMetrics(m)
+		if err := m.Flush(); err != nil {
+			s.logger.Error("Failed writing metrics", "error", err.Error())
+		}
+	}
+
+	if s.adminToken != "" {
+		h = s.authorize(h)
+	}
+
+	return "GET /_metrics", h
+}
+
+// WriteMetrics writes metrics for all gateways and destinations currently running to the given
+// [metrics.Writer].
+func (s *Service) writeMetrics(m *metrics.Writer) {
+	s.mu.RLock()
+	var gateways, destinations = s.gateway, s.destinations
+	s.mu.RUnlock()
+
+	// Metrics for gateways, including parsing of requests by sources.
+	m.Family(metricsPrefix+"requests_total", metrics.TypeCounter, "Total number of requests handled by gateways, by outcome.")
+	for _, g := range gateways {
+		requests := g.Requests()
+		for _, outcome := range slices.Sorted(maps.Keys(requests)) {
+			m.Value(metricsPrefix+"requests_total", float64(requests[outcome]), "gateway", g.Name(), "outcome", outcome)
+		}
+	}
+
+	m.Family(metricsPrefix+"parse_duration_seconds", metrics.TypeHistogram, "Time taken for parsing requests via gateway sources.")
+	for _, g := range gateways {
+		parse, _ := g.Latency()
+		m.Histogram(metricsPrefix+"parse_duration_seconds", parse, "gateway", g.Name())
+	}
+
+	m.Family(metricsPrefix+"push_duration_seconds", metrics.TypeHistogram, "Time taken for pushing messages to gateway destinations.")
+	for _, g := range gateways {
+		_, push := g.Latency()
+		m.Histogram(metricsPrefix+"push_duration_seconds", push, "gateway", g.Name())
+	}
+
+	for _, c := range []struct {
+		name, help string
+		value      func(*gateway.Gateway) uint64
+	}{
+		{"messages_filtered_total", "Total number of messages dropped by gateway filters.", (*gateway.Gateway).Filtered},
+		{"messages_deduplicated_total", "Total number of duplicate messages dropped by gateways.", (*gateway.Gateway).Deduplicated},
+		{"messages_silenced_total", "Total number of messages dropped or held by silences.", (*gateway.Gateway).Silenced},
+	} {
+		m.Family(metricsPrefix+c.name, metrics.TypeCounter, c.help)
+		for _, g := range gateways {
+			m.Value(metricsPrefix+c.name, float64(c.value(g)), "gateway", g.Name())
+		}
+	}
+
+	// Metrics for destinations, as reported by delivery queues and destinations themselves.
+	var names = slices.Sorted(maps.Keys(destinations))
+
+	m.Family(metricsPrefix+"queue_length", metrics.TypeGauge, "Number of entries pending delivery to destinations.")
+	for _, name := range names {
+		if q := queueOf(destinations[name]); q != nil {
+			m.Value(metricsPrefix+"queue_length", float64(q.Len()), "destination", name)
+		}
+	}
+
+	m.Family(metricsPrefix+"deliveries_total", metrics.TypeCounter, "Total number of delivery attempts made to destinations, by outcome.")
+	for _, name := range names {
+		if q := queueOf(destinations[name]); q != nil {
+			deliveries := q.Deliveries()
+			for _, outcome := range slices.Sorted(maps.Keys(deliveries)) {
+				m.Value(metricsPrefix+"deliveries_total", float64(deliveries[outcome]), "destination", name, "outcome", outcome)
+			}
+		}
+	}
+
+	m.Family(metricsPrefix+"delivery_duration_seconds", metrics.TypeHistogram, "Time taken for delivery attempts made to destinations.")
+	for _, name := range names {
+		if q := queueOf(destinations[name]); q != nil {
+			m.Histogram(metricsPrefix+"delivery_duration_seconds", q.Latency(), "destination", name)
+		}
+	}
+
+	m.Family(metricsPrefix+"destination_connected", metrics.TypeGauge, "Whether destinations are connected to remote endpoints, where applicable.")
+	for _, name := range names {
+		if c, ok := unwrap[interface{ Connected() bool }](destinations[name]); ok {
+			var v float64
+			if c.Connected() {
+				v = 1
+			}
+			m.Value(metricsPrefix+"destination_connected", v, "destination", name)
+		}
+	}
+}
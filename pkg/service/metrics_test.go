@@ -0,0 +1,77 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServiceMetrics(t *testing.T) {
+	var h = &testHandler{http.NewServeMux()}
+	s, err := New(WithHandler(h), WithAdminToken("secret"), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	var data = map[string]any{
+		"destination": map[string]any{"shared": map[string]any{"type": "test"}},
+		"gateway": []map[string]any{
+			{"path": "/test", "source": map[string]any{"type": "test"}, "destination": "shared"},
+			{"secret": "hunter2", "source": map[string]any{"type": "test"}, "destination": "shared"},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := s.UnmarshalTOML(data); err != nil {
+		t.Fatalf("Service.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	} else if err := s.Init(ctx); err != nil {
+		t.Fatalf("Service.Init(): want error 'nil', have '%s'", err)
+	}
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := request("POST", "/test", ""); w.Code != http.StatusAccepted {
+		t.Fatalf("POST /test: want status '%d', have '%d'", http.StatusAccepted, w.Code)
+	} else if w := request("GET", "/_metrics", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /_metrics: want status '%d' without token, have '%d'", http.StatusUnauthorized, w.Code)
+	}
+
+	w := request("GET", "/_metrics", "secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /_metrics: want status '%d', have '%d'", http.StatusOK, w.Code)
+	}
+
+	var body = w.Body.String()
+	for _, want := range []string{
+		"# TYPE webhook_gateway_requests_total counter\n",
+		`webhook_gateway_requests_total{gateway="/test",outcome="accepted"} 1` + "\n",
+		`webhook_gateway_parse_duration_seconds_count{gateway="/test"} 1` + "\n",
+		`webhook_gateway_messages_filtered_total{gateway="/test"} 0` + "\n",
+		`webhook_gateway_queue_length{destination="shared"} `,
+		`webhook_gateway_delivery_duration_seconds_bucket{destination="shared",le="+Inf"} `,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("GET /_metrics: want output containing '%s', have:\n%s", want, body)
+		}
+	}
+
+	// Gateways with paths derived from secrets are not reported by path.
+	if strings.Contains(body, "hunter2") {
+		t.Fatalf("GET /_metrics: want gateway secret redacted, have:\n%s", body)
+	}
+}
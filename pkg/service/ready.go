@@ -0,0 +1,126 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+// Maximum amount of time spent checking the health of all components on readiness checks.
+const readyTimeout = 5 * time.Second
+
+// A ReadyReport represents the readiness of the [Service] as a whole, as well as the status of each
+// gateway and destination currently running.
+type readyReport struct {
+	Ready        bool              `json:"ready"`
+	Gateways     []componentStatus `json:"gateways"`
+	Destinations []componentStatus `json:"destinations"`
+}
+
+// A ComponentStatus represents the health of a single gateway or destination, alongside the outcome
+// of the most recent operations for it.
+type componentStatus struct {
+	Name        string     `json:"name"`
+	Ready       bool       `json:"ready"`
+	Critical    bool       `json:"critical"`
+	Error       string     `json:"error,omitempty"`
+	Pending     *int       `json:"pending,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// HandleReady is an HTTP handler for readiness checks, returning the overall readiness of the
+// service. Requests fail with a '503 Service Unavailable' status if any gateway source, or any
+// destination marked as critical, is unhealthy. Requests carrying the configured admin token, if
+// any, are returned a JSON report on the health of all gateways and destinations currently running,
+// which is otherwise withheld, as it contains component names and error details.
+func (s *Service) handleReady() (string, http.HandlerFunc) {
+	return "GET /_ready", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		var report, status = s.ready(ctx), http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+
+		s.mu.RLock()
+		var token = s.adminToken
+		s.mu.RUnlock()
+
+		if !authorized(r, []byte(token)) {
+			writeJSON(w, status, map[string]bool{"ready": report.Ready})
+			return
+		}
+
+		writeJSON(w, status, report)
+	}
+}
+
+// Ready returns a [readyReport] for all gateways and destinations currently running. Destinations
+// are taken to be critical unless configured with 'critical = false'.
+func (s *Service) ready(ctx context.Context) readyReport {
+	s.mu.RLock()
+	var gateways, destinations, conf = s.gateway, s.destinations, s.destinationConf
+	s.mu.RUnlock()
+
+	var report = readyReport{Ready: true, Gateways: []componentStatus{}, Destinations: []componentStatus{}}
+	for _, g := range gateways {
+		var status = newComponentStatus(g.Name(), g.Healthy(ctx), true, g.Activity())
+		report.Ready = report.Ready && status.Ready
+		report.Gateways = append(report.Gateways, status)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(destinations)) {
+		var status = s.destinationStatus(ctx, name, destinations[name], conf[name])
+		report.Ready = report.Ready && (status.Ready || !status.Critical)
+		report.Destinations = append(report.Destinations, status)
+	}
+
+	return report
+}
+
+// DestinationStatus returns a [componentStatus] for the destination and configuration given, with
+// the number of entries pending delivery, where the destination is queued.
+func (s *Service) destinationStatus(ctx context.Context, name string, d gateway.Destination, conf map[string]any) componentStatus {
+	var err error
+	if c, ok := unwrap[gateway.HealthChecker](d); ok {
+		err = c.Healthy(ctx)
+	}
+
+	var activity gateway.Activity
+	var pending *int
+	if q := queueOf(d); q != nil {
+		n := q.Len()
+		activity, pending = q.Activity(), &n
+	}
+
+	var status = newComponentStatus(name, err, conf["critical"] != false, activity)
+	status.Pending = pending
+
+	return status
+}
+
+// NewComponentStatus returns a [componentStatus] for the component name, health-check error, and
+// [gateway.Activity] given.
+func newComponentStatus(name string, err error, critical bool, activity gateway.Activity) componentStatus {
+	var status = componentStatus{Name: name, Ready: err == nil, Critical: critical, LastError: activity.LastError}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	if !activity.LastSuccess.IsZero() {
+		status.LastSuccess = &activity.LastSuccess
+	}
+	if !activity.LastFailure.IsZero() {
+		status.LastFailure = &activity.LastFailure
+	}
+
+	return status
+}
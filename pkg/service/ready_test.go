@@ -0,0 +1,114 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+)
+
+type unhealthyDestination struct {
+	testDestination
+}
+
+func (d *unhealthyDestination) Healthy(context.Context) error {
+	return errors.New("connection lost")
+}
+
+func init() {
+	gateway.RegisterDestination("test-unhealthy", func() gateway.Destination { return &unhealthyDestination{} })
+}
+
+func TestServiceReady(t *testing.T) {
+	var testCases = []struct {
+		descr       string
+		destination map[string]any
+
+		status int
+		ready  bool
+	}{
+		{
+			descr:       "healthy destination",
+			destination: map[string]any{"type": "test"},
+			status:      http.StatusOK,
+			ready:       true,
+		},
+		{
+			descr:       "unhealthy critical destination",
+			destination: map[string]any{"type": "test-unhealthy"},
+			status:      http.StatusServiceUnavailable,
+		},
+		{
+			descr:       "unhealthy non-critical destination",
+			destination: map[string]any{"type": "test-unhealthy", "critical": false},
+			status:      http.StatusOK,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var h = &testHandler{http.NewServeMux()}
+			var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+			s, err := New(WithHandler(h), WithAdminToken("secret"), WithLogger(logger))
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			var data = map[string]any{
+				"destination": map[string]any{"shared": tt.destination},
+				"gateway":     []map[string]any{{"path": "/test", "source": map[string]any{"type": "test"}, "destination": "shared"}},
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if err := s.UnmarshalTOML(data); err != nil {
+				t.Fatalf("Service.UnmarshalTOML(): want error 'nil', have '%s'", err)
+			} else if err := s.Init(ctx); err != nil {
+				t.Fatalf("Service.Init(): want error 'nil', have '%s'", err)
+			}
+
+			// Requests without the admin token are only given overall readiness.
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/_ready", nil))
+			if w.Code != tt.status {
+				t.Fatalf("GET /_ready: want status '%d', have '%d'", tt.status, w.Code)
+			}
+
+			var want = fmt.Sprintf(`{"ready":%v}`, tt.status == http.StatusOK)
+			if have := strings.TrimSpace(w.Body.String()); have != want {
+				t.Fatalf("GET /_ready: want body '%s', have '%s'", want, have)
+			}
+
+			r := httptest.NewRequest("GET", "/_ready", nil)
+			r.Header.Set("Authorization", "Bearer secret")
+
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("GET /_ready: want status '%d', have '%d'", tt.status, w.Code)
+			}
+
+			var report readyReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("json.Decode(): want error 'nil', have '%s'", err)
+			} else if len(report.Gateways) != 1 || !report.Gateways[0].Ready {
+				t.Fatalf("GET /_ready: want single ready gateway, have '%+v'", report.Gateways)
+			} else if len(report.Destinations) != 1 || report.Destinations[0].Ready != tt.ready {
+				t.Fatalf("GET /_ready: want destination ready '%v', have '%+v'", tt.ready, report.Destinations)
+			} else if d := report.Destinations[0]; d.Pending == nil || *d.Pending != 0 {
+				t.Fatalf("GET /_ready: want no pending entries reported for destination, have '%v'", d.Pending)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/delivery"
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+	"go.deuill.org/webhook-gateway/pkg/trace"
+)
+
+// Maximum amount of time spent closing destinations and gateways no longer in use on reload.
+const reloadCloseTimeout = 30 * time.Second
+
+// Reload applies the configuration given, as sourced from TOML, to the running [Service], which is
+// expected to have been initialized with [Service.Init] beforehand. Configuration is validated in
+// full before being applied, and any errors will leave the running service unchanged.
+//
+// Destinations and gateways with unchanged configuration are carried over as-is, and keep any open
+// connections and internal state. Destinations with changed configuration are replaced, with new
+// destinations initialized before replacing existing ones, and with any messages pending delivery
+// moved over to new destinations of the same identity, i.e. of the same type and delivering to the
+// same remote endpoint. Messages pending delivery for destinations with no such replacement are
+// moved to dead letters, where a state directory is configured, rather than being delivered to
+// different recipients. Messages held by silences are moved over to replacement gateways of the
+// same name, or are released for gateways no longer configured. Destinations and gateways no longer
+// configured are stopped, and request handlers are replaced atomically, with requests already in
+// progress completing against the previous configuration. Changes to tracing configuration replace
+// the running tracer, which exports any spans left pending before being stopped.
+//
+// Changes to the state directory cannot be applied without a restart, and will return an error,
+// whereas changes to HTTP server and request history configuration are ignored.
+func (s *Service) Reload(ctx context.Context, data any) error {
+	next, err := New(WithLogger(s.logger))
+	if err != nil {
+		return err
+	}
+
+	// Share state between running and reloaded service, as persisted across restarts.
+	next.previous, next.stateDir = s, s.stateDir
+	next.adminSilences, next.alerts, next.history = s.adminSilences, s.alerts, s.history
+	if s.stateDir != "" {
+		next.deadLetters = s.getDeadLetters()
+	}
+
+	if err := next.UnmarshalTOML(data); err != nil {
+		return err
+	} else if next.stateDir != s.stateDir {
+		return fmt.Errorf("changes to state directory require a restart")
+	} else if len(next.gateway) == 0 {
+		return fmt.Errorf("no gateway configuration found")
+	}
+
+	if !reflect.DeepEqual(next.httpConf, s.httpConf) {
+		s.logger.Warn("Changes to HTTP server configuration require a restart, ignoring")
+	}
+	if !reflect.DeepEqual(next.historyConf, s.historyConf) {
+		s.logger.Warn("Changes to request history configuration require a restart, ignoring")
+	}
+
+	if h, ok := s.handler.(*HTTP); ok && !h.requestClientCert && slices.ContainsFunc(next.gateway, (*gateway.Gateway).RequiresClientCert) {
+		s.logger.Warn("Client certificates are not requested by the running HTTP server, gateways requiring these will reject all requests until restarted")
+	}
+
+	// Initialize any new destinations and gateways, stopping these if any fail to initialize.
+	var started = make(map[any]context.CancelFunc)
+	var abort = func() {
+		for _, stop := range started {
+			stop()
+		}
+	}
+
+	// Keep the running tracer where tracing configuration is unchanged.
+	if reflect.DeepEqual(next.tracingConf, s.tracingConf) {
+		next.tracer = s.tracer
+	} else if next.tracer != nil {
+		if err := start(ctx, started, next.tracer, next.tracer.Init); err != nil {
+			return fmt.Errorf("failed initializing tracing: %w", err)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(next.destinations)) {
+		d := next.destinations[name]
+		if s.owns(d) {
+			continue
+		} else if err := start(ctx, started, d, d.Init); err != nil {
+			abort()
+			return fmt.Errorf("failed initializing destination '%s': %w", name, err)
+		}
+	}
+
+	for _, g := range next.gateway {
+		if slices.Contains(s.gateway, g) {
+			continue
+		} else if err := start(ctx, started, g, g.Init); err != nil {
+			abort()
+			return fmt.Errorf("failed initializing gateway: %w", err)
+		}
+	}
+
+	router, err := next.routes()
+	if err != nil {
+		abort()
+		return err
+	}
+
+	// Move messages held by silences over to replacement gateways of the same name, and release any
+	// messages held by gateways left without a replacement, ahead of retiring their destinations.
+	for _, prev := range s.gateway {
+		if slices.Contains(next.gateway, prev) {
+			continue
+		} else if i := slices.IndexFunc(next.gateway, func(g *gateway.Gateway) bool {
+			return g.Name() == prev.Name() && !slices.Contains(s.gateway, g)
+		}); i >= 0 {
+			next.gateway[i].Adopt(prev)
+		} else {
+			prev.ReleaseAll()
+		}
+	}
+
+	// Hand spools over to replacement destinations of the same name, as spool files are named after
+	// destinations, and move pending messages over to replacement destinations delivering to the same
+	// remote endpoints as the destinations replaced. Destinations left without such a replacement are
+	// retired, with any pending messages moved to dead letters.
+	s.mu.Lock()
+	var names = slices.Sorted(maps.Keys(next.destinations))
+	for _, name := range names {
+		if q, prev := queueOf(next.destinations[name]), queueOf(s.destinations[name]); q != nil && prev != nil && q != prev {
+			q.TakeSpool(prev)
+		}
+	}
+
+	var adopted = make(map[*delivery.Queue]bool)
+	for _, name := range names {
+		if d := next.destinations[name]; s.owns(d) {
+			continue
+		} else if q, prev := queueOf(d), s.findReplaced(next, name, adopted); q != nil && prev != nil {
+			q.Adopt(prev)
+			adopted[prev] = true
+		}
+	}
+
+	var stale []any
+	for _, name := range slices.Sorted(maps.Keys(s.destinations)) {
+		d := s.destinations[name]
+		if next.owns(d) {
+			continue
+		} else if q := queueOf(d); q != nil && !adopted[q] {
+			q.Retire()
+		}
+		stale = append(stale, d)
+	}
+	for _, g := range s.gateway {
+		if !slices.Contains(next.gateway, g) {
+			stale = append(stale, g)
+		}
+	}
+	if s.tracer != nil && s.tracer != next.tracer {
+		stale = append(stale, s.tracer)
+	}
+
+	var stop = make(map[any]context.CancelFunc)
+	for _, v := range stale {
+		stop[v] = s.stop[v]
+		delete(s.stop, v)
+	}
+
+	maps.Copy(s.stop, started)
+	s.gateway, s.destinations, s.router = next.gateway, next.destinations, router
+	s.destinationConf, s.gatewayConf = next.destinationConf, next.gatewayConf
+	s.tracer, s.tracingConf = next.tracer, next.tracingConf
+	s.adminToken, s.dashboard = next.adminToken, next.dashboard
+	s.mu.Unlock()
+
+	trace.SetDefault(s.tracer)
+
+	if err := s.silences.Replace(next.silences); err != nil {
+		s.logger.Error("Failed replacing silences", "error", err.Error())
+	}
+
+	// Close and stop components no longer in use; messages pushed to replaced
+	// destinations after this point are forwarded to their replacements.
+	closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reloadCloseTimeout)
+	defer cancel()
+
+	for _, v := range stale {
+		if c, ok := v.(interface{ Close(context.Context) error }); ok {
+			if err := c.Close(closeCtx); err != nil {
+				s.logger.Warn("Failed closing stale component", "error", err.Error())
+			}
+		}
+	}
+
+	for _, fn := range stop {
+		if fn != nil {
+			fn()
+		}
+	}
+
+	s.logger.Info("Reloaded configuration", "gateways", len(next.gateway), "destinations", len(next.destinations),
+		"started", len(started), "stopped", len(stop))
+
+	return nil
+}
+
+// Owns returns whether the given [gateway.Destination] is owned by the [Service]. Nil services are
+// taken to own no destinations.
+func (s *Service) owns(d gateway.Destination) bool {
+	if s == nil {
+		return false
+	}
+
+	for _, v := range s.destinations {
+		if v == d {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindReplaced returns the [delivery.Queue] for the destination in the running [Service] replaced by
+// the destination of the name given in the next service, if any. Destinations are replaced by ones
+// of the same identity, as determined by [sameIdentity], with any destination of the same name
+// preferred, and excluding destinations carried over to the next service, or already taken.
+func (s *Service) findReplaced(next *Service, name string, taken map[*delivery.Queue]bool) *delivery.Queue {
+	var conf = next.destinationConf[name]
+	if conf == nil {
+		return nil
+	}
+
+	var names = slices.Sorted(maps.Keys(s.destinations))
+	if _, ok := s.destinations[name]; ok {
+		names = append([]string{name}, names...)
+	}
+
+	for _, n := range names {
+		d := s.destinations[n]
+		if q := queueOf(d); q != nil && !taken[q] && !next.owns(d) && sameIdentity(s.destinationConf[n], conf) {
+			return q
+		}
+	}
+
+	return nil
+}
+
+// Options in destination configuration applying to delivery rather than to the destination itself,
+// and which can be changed without changing the identity of the destination.
+var deliveryOptions = []string{"queue", "rate-limit", "batch", "critical"}
+
+// SameIdentity returns whether the destination configurations given refer to the same destination,
+// i.e. are of the same type and deliver to the same remote endpoint with the same options, differing
+// only in delivery options, such as for queueing and rate-limiting.
+func sameIdentity(a, b map[string]any) bool {
+	if a == nil || b == nil {
+		return false
+	}
+
+	a, b = maps.Clone(a), maps.Clone(b)
+	for _, k := range deliveryOptions {
+		delete(a, k)
+		delete(b, k)
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// FindGateway returns the [gateway.Gateway] with the configuration given, excluding any gateways
+// already taken, if any.
+func (s *Service) findGateway(conf map[string]any, taken []*gateway.Gateway) *gateway.Gateway {
+	for _, g := range s.gateway {
+		if !slices.Contains(taken, g) && reflect.DeepEqual(s.gatewayConf[g], conf) {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// QueueOf returns the [delivery.Queue] wrapped by the value given, if any.
+func queueOf(v any) *delivery.Queue {
+	q, _ := unwrap[*delivery.Queue](v)
+	return q
+}
+
+// Unwrap returns the first value of type T found in the chain of destinations wrapped by the value
+// given, including the value itself, if any.
+func unwrap[T any](v any) (T, bool) {
+	for {
+		if t, ok := v.(T); ok {
+			return t, true
+		} else if d, ok := v.(interface{ Destination() gateway.Destination }); ok {
+			v = d.Destination()
+		} else {
+			var zero T
+			return zero, false
+		}
+	}
+}
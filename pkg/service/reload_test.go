@@ -0,0 +1,189 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+
+	// Third-party packages.
+	"github.com/BurntSushi/toml"
+)
+
+type testSource struct{}
+
+func (testSource) ParseHTTP(*http.Request) ([]*gateway.Message, error) {
+	return []*gateway.Message{{Content: "Hello"}}, nil
+}
+
+func (testSource) Init(context.Context) error  { return nil }
+func (testSource) Close(context.Context) error { return nil }
+
+func init() {
+	gateway.RegisterSource("test", func() gateway.Source { return testSource{} })
+}
+
+func TestServiceReload(t *testing.T) {
+	var h = &testHandler{http.NewServeMux()}
+	s, err := New(WithHandler(h), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	decode := func(data string) map[string]any {
+		var conf map[string]any
+		if _, err := toml.Decode(data, &conf); err != nil {
+			t.Fatalf("toml.Decode(): want error 'nil', have '%s'", err)
+		}
+		return conf
+	}
+
+	request := func(path string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader("{}")))
+		return w.Code
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err = s.UnmarshalTOML(decode(`
+		[destination.a]
+		type = "test"
+
+		[destination.b]
+		type = "test"
+
+		[[gateway]]
+		path = "/one"
+		source.type = "test"
+		destination = "a"
+
+		[[gateway]]
+		path = "/two"
+		source.type = "test"
+		destination = "b"
+
+		[[gateway]]
+		path = "/three"
+		source.type = "test"
+		destination.type = "test"
+	`))
+	if err != nil {
+		t.Fatalf("Service.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	} else if err := s.Init(ctx); err != nil {
+		t.Fatalf("Service.Init(): want error 'nil', have '%s'", err)
+	}
+
+	var gateways, destinations = s.gateway, s.destinations
+
+	// Invalid configuration leaves the running service unchanged.
+	err = s.Reload(ctx, decode(`
+		[destination.a]
+		type = "unknown"
+	`))
+	if err == nil {
+		t.Fatalf("Service.Reload(): want error for invalid configuration, have 'nil'")
+	} else if s.destinations["a"] != destinations["a"] || len(s.gateway) != 3 {
+		t.Fatalf("Service.Reload(): want running configuration unchanged, have '%v'", s.destinations)
+	}
+
+	// Apply configuration with unchanged, changed, and removed destinations and gateways.
+	err = s.Reload(ctx, decode(`
+		[destination.a]
+		type = "test"
+
+		[destination.b]
+		type = "test"
+		queue.size = 10
+
+		[[gateway]]
+		path = "/one"
+		source.type = "test"
+		destination = "a"
+
+		[[gateway]]
+		path = "/two"
+		source.type = "test"
+		destination = "b"
+
+		[[gateway]]
+		path = "/four"
+		source.type = "test"
+		destination = "a"
+	`))
+	if err != nil {
+		t.Fatalf("Service.Reload(): want error 'nil', have '%s'", err)
+	}
+
+	if s.destinations["a"] != destinations["a"] {
+		t.Fatalf("Service.Reload(): want unchanged destination reused, have new destination")
+	} else if s.destinations["b"] == destinations["b"] {
+		t.Fatalf("Service.Reload(): want changed destination replaced, have previous destination")
+	} else if slices.ContainsFunc(slices.Collect(maps.Keys(s.destinations)), func(name string) bool { return strings.HasPrefix(name, "/three/") }) {
+		t.Fatalf("Service.Reload(): want removed destination stopped, have destination")
+	} else if s.gateway[0] != gateways[0] {
+		t.Fatalf("Service.Reload(): want unchanged gateway reused, have new gateway")
+	} else if s.gateway[1] == gateways[1] {
+		t.Fatalf("Service.Reload(): want gateway with changed destination replaced, have previous gateway")
+	}
+
+	for path, want := range map[string]int{"/one": http.StatusAccepted, "/two": http.StatusAccepted, "/three": http.StatusNotFound, "/four": http.StatusAccepted} {
+		if have := request(path); have != want {
+			t.Fatalf("POST %s: want status '%d', have '%d'", path, want, have)
+		}
+	}
+}
+
+func TestSameIdentity(t *testing.T) {
+	var testCases = []struct {
+		descr string
+		a, b  map[string]any
+		want  bool
+	}{
+		{
+			descr: "identical configuration",
+			a:     map[string]any{"type": "xmpp", "jid": "bot@example.com", "recipients": []any{"alice@example.com"}},
+			b:     map[string]any{"type": "xmpp", "jid": "bot@example.com", "recipients": []any{"alice@example.com"}},
+			want:  true,
+		},
+		{
+			descr: "changed delivery options",
+			a:     map[string]any{"type": "xmpp", "jid": "bot@example.com", "queue": map[string]any{"size": int64(10)}},
+			b:     map[string]any{"type": "xmpp", "jid": "bot@example.com", "rate-limit": map[string]any{"rate": 1.0}, "critical": false},
+			want:  true,
+		},
+		{
+			descr: "changed account",
+			a:     map[string]any{"type": "xmpp", "jid": "bot@example.com"},
+			b:     map[string]any{"type": "xmpp", "jid": "other@example.com"},
+		},
+		{
+			descr: "changed recipients",
+			a:     map[string]any{"type": "xmpp", "jid": "bot@example.com", "recipients": []any{"alice@example.com"}},
+			b:     map[string]any{"type": "xmpp", "jid": "bot@example.com", "recipients": []any{"bob@example.com"}},
+		},
+		{
+			descr: "missing configuration",
+			a:     nil,
+			b:     nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			if have := sameIdentity(tt.a, tt.b); have != tt.want {
+				t.Fatalf("sameIdentity(): want '%v', have '%v'", tt.want, have)
+			}
+		})
+	}
+}
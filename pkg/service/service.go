@@ -3,33 +3,80 @@ package service
 import (
 	// Standard library.
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
 
 	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/delivery"
 	"go.deuill.org/webhook-gateway/pkg/gateway"
+	"go.deuill.org/webhook-gateway/pkg/trace"
 )
 
+// Maximum amount of time spent pushing each dead letter on replay, including any time spent waiting
+// for destinations to connect.
+const replayTimeout = 30 * time.Second
+
 // A Handler represents any type that's capable of attaching a given [http.HandlerFunc] against a
 // specific path to server-wide request processing.
 type Handler interface {
 	Handle(string, http.HandlerFunc) error
 	Init(context.Context) error
+	Close(context.Context) error
 }
 
 // A Service represents an abstract collection of [gateway.Gateway] configurations, against a request
-// [Handler] used for fulfilling incoming requests.
+// [Handler] used for fulfilling incoming requests. Services can also hold named [gateway.Destination]
+// instances, which are shared between gateways referring to them.
 type Service struct {
-	gateway []*gateway.Gateway
-	handler Handler
-	logger  *slog.Logger
+	gateway      []*gateway.Gateway
+	destinations map[string]gateway.Destination
+	handler      Handler
+	silences     *gateway.Silences
+	stateDir     string
+	adminToken   string
+	dashboard    bool
+
+	// Internal fields.
+	adminSilences   *gateway.Silences
+	alerts          *gateway.AlertTracker
+	deadLetters     *delivery.DeadLetters
+	mu              sync.RWMutex
+	router          *http.ServeMux                      // Request handlers, as replaced on reloads.
+	stop            map[any]context.CancelFunc          // Stop functions for initialized destinations and gateways.
+	destinationConf map[string]map[string]any           // Configuration for destinations, keyed by name.
+	gatewayConf     map[*gateway.Gateway]map[string]any // Configuration for gateways.
+	httpConf        map[string]any                      // Configuration for the HTTP server.
+	tracingConf     map[string]any                      // Configuration for tracing, if enabled.
+	tracer          *trace.Tracer                       // The tracer exporting spans, if enabled.
+	historyConf     map[string]any                      // Configuration for request history, if enabled.
+	history         *gateway.History                    // The request history store, if enabled.
+	previous        *Service                            // The running service, when parsing configuration for reloads.
+	logger          *slog.Logger
 }
 
 // New instantiates an instance of a [Service], for the options given.
 func New(options ...Option) (*Service, error) {
 	var s = Service{
-		logger: slog.Default(),
+		destinations:    make(map[string]gateway.Destination),
+		silences:        gateway.NewSilences(),
+		adminSilences:   gateway.NewSilences(),
+		alerts:          gateway.NewAlertTracker(0),
+		stop:            make(map[any]context.CancelFunc),
+		destinationConf: make(map[string]map[string]any),
+		gatewayConf:     make(map[*gateway.Gateway]map[string]any),
+		logger:          slog.Default(),
 	}
 
 	for _, fn := range options {
@@ -61,6 +108,29 @@ func WithGateway(w *gateway.Gateway) Option {
 	}
 }
 
+// WithDestination adds the given [gateway.Destination] to the list of shared destinations, under the
+// name given. Shared destinations are initialized once, and can be referenced by name in gateway
+// configuration; see [gateway.WithDestinationLookup] for more information.
+func WithDestination(name string, d gateway.Destination) Option {
+	return func(s *Service) error {
+		if _, ok := s.destinations[name]; ok {
+			return fmt.Errorf("destination with name '%s' already exists", name)
+		}
+		s.destinations[name] = d
+		return nil
+	}
+}
+
+// WithStateDir sets the directory used for storing persistent state, such as messages pending
+// delivery, or messages that failed delivery. If no state directory is set, no state will be
+// persisted across restarts.
+func WithStateDir(dir string) Option {
+	return func(s *Service) error {
+		s.stateDir = dir
+		return nil
+	}
+}
+
 // WithLogger sets the given [slog.Logger] as the log handler for the service and other downstream
 // dependencies.
 func WithLogger(l *slog.Logger) Option {
@@ -71,8 +141,14 @@ func WithLogger(l *slog.Logger) Option {
 }
 
 // Init ensures the [Service] is configured correctly, and initializes any sub-resources necessary
-// for its operation. Specifically, any attached [gateway.Gateway] and [Handler] instances will have
-// their 'Init' functions called, with any errors being returned immediately.
+// for its operation. Specifically, any shared [gateway.Destination] instances, as well as attached
+// [gateway.Gateway] and [Handler] instances will have their 'Init' functions called, with any errors
+// being returned immediately.
+//
+// Destinations and gateways are initialized with contexts of their own, derived from the context
+// given, allowing for these to be stopped independently of one another on configuration reloads.
+// Requests are dispatched via a single request handler, which allows for request handlers to be
+// replaced atomically on reloads; see [Service.Reload] for more information.
 func (s *Service) Init(ctx context.Context) error {
 	if s.handler == nil {
 		return fmt.Errorf("no request handler configuration found")
@@ -80,26 +156,170 @@ func (s *Service) Init(ctx context.Context) error {
 		return fmt.Errorf("no gateway configuration found")
 	}
 
-	// Set up request handlers.
-	if err := s.handler.Handle(s.handleHealth()); err != nil {
-		return fmt.Errorf("failed setting up request handler for health-checks: %w", err)
+	// Load silences created via the admin API, as well as alert state, which are persisted across
+	// restarts.
+	if s.stateDir != "" {
+		if err := s.adminSilences.Load(filepath.Join(s.stateDir, "silences.json")); err != nil {
+			return err
+		} else if err := s.alerts.Load(filepath.Join(s.stateDir, "alerts.json")); err != nil {
+			return err
+		}
+	}
+
+	// Load request history persisted across restarts, if enabled.
+	if s.history != nil {
+		if persist, _ := s.historyConf["persist"].(bool); persist {
+			if err := s.history.Load(filepath.Join(s.stateDir, "history.log")); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Set up tracing ahead of any components producing spans.
+	if s.tracer != nil {
+		if err := start(ctx, s.stop, s.tracer, s.tracer.Init); err != nil {
+			return fmt.Errorf("failed initializing tracing: %w", err)
+		}
+		trace.SetDefault(s.tracer)
+	}
+
+	// Initialize shared destinations ahead of any gateways referring to them.
+	for _, name := range slices.Sorted(maps.Keys(s.destinations)) {
+		d := s.destinations[name]
+		if err := start(ctx, s.stop, d, d.Init); err != nil {
+			return fmt.Errorf("failed initializing destination '%s': %w", name, err)
+		}
 	}
 
 	for _, g := range s.gateway {
-		if err := g.Init(ctx); err != nil {
+		if err := start(ctx, s.stop, g, g.Init); err != nil {
 			return fmt.Errorf("failed initializing gateway: %w", err)
-		} else if err = s.handler.Handle(g.HandleHTTP()); err != nil {
-			return fmt.Errorf("failed setting up request handler for gateway: %w", err)
 		}
 	}
 
-	if err := s.handler.Init(ctx); err != nil {
+	// Set up request handlers.
+	router, err := s.routes()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.router = router
+	s.mu.Unlock()
+
+	if err := s.handler.Handle("/", s.serveHTTP); err != nil {
+		return fmt.Errorf("failed setting up request handler: %w", err)
+	} else if err := s.handler.Init(ctx); err != nil {
 		return fmt.Errorf("failed initializing request handler: %w", err)
 	}
 
 	return nil
 }
 
+// Close stops the [Service] gracefully, returning once done, or once the given [context.Context] is
+// cancelled. The request handler is closed first, waiting for requests in progress to complete,
+// followed by gateways, which release any messages held, destinations, which flush any pending
+// messages and close connections to remote endpoints, the request history, if any, and finally the
+// tracer, if any, which exports any spans left pending. Any errors encountered are returned together.
+func (s *Service) Close(ctx context.Context) error {
+	var errs []error
+	if s.handler != nil {
+		if err := s.handler.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed closing request handler: %w", err))
+		}
+	}
+
+	s.mu.Lock()
+	var gateways, destinations, tracer = s.gateway, s.destinations, s.tracer
+	s.mu.Unlock()
+
+	for _, g := range gateways {
+		if err := g.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed closing gateway: %w", err))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(destinations)) {
+		if err := destinations[name].Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed closing destination '%s': %w", name, err))
+		}
+	}
+
+	if s.history != nil {
+		if err := s.history.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed closing request history: %w", err))
+		}
+	}
+
+	if tracer != nil {
+		if trace.Default() == tracer {
+			trace.SetDefault(nil)
+		}
+		if err := tracer.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed closing tracer: %w", err))
+		}
+	}
+
+	s.mu.Lock()
+	for key, stop := range s.stop {
+		stop()
+		delete(s.stop, key)
+	}
+	s.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// Routes returns a [http.ServeMux] containing request handlers for health and readiness checks,
+// alert state, metrics, the admin API and dashboard, and all gateways configured for the [Service].
+// Gateways are expected to have been initialized beforehand.
+func (s *Service) routes() (*http.ServeMux, error) {
+	var mux = serveMux{http.NewServeMux()}
+	if err := mux.Handle(s.handleHealth()); err != nil {
+		return nil, fmt.Errorf("failed setting up request handler for health-checks: %w", err)
+	} else if err := mux.Handle(s.handleAlerts()); err != nil {
+		return nil, fmt.Errorf("failed setting up request handler for alerts: %w", err)
+	} else if err := mux.Handle(s.handleReady()); err != nil {
+		return nil, fmt.Errorf("failed setting up request handler for readiness checks: %w", err)
+	} else if err := mux.Handle(s.handleMetrics()); err != nil {
+		return nil, fmt.Errorf("failed setting up request handler for metrics: %w", err)
+	} else if err := s.handleAdmin(mux); err != nil {
+		return nil, fmt.Errorf("failed setting up request handlers for admin API: %w", err)
+	} else if err := s.handleDashboard(mux); err != nil {
+		return nil, fmt.Errorf("failed setting up request handlers for dashboard: %w", err)
+	}
+
+	for _, g := range s.gateway {
+		if err := mux.Handle(g.HandleHTTP()); err != nil {
+			return nil, fmt.Errorf("failed setting up request handler for gateway: %w", err)
+		}
+	}
+
+	return mux.ServeMux, nil
+}
+
+// ServeHTTP dispatches the given request to the request handlers currently in effect.
+func (s *Service) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	router := s.router
+	s.mu.RUnlock()
+
+	router.ServeHTTP(w, r)
+}
+
+// Start initializes a component under a context of its own, derived from the context given, and
+// records the function stopping the component under the key given.
+func start(ctx context.Context, stop map[any]context.CancelFunc, key any, init func(context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	if err := init(ctx); err != nil {
+		cancel()
+		return err
+	}
+
+	stop[key] = cancel
+	return nil
+}
+
 // UnmarshalTOML configures the [Service] based on values sourced from TOML configuration.
 func (s *Service) UnmarshalTOML(data any) error {
 	conf, ok := data.(map[string]any)
@@ -107,9 +327,14 @@ func (s *Service) UnmarshalTOML(data any) error {
 		return fmt.Errorf("no valid configuration keys found")
 	}
 
+	if v, ok := conf["state-dir"].(string); ok {
+		s.stateDir = v
+	}
+
 	// Process configuration for HTTP server.
 	if v, ok := conf["http"].(map[string]any); ok {
-		var options []HTTPOption
+		s.httpConf = v
+		var options = []HTTPOption{WithHTTPLogger(s.logger)}
 		if host, ok := v["host"].(string); ok {
 			options = append(options, WithHTTPHost(host))
 		}
@@ -117,6 +342,25 @@ func (s *Service) UnmarshalTOML(data any) error {
 			options = append(options, WithHTTPPort(port))
 		}
 
+		cert, _ := v["certificate"].(string)
+		key, _ := v["key"].(string)
+		if (cert == "") != (key == "") {
+			return fmt.Errorf("both certificate and key are required for HTTPS in HTTP server configuration")
+		} else if cert != "" {
+			options = append(options, WithHTTPCertificate(cert, key))
+		}
+
+		if ca, ok := v["client-ca"].(string); ok && ca != "" {
+			if cert == "" {
+				return fmt.Errorf("client CA requires certificate and key in HTTP server configuration")
+			}
+			pool, err := gateway.LoadCertPool(ca, s.logger)
+			if err != nil {
+				return fmt.Errorf("failed loading client CA for HTTP server: %w", err)
+			}
+			options = append(options, WithHTTPClientCA(pool))
+		}
+
 		h, err := NewHTTP(options...)
 		if err != nil {
 			return fmt.Errorf("failed initializing HTTP server: %w", err)
@@ -125,23 +369,354 @@ func (s *Service) UnmarshalTOML(data any) error {
 		s.handler = h
 	}
 
-	// Process configuration for gateways.
+	// Process configuration for tracing, which is only enabled if a collector endpoint is set.
+	if v, ok := conf["tracing"].(map[string]any); ok {
+		t, err := s.newTracer(v)
+		if err != nil {
+			return fmt.Errorf("failed parsing tracing configuration: %w", err)
+		}
+		s.tracer, s.tracingConf = t, v
+	}
+
+	// Process configuration for request history, which is shared with the running service, when
+	// parsing configuration for reloads.
+	if v, ok := conf["history"].(map[string]any); ok {
+		h, err := s.newHistory(v)
+		if err != nil {
+			return fmt.Errorf("failed parsing history configuration: %w", err)
+		} else if s.previous == nil {
+			s.history = h
+		}
+		s.historyConf = v
+	}
+
+	// Process configuration for admin API, which is only enabled if a token is set, as is the
+	// dashboard.
+	if v, ok := conf["admin"].(map[string]any); ok {
+		if token, ok := v["token"].(string); ok {
+			s.adminToken = token
+		}
+		switch d := v["dashboard"].(type) {
+		case nil:
+		case bool:
+			if d && s.adminToken == "" {
+				return fmt.Errorf("dashboard requires an admin token to be set")
+			}
+			s.dashboard = d
+		default:
+			return fmt.Errorf("invalid value '%v' for 'dashboard', expected boolean", d)
+		}
+	}
+
+	// Process configuration for shared destinations, which need to be set up before any gateways
+	// referring to them.
+	if v, ok := conf["destination"].(map[string]any); ok {
+		for name, v := range v {
+			v, ok := v.(map[string]any)
+			if !ok {
+				return fmt.Errorf("invalid configuration for destination '%s'", name)
+			}
+
+			if _, err := s.newDestination(name, v); err != nil {
+				return fmt.Errorf("failed parsing configuration for destination '%s': %w", name, err)
+			}
+		}
+	}
+
+	// Process configuration for silences, which apply to all gateways.
+	if v, ok := conf["silence"].([]map[string]any); ok {
+		for i := range v {
+			silence, err := gateway.ParseSilence(v[i])
+			if err != nil {
+				return fmt.Errorf("failed parsing configuration for silence %d: %w", i+1, err)
+			} else if err = s.silences.Add(silence); err != nil {
+				return fmt.Errorf("failed adding silence %d: %w", i+1, err)
+			}
+		}
+	}
+
+	// Process configuration for gateways. Any destinations defined inline for gateways are owned by
+	// the service, and are named after the gateway they belong to and their configuration. Silences
+	// shared between gateways are held by the running service, when parsing configuration for reloads.
+	var silences = s.silences
+	if s.previous != nil {
+		silences = s.previous.silences
+	}
+
 	if v, ok := conf["gateway"].([]map[string]any); ok {
 		for i := range v {
-			g, err := gateway.New(gateway.WithLogger(s.logger))
+			// Track whether the gateway refers to any destinations not carried over from the running
+			// service, if any, in which case the gateway cannot be reused.
+			var g *gateway.Gateway
+			var err error
+			var changed = s.previous == nil
+			factory := func(conf map[string]any) (string, gateway.Destination, error) {
+				name, err := inlineName(g.Name(), conf)
+				if err != nil {
+					return "", nil, err
+				} else if _, ok := s.destinations[name]; ok {
+					return "", nil, fmt.Errorf("duplicate destination '%s' in gateway configuration", name)
+				}
+				d, err := s.newDestination(name, conf)
+				changed = changed || err != nil || !s.previous.owns(d)
+				return name, d, err
+			}
+
+			lookup := func(name string) (gateway.Destination, bool) {
+				d, ok := s.lookupDestination(name)
+				changed = changed || !ok || !s.previous.owns(d)
+				return d, ok
+			}
+
+			g, err = gateway.New(
+				gateway.WithLogger(s.logger),
+				gateway.WithStateDir(s.stateDir),
+				gateway.WithSilences(silences),
+				gateway.WithSilences(s.adminSilences),
+				gateway.WithAlertTracker(s.alerts),
+				gateway.WithHistory(s.history),
+				gateway.WithDestinationLookup(lookup),
+				gateway.WithDestinationFactory(factory),
+			)
 			if err != nil {
 				return fmt.Errorf("failed initializing gateway: %w", err)
 			} else if err := g.UnmarshalTOML(v[i]); err != nil {
 				return fmt.Errorf("failed parsing gateway configuration: %w", err)
 			}
 
+			// Reuse gateways from the running service where configuration is unchanged.
+			if !changed {
+				if prev := s.previous.findGateway(v[i], s.gateway); prev != nil {
+					g = prev
+				}
+			}
+
 			s.gateway = append(s.gateway, g)
+			s.gatewayConf[g] = v[i]
 		}
 	}
 
+	// Request client certificates for verification by gateways requiring these.
+	if h, ok := s.handler.(*HTTP); ok && slices.ContainsFunc(s.gateway, (*gateway.Gateway).RequiresClientCert) {
+		if h.certificate == nil {
+			s.logger.Warn("Gateways requiring client certificates will reject all requests, as HTTPS is not configured")
+		}
+		h.requestClientCert = true
+	}
+
 	return nil
 }
 
+// InlineName returns the name for a destination defined inline in the configuration of the gateway
+// named, as derived from the gateway name, the destination type, and a hash of the destination
+// configuration. Names are thus stable across changes to other parts of the configuration, such as
+// the order of gateways, and are used for naming spool files and dead letters, among others.
+func inlineName(gatewayName string, conf map[string]any) (string, error) {
+	buf, err := json.Marshal(conf)
+	if err != nil {
+		return "", fmt.Errorf("failed encoding destination configuration: %w", err)
+	}
+
+	typ, _ := conf["type"].(string)
+	sum := sha256.Sum256(buf)
+	return gatewayName + "/" + typ + "-" + hex.EncodeToString(sum[:4]), nil
+}
+
+// NewDestination instantiates a [gateway.Destination] for the configuration given, and adds it to
+// the list of destinations owned by the service under the name given. Destinations are wrapped in a
+// [delivery.Queue], which handles asynchronous delivery and retries, and which can be configured via
+// the 'queue' table in the destination configuration. If a state directory is configured, queued
+// messages are also spooled to disk, under a file named after the destination, and messages that
+// fail delivery are stored as dead letters, for later replay. Outgoing messages can be rate-limited
+// via the 'rate-limit' table, with messages exceeding the limit held in the queue. Destinations with
+// a 'batch' table in their configuration have queued messages collected into digests by a
+// [delivery.Batcher]. Destinations are taken to be critical for readiness checks, unless 'critical'
+// is set to false.
+//
+// When parsing configuration for reloads, destinations with configuration identical to that of the
+// running service are reused as-is. Queues for destinations replacing existing ones of the same name
+// are set up to take over the spool of the existing queue, rather than opening the spool file
+// themselves.
+func (s *Service) newDestination(name string, conf map[string]any) (gateway.Destination, error) {
+	if p := s.previous; p != nil {
+		if d, ok := p.destinations[name]; ok && reflect.DeepEqual(p.destinationConf[name], conf) {
+			if err := WithDestination(name, d)(s); err != nil {
+				return nil, err
+			}
+			s.destinationConf[name] = conf
+			return d, nil
+		}
+	}
+
+	d, err := gateway.NewDestination(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var options = []delivery.Option{delivery.WithLogger(s.logger), delivery.WithHistory(s.history)}
+	if s.stateDir != "" {
+		if s.previous == nil || s.previous.destinations[name] == nil {
+			path := filepath.Join(s.stateDir, "spool", url.PathEscape(name)+".log")
+			options = append(options, delivery.WithSpool(delivery.NewSpool(path)))
+		}
+		options = append(options, delivery.WithDeadLetters(s.getDeadLetters()))
+	}
+
+	if v, ok := conf["critical"]; ok {
+		if _, ok := v.(bool); !ok {
+			return nil, fmt.Errorf("invalid value '%v' for 'critical', expected boolean", v)
+		}
+	}
+
+	if v, ok := conf["rate-limit"]; ok {
+		limit, err := gateway.ParseRateLimit(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing rate limit configuration: %w", err)
+		}
+		options = append(options, delivery.WithRateLimit(limit))
+	}
+
+	if v, ok := conf["batch"]; ok {
+		b, err := delivery.NewBatcher()
+		if err != nil {
+			return nil, err
+		} else if err = b.UnmarshalTOML(v); err != nil {
+			return nil, fmt.Errorf("failed parsing batch configuration: %w", err)
+		}
+		options = append(options, delivery.WithBatcher(b))
+	}
+
+	q, err := delivery.New(name, d, options...)
+	if err != nil {
+		return nil, err
+	} else if err = q.UnmarshalTOML(conf["queue"]); err != nil {
+		return nil, fmt.Errorf("failed parsing queue configuration: %w", err)
+	}
+
+	if err = WithDestination(name, q)(s); err != nil {
+		return nil, err
+	}
+
+	s.destinationConf[name] = conf
+	return q, nil
+}
+
+// DeadLetters returns all messages that failed delivery, as stored in the dead-letter store. An
+// error is returned if no state directory has been configured.
+func (s *Service) DeadLetters() ([]*delivery.DeadLetter, error) {
+	if s.stateDir == "" {
+		return nil, fmt.Errorf("no state directory configured")
+	}
+	return s.getDeadLetters().List()
+}
+
+// Replay re-submits dead letters matching the given function to the destinations they were
+// originally destined for, removing them from the dead-letter store on successful delivery. Only
+// destinations referred to by dead letters will be initialized, and messages are pushed to these
+// directly, bypassing any delivery queues; destinations failing to initialize are not retried for
+// further dead letters. The dead-letter store is compacted once any dead letters are replayed. The
+// number of dead letters successfully replayed is returned, along with any errors encountered.
+func (s *Service) Replay(ctx context.Context, match func(*delivery.DeadLetter) bool) (int, error) {
+	letters, err := s.DeadLetters()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	var errs []error
+	var initialized = make(map[string]gateway.Destination)
+	var failed = make(map[string]error)
+
+	for _, l := range letters {
+		if !match(l) {
+			continue
+		} else if err, ok := failed[l.Destination]; ok {
+			errs = append(errs, fmt.Errorf("failed replaying dead letter '%s': %w", l.ID, err))
+			continue
+		}
+
+		d, ok := initialized[l.Destination]
+		if !ok {
+			if d, ok = s.destinations[l.Destination]; !ok {
+				errs = append(errs, fmt.Errorf("unknown destination '%s' for dead letter '%s'", l.Destination, l.ID))
+				continue
+			}
+
+			// Unwrap any batching and queueing, delivering messages directly.
+			for {
+				w, ok := d.(interface{ Destination() gateway.Destination })
+				if !ok {
+					break
+				}
+				d = w.Destination()
+			}
+
+			if err := d.Init(ctx); err != nil {
+				failed[l.Destination] = fmt.Errorf("failed initializing destination '%s': %w", l.Destination, err)
+				errs = append(errs, failed[l.Destination])
+				continue
+			}
+
+			defer d.Close(ctx) //nolint:errcheck // Errors in closing destinations do not affect replay.
+			initialized[l.Destination] = d
+		}
+
+		pushCtx, cancel := context.WithTimeout(gateway.SetRequestID(gateway.SetPath(ctx, l.Gateway), l.RequestID), replayTimeout)
+		err = d.PushMessages(pushCtx, l.Messages...)
+		cancel()
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed replaying dead letter '%s': %w", l.ID, err))
+			continue
+		} else if err = s.getDeadLetters().Remove(l.ID); err != nil {
+			errs = append(errs, err)
+		}
+
+		s.logger.Info("Replayed dead letter", "id", l.ID, "destination", l.Destination, "gateway", l.Gateway)
+		count++
+	}
+
+	if count > 0 {
+		if err := s.getDeadLetters().Compact(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return count, errors.Join(errs...)
+}
+
+// GetDeadLetters returns the dead-letter store for the service, as located in the state directory.
+func (s *Service) getDeadLetters() *delivery.DeadLetters {
+	if s.deadLetters == nil {
+		s.deadLetters = delivery.NewDeadLetters(filepath.Join(s.stateDir, "dead-letters.log"))
+	}
+	return s.deadLetters
+}
+
+// LookupDestination returns the shared destination for the name given, if any.
+func (s *Service) lookupDestination(name string) (gateway.Destination, bool) {
+	d, ok := s.destinations[name]
+	return d, ok
+}
+
+// HandleAlerts is an HTTP handler returning the state of all alerts currently tracked, as a JSON
+// array. Requests require the admin token, if one is configured.
+func (s *Service) handleAlerts() (string, http.HandlerFunc) {
+	var h = func(w http.ResponseWriter, _ *http.Request) {
+		var alerts = s.alerts.List()
+		if alerts == nil {
+			alerts = []*gateway.Alert{}
+		}
+		writeJSON(w, http.StatusOK, alerts)
+	}
+
+	if s.adminToken != "" {
+		h = s.authorize(h)
+	}
+
+	return "GET /_alerts", h
+}
+
 // HandleHealth is an HTTP handler for health-checks.
 func (s *Service) handleHealth() (string, http.HandlerFunc) {
 	return "/_health", func(w http.ResponseWriter, _ *http.Request) {
@@ -0,0 +1,300 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/delivery"
+	"go.deuill.org/webhook-gateway/pkg/gateway"
+
+	// Third-party packages.
+	"github.com/BurntSushi/toml"
+)
+
+type testDestination struct {
+	messages []*gateway.Message
+	closed   bool
+}
+
+func (d *testDestination) PushMessages(_ context.Context, messages ...*gateway.Message) error {
+	d.messages = append(d.messages, messages...)
+	return nil
+}
+
+func (d *testDestination) Init(context.Context) error  { return nil }
+func (d *testDestination) Close(context.Context) error { d.closed = true; return nil }
+
+func init() {
+	gateway.RegisterDestination("test", func() gateway.Destination { return &testDestination{} })
+}
+
+func TestServiceUnmarshalTOML(t *testing.T) {
+	var testCases = []struct {
+		descr string
+		data  string
+
+		gateways     int
+		destinations int
+		err          error
+	}{
+		{
+			descr: "gateway with inline destination",
+			data: `
+				[[gateway]]
+				path = "/test"
+				destination.type = "test"
+			`,
+			gateways:     1,
+			destinations: 1,
+		},
+		{
+			descr: "gateways with shared destination",
+			data: `
+				[destination.shared]
+				type = "test"
+
+				[[gateway]]
+				path = "/test-1"
+				destination = "shared"
+
+				[[gateway]]
+				path = "/test-2"
+				destination = ["shared"]
+			`,
+			gateways:     2,
+			destinations: 1,
+		},
+		{
+			descr: "gateways with identical inline destinations",
+			data: `
+				[[gateway]]
+				path = "/test-1"
+				destination.type = "test"
+
+				[[gateway]]
+				path = "/test-2"
+				destination.type = "test"
+			`,
+			gateways:     2,
+			destinations: 2,
+		},
+		{
+			descr: "gateway with duplicate inline destinations",
+			data: `
+				[[gateway]]
+				path = "/test"
+				destination = [{type = "test"}, {type = "test"}]
+			`,
+			err: errors.New("failed parsing gateway configuration: duplicate destination '/test/test-ec3ac967' in gateway configuration"),
+		},
+		{
+			descr: "gateway with unknown shared destination",
+			data: `
+				[[gateway]]
+				path = "/test"
+				destination = "shared"
+			`,
+			err: errors.New("failed parsing gateway configuration: unknown destination 'shared' referenced in gateway configuration"),
+		},
+		{
+			descr: "shared destination with unknown type",
+			data: `
+				[destination.shared]
+				type = "foo"
+			`,
+			err: errors.New("failed parsing configuration for destination 'shared': unknown destination type 'foo' given in configuration"),
+		},
+		{
+			descr: "gateway with shared silences",
+			data: `
+				[[silence]]
+				matchers = ['severity!="critical"']
+				action = "hold"
+				schedule = {time = "22:00-07:00", weekdays = ["mon-fri"], timezone = "UTC"}
+
+				[[silence]]
+				matchers = ['alertname="DiskFull"']
+				ends-at = 2030-01-01T00:00:00Z
+
+				[[gateway]]
+				path = "/test"
+				destination.type = "test"
+			`,
+			gateways:     1,
+			destinations: 1,
+		},
+		{
+			descr: "silence without schedule or end time",
+			data: `
+				[[silence]]
+				matchers = ['severity!="critical"']
+			`,
+			err: errors.New("failed parsing configuration for silence 1: silences require either a schedule or an end time"),
+		},
+		{
+			descr: "tracing without endpoint",
+			data: `
+				[tracing]
+				sample-rate = 0.5
+			`,
+			err: errors.New("failed parsing tracing configuration: empty or missing endpoint"),
+		},
+		{
+			descr: "tracing with invalid sample rate",
+			data: `
+				[tracing]
+				endpoint = "http://localhost:4318/v1/traces"
+				sample-rate = 2
+			`,
+			err: errors.New("failed parsing tracing configuration: invalid sample rate '2', expected value between 0 and 1"),
+		},
+		{
+			descr: "persisted history without state directory",
+			data: `
+				[history]
+				persist = true
+			`,
+			err: errors.New("failed parsing history configuration: persisting history requires a state directory"),
+		},
+		{
+			descr: "dashboard without admin token",
+			data: `
+				[admin]
+				dashboard = true
+			`,
+			err: errors.New("dashboard requires an admin token to be set"),
+		},
+		{
+			descr: "history with invalid size",
+			data: `
+				[history]
+				size = 0
+			`,
+			err: errors.New("failed parsing history configuration: invalid size '0', expected positive number"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			s, err := New()
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			var data map[string]any
+			if _, err := toml.Decode(tt.data, &data); err != nil {
+				t.Fatalf("toml.Decode(): want error 'nil', have '%s'", err)
+			}
+
+			err = s.UnmarshalTOML(data)
+			if (err != nil && tt.err == nil) || (err == nil && tt.err != nil) {
+				t.Fatalf("Service.UnmarshalTOML(): want error '%v', have '%v'", tt.err, err)
+			} else if err != nil && tt.err != nil && err.Error() != tt.err.Error() {
+				t.Fatalf("Service.UnmarshalTOML(): want error '%s', have '%s'", tt.err.Error(), err.Error())
+			} else if err == nil && len(s.gateway) != tt.gateways {
+				t.Fatalf("Service.UnmarshalTOML(): want %d gateways, have %d", tt.gateways, len(s.gateway))
+			} else if err == nil && len(s.destinations) != tt.destinations {
+				t.Fatalf("Service.UnmarshalTOML(): want %d destinations, have %d", tt.destinations, len(s.destinations))
+			}
+		})
+	}
+}
+
+func TestServiceReplay(t *testing.T) {
+	var dir = t.TempDir()
+	var letters = []*delivery.DeadLetter{
+		{Entry: delivery.Entry{ID: "1", Messages: []*gateway.Message{{Content: "Hello"}}}, Destination: "shared"},
+		{Entry: delivery.Entry{ID: "2", Messages: []*gateway.Message{{Content: "World"}}}, Destination: "shared"},
+		{Entry: delivery.Entry{ID: "3", Messages: []*gateway.Message{{Content: "!"}}}, Destination: "unknown"},
+	}
+
+	store := delivery.NewDeadLetters(filepath.Join(dir, "dead-letters.log"))
+	for _, l := range letters {
+		if err := store.Add(l); err != nil {
+			t.Fatalf("DeadLetters.Add(): want error 'nil', have '%s'", err)
+		}
+	}
+
+	s, err := New(WithStateDir(dir))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	var data = map[string]any{"destination": map[string]any{"shared": map[string]any{"type": "test"}}}
+	if err := s.UnmarshalTOML(data); err != nil {
+		t.Fatalf("Service.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	}
+
+	count, err := s.Replay(context.Background(), func(l *delivery.DeadLetter) bool { return l.ID != "2" })
+	if err == nil || err.Error() != "unknown destination 'unknown' for dead letter '3'" {
+		t.Fatalf("Service.Replay(): want error for unknown destination, have '%v'", err)
+	} else if count != 1 {
+		t.Fatalf("Service.Replay(): want 1 dead letter replayed, have %d", count)
+	}
+
+	d := s.destinations["shared"].(*delivery.Queue).Destination().(*testDestination)
+	if !reflect.DeepEqual(d.messages, letters[0].Messages) {
+		t.Fatalf("Service.Replay(): want messages '%#v', have '%#v'", letters[0].Messages, d.messages)
+	} else if !d.closed {
+		t.Fatalf("Service.Replay(): want destination closed, have destination open")
+	}
+
+	remaining, err := s.DeadLetters()
+	if err != nil {
+		t.Fatalf("Service.DeadLetters(): want error 'nil', have '%s'", err)
+	} else if !reflect.DeepEqual(remaining, letters[1:]) {
+		t.Fatalf("Service.DeadLetters(): want dead letters '%#v', have '%#v'", letters[1:], remaining)
+	}
+}
+
+func TestServiceClose(t *testing.T) {
+	var h = &testHandler{http.NewServeMux()}
+	s, err := New(WithHandler(h), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	var data = map[string]any{
+		"destination": map[string]any{"shared": map[string]any{"type": "test"}},
+		"gateway":     []map[string]any{{"path": "/test", "source": map[string]any{"type": "test"}, "destination": "shared"}},
+	}
+
+	if err := s.UnmarshalTOML(data); err != nil {
+		t.Fatalf("Service.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	} else if err := s.Init(context.Background()); err != nil {
+		t.Fatalf("Service.Init(): want error 'nil', have '%s'", err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/test", strings.NewReader("{}")))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST /test: want status '%d', have '%d'", http.StatusAccepted, w.Code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := s.Close(ctx); err != nil {
+		t.Fatalf("Service.Close(): want error 'nil', have '%s'", err)
+	} else if len(s.stop) != 0 {
+		t.Fatalf("Service.Close(): want all components stopped, have %d running", len(s.stop))
+	}
+
+	d := s.destinations["shared"].(*delivery.Queue).Destination().(*testDestination)
+	if len(d.messages) != 1 {
+		t.Fatalf("Service.Close(): want pending message delivered, have %d messages", len(d.messages))
+	} else if !d.closed {
+		t.Fatalf("Service.Close(): want destination closed, have destination open")
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/internal/testcert"
+
+	// Third-party packages.
+	"github.com/BurntSushi/toml"
+)
+
+// FreePort returns a TCP port number currently available for listening on.
+func freePort(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen(): want error 'nil', have '%s'", err)
+	}
+
+	defer ln.Close()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port
+}
+
+func TestServiceTLS(t *testing.T) {
+	var dir = t.TempDir()
+	var ca, other = testcert.New(t, "Test CA", nil), testcert.New(t, "Other CA", nil)
+	var client, untrusted = testcert.New(t, "client", ca), testcert.New(t, "client", other)
+
+	certFile, keyFile := testcert.New(t, "server", ca).Write(t, dir, "server")
+	caFile, _ := ca.Write(t, dir, "ca")
+
+	var testCases = []struct {
+		descr string
+		conf  string
+		path  string
+		cert  *testcert.Cert
+
+		status int // Zero if the TLS handshake is expected to fail.
+	}{
+		{
+			descr:  "HTTPS without client certificates",
+			path:   "/_health",
+			status: http.StatusOK,
+		},
+		{
+			descr:  "server-wide client CA with trusted certificate",
+			conf:   `client-ca = "` + caFile + `"`,
+			path:   "/_health",
+			cert:   client,
+			status: http.StatusOK,
+		},
+		{
+			descr: "server-wide client CA without certificate",
+			conf:  `client-ca = "` + caFile + `"`,
+			path:  "/_health",
+		},
+		{
+			descr: "server-wide client CA with untrusted certificate",
+			conf:  `client-ca = "` + caFile + `"`,
+			path:  "/_health",
+			cert:  untrusted,
+		},
+		{
+			descr:  "gateway client CA with trusted certificate",
+			path:   "/secure",
+			cert:   client,
+			status: http.StatusAccepted,
+		},
+		{
+			descr:  "gateway client CA without certificate",
+			path:   "/secure",
+			status: http.StatusForbidden,
+		},
+		{
+			descr:  "gateway client CA with untrusted certificate",
+			path:   "/secure",
+			cert:   untrusted,
+			status: http.StatusForbidden,
+		},
+		{
+			descr:  "gateway without client CA",
+			path:   "/open",
+			status: http.StatusAccepted,
+		},
+	}
+
+	var roots = x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			var port = freePort(t)
+			s, err := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+			if err != nil {
+				t.Fatalf("New(): want error 'nil', have '%s'", err)
+			}
+
+			var data map[string]any
+			_, err = toml.Decode(`
+				[http]
+				host = "127.0.0.1"
+				port = "`+port+`"
+				certificate = "`+certFile+`"
+				key = "`+keyFile+`"
+				`+tt.conf+`
+
+				[[gateway]]
+				path = "/secure"
+				client-ca = "`+caFile+`"
+				source.type = "test"
+				destination.type = "test"
+
+				[[gateway]]
+				path = "/open"
+				source.type = "test"
+				destination.type = "test"
+			`, &data)
+			if err != nil {
+				t.Fatalf("toml.Decode(): want error 'nil', have '%s'", err)
+			} else if err := s.UnmarshalTOML(data); err != nil {
+				t.Fatalf("Service.UnmarshalTOML(): want error 'nil', have '%s'", err)
+			} else if err := s.Init(context.Background()); err != nil {
+				t.Fatalf("Service.Init(): want error 'nil', have '%s'", err)
+			}
+
+			defer s.Close(context.Background())
+
+			var conf = &tls.Config{RootCAs: roots}
+			if tt.cert != nil {
+				conf.Certificates = []tls.Certificate{tt.cert.TLS()}
+			}
+
+			c := &http.Client{Transport: &http.Transport{TLSClientConfig: conf}, Timeout: time.Second}
+			resp, err := c.Post("https://127.0.0.1:"+port+tt.path, "application/json", strings.NewReader("{}"))
+			if tt.status == 0 {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("POST %s: want error for failed handshake, have status '%d'", tt.path, resp.StatusCode)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("POST %s: want error 'nil', have '%s'", tt.path, err)
+			}
+
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("POST %s: want status '%d', have '%d'", tt.path, tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	// Standard library.
+	"fmt"
+	"time"
+
+	// Internal packages.
+	"go.deuill.org/webhook-gateway/pkg/trace"
+)
+
+// Service name reported for spans exported, unless configured otherwise.
+const defaultServiceName = "webhook-gateway"
+
+// NewTracer returns a [trace.Tracer] for the tracing configuration given, exporting spans via OTLP
+// to the configured collector endpoint.
+func (s *Service) newTracer(conf map[string]any) (*trace.Tracer, error) {
+	endpoint, _ := conf["endpoint"].(string)
+	if endpoint == "" {
+		return nil, fmt.Errorf("empty or missing endpoint")
+	}
+
+	var name = defaultServiceName
+	if v, ok := conf["service-name"].(string); ok && v != "" {
+		name = v
+	}
+
+	var headers = make(map[string]string)
+	if v, ok := conf["headers"]; ok {
+		h, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid headers, expected table")
+		}
+		for k, v := range h {
+			if headers[k], ok = v.(string); !ok {
+				return nil, fmt.Errorf("invalid value '%v' for header '%s', expected string", v, k)
+			}
+		}
+	}
+
+	exp, err := trace.NewOTLPExporter(endpoint, name, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var options = []trace.Option{trace.WithLogger(s.logger)}
+	switch v := conf["sample-rate"].(type) {
+	case nil:
+	case float64:
+		options = append(options, trace.WithSampleRate(v))
+	case int64:
+		options = append(options, trace.WithSampleRate(float64(v)))
+	default:
+		return nil, fmt.Errorf("invalid sample rate '%v', expected number", v)
+	}
+
+	if v, ok := conf["interval"].(string); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing export interval: %w", err)
+		}
+		options = append(options, trace.WithInterval(d))
+	}
+
+	return trace.NewTracer(exp, options...)
+}
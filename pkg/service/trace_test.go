@@ -0,0 +1,107 @@
+package service
+
+import (
+	// Standard library.
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// A TestSpan represents a span as received by the collector stand-in below, keeping only the fields
+// relevant for tests.
+type testSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+}
+
+func TestServiceTracing(t *testing.T) {
+	var mu sync.Mutex
+	var spans []testSpan
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []testSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+
+	var h = &testHandler{http.NewServeMux()}
+	s, err := New(WithHandler(h), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatalf("New(): want error 'nil', have '%s'", err)
+	}
+
+	var data = map[string]any{
+		"tracing":     map[string]any{"endpoint": collector.URL + "/v1/traces", "sample-rate": int64(1)},
+		"destination": map[string]any{"shared": map[string]any{"type": "test"}},
+		"gateway":     []map[string]any{{"path": "/test", "source": map[string]any{"type": "test"}, "destination": "shared"}},
+	}
+
+	if err := s.UnmarshalTOML(data); err != nil {
+		t.Fatalf("Service.UnmarshalTOML(): want error 'nil', have '%s'", err)
+	} else if err := s.Init(context.Background()); err != nil {
+		t.Fatalf("Service.Init(): want error 'nil', have '%s'", err)
+	}
+
+	r := httptest.NewRequest("POST", "/test", strings.NewReader("{}"))
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST /test: want status '%d', have '%d'", http.StatusAccepted, w.Code)
+	} else if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Service.Close(): want error 'nil', have '%s'", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Spans are expected for the request itself, for parsing the request, and for pushing messages
+	// to and delivering messages from the delivery queue, all as part of the incoming trace.
+	var byName = make(map[string]testSpan)
+	for _, span := range spans {
+		if span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("Service.Close(): want spans for incoming trace, have '%+v'", span)
+		}
+		byName[span.Name] = span
+	}
+
+	for name, parent := range map[string]string{
+		"POST /test":     "00f067aa0ba902b7",
+		"parse /test":    byName["POST /test"].SpanID,
+		"push shared":    byName["POST /test"].SpanID,
+		"deliver shared": byName["push shared"].SpanID,
+	} {
+		if span, ok := byName[name]; !ok {
+			t.Fatalf("Service.Close(): want span '%s' exported, have '%+v'", name, spans)
+		} else if span.ParentSpanID != parent {
+			t.Fatalf("Service.Close(): want parent '%s' for span '%s', have '%s'", parent, name, span.ParentSpanID)
+		}
+	}
+}
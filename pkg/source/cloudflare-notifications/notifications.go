@@ -7,17 +7,33 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	// Internal packages.
 	"go.deuill.org/webhook-gateway/pkg/gateway"
 )
 
 // A Payload represents the full request payload for Cloudflare Notifications. By default,
-// notification payloads contain only a simple text field, with not much configurability.
+// notification payloads contain only a simple text field, with not much configurability, alongside
+// some metadata on the alert policy the notification was sent for.
 type Payload struct {
 	Text string `json:"text"`
+
+	Name       string `json:"name"`
+	Timestamp  int64  `json:"ts"`
+	AccountID  string `json:"account_id"`
+	PolicyID   string `json:"policy_id"`
+	PolicyName string `json:"policy_name"`
+	AlertType  string `json:"alert_type"`
+	AlertEvent string `json:"alert_event"`
 }
 
+// Alert events denoting the start and end of alerts, for alert types that support these.
+const (
+	alertEventStart = "ALERT_STATE_EVENT_START"
+	alertEventEnd   = "ALERT_STATE_EVENT_END"
+)
+
 // Grafana represents a message source for Cloudflare Notifications. For information on how incoming
 // requests are parsed, check the documentation for [Notifications.ParseHTTP].
 type Notifications struct{}
@@ -31,6 +47,9 @@ func New() (*Notifications, error) {
 //
 // Incoming requests will have the 'cf-webhook-auth' header checked for a correct token
 // corresponding secret configured at the gateway level.
+//
+// Messages carry the contents of the 'text' field, as well as any alert metadata found in the
+// payload, with policy and alert type information made available as labels.
 func (n *Notifications) ParseHTTP(r *http.Request) ([]*gateway.Message, error) {
 	// Validate secret in HTTP headers.
 	if secret := gateway.GetSecret(r.Context()); secret != "" {
@@ -56,11 +75,37 @@ func (n *Notifications) ParseHTTP(r *http.Request) ([]*gateway.Message, error) {
 
 	var msg gateway.Message
 	if payload.Text != "" {
-		msg.Content = payload.Text
+		msg.Content, msg.Body = payload.Text, payload.Text
 	} else {
 		return nil, fmt.Errorf("no message content found")
 	}
 
+	msg.Title, msg.Source = payload.Name, "cloudflare-notifications"
+	if payload.Timestamp > 0 {
+		msg.Timestamp = time.Unix(payload.Timestamp, 0).UTC()
+	}
+
+	switch payload.AlertEvent {
+	case alertEventStart:
+		msg.Status = gateway.StatusFiring
+	case alertEventEnd:
+		msg.Status = gateway.StatusResolved
+	}
+
+	for k, v := range map[string]string{
+		"account_id":  payload.AccountID,
+		"policy_id":   payload.PolicyID,
+		"policy_name": payload.PolicyName,
+		"alert_type":  payload.AlertType,
+	} {
+		if v == "" {
+			continue
+		} else if msg.Labels == nil {
+			msg.Labels = make(map[string]string)
+		}
+		msg.Labels[k] = v
+	}
+
 	return []*gateway.Message{&msg}, nil
 }
 
@@ -70,6 +115,11 @@ func (n *Notifications) Init(_ context.Context) error {
 	return nil
 }
 
+// Close releases any resources held by the Cloudflare [Notifications] source.
+func (n *Notifications) Close(_ context.Context) error {
+	return nil
+}
+
 // Register Grafana source for gateway configuration.
 func init() {
 	initfn := func() gateway.Source { return &Notifications{} }
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	// Internal packages.
 	"go.deuill.org/webhook-gateway/pkg/gateway"
@@ -84,7 +85,35 @@ func TestNotificationsParseTemplate(t *testing.T) {
 			descr:   "message from content",
 			source:  &Notifications{},
 			request: httptest.NewRequest("POST", "/test", strings.NewReader(`{"text": "Hello World"}`)),
-			expect:  []*gateway.Message{{Content: "Hello World"}},
+			expect: []*gateway.Message{{
+				Content: "Hello World",
+				Body:    "Hello World",
+				Source:  "cloudflare-notifications",
+			}},
+		},
+		{
+			descr:  "message with alert metadata",
+			source: &Notifications{},
+			request: httptest.NewRequest("POST", "/test", strings.NewReader(`{
+				"name": "Origin Health",
+				"text": "Origin is unreachable",
+				"ts": 1714557600,
+				"policy_name": "Origin Monitoring",
+				"alert_type": "health_check_status_notification",
+				"alert_event": "ALERT_STATE_EVENT_START"
+			}`)),
+			expect: []*gateway.Message{{
+				Content: "Origin is unreachable",
+				Title:   "Origin Health",
+				Body:    "Origin is unreachable",
+				Status:  gateway.StatusFiring,
+				Labels: map[string]string{
+					"policy_name": "Origin Monitoring",
+					"alert_type":  "health_check_status_notification",
+				},
+				Source:    "cloudflare-notifications",
+				Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			}},
 		},
 	}
 
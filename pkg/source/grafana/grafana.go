@@ -3,6 +3,7 @@ package grafana
 import (
 	// Standard library.
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"strings"
 	"text/template"
+	"time"
 
 	// Internal packages.
 	"go.deuill.org/webhook-gateway/pkg/gateway"
@@ -41,11 +43,13 @@ type Alert struct {
 	Labels      map[string]string `json:"labels"`
 	Annotations map[string]string `json:"annotations"`
 	Values      map[string]string `json:"values"`
-	ValueString string `json:"valueString"`
+	ValueString string            `json:"valueString"`
+	Fingerprint string            `json:"fingerprint"`
 
 	StartsAt string `json:"startsAt"`
 	EndsAt   string `json:"endsAt"`
 
+	GeneratorURL string `json:"generatorURL"`
 	SilenceURL   string `json:"silenceURL"`
 	DashboardURL string `json:"dashboardURL"`
 	PanelURL     string `json:"panelURL"`
@@ -96,7 +100,12 @@ func WithTemplate(t string) Option {
 // By default, notifications will be collected into a single [gateway.Message], using the title and
 // content found in the payload itself; however, if a custom template has been configured, this will
 // be used instead. If neither custom template nor payload-provided content is found, this function
-// will return an error.
+// will return an error. Payloads containing more than one alert are split into a message for each
+// alert, so that each message refers to a single alert, as identified by its fingerprint; see
+// [Payload.split] for more.
+//
+// Messages also carry the status, common labels, and links found in the payload, with severity
+// determined by the 'severity' label, if any, and the timestamp by the earliest alert start time.
 func (g *Grafana) ParseHTTP(r *http.Request) ([]*gateway.Message, error) {
 	// Validate secret in HTTP headers.
 	if secret := gateway.GetSecret(r.Context()); secret != "" {
@@ -120,7 +129,40 @@ func (g *Grafana) ParseHTTP(r *http.Request) ([]*gateway.Message, error) {
 		return nil, fmt.Errorf("failed parsing request: %w", err)
 	}
 
-	var msg gateway.Message
+	var payloads = []Payload{payload}
+	if len(payload.Alerts) > 1 {
+		payloads = payload.split()
+	}
+
+	var messages []*gateway.Message
+	for _, p := range payloads {
+		msg, err := g.message(p)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// Message returns a [gateway.Message] for the payload given, with content rendered from the
+// configured template, if any, or taken from the payload title and message otherwise.
+func (g *Grafana) message(payload Payload) (*gateway.Message, error) {
+	var msg = gateway.Message{
+		Title:       payload.Title,
+		Body:        payload.Message,
+		Status:      gateway.Status(payload.Status),
+		Labels:      payload.CommonLabels,
+		Links:       payload.links(),
+		Source:      "grafana",
+		Fingerprint: payload.fingerprint(),
+		Timestamp:   payload.timestamp(),
+	}
+
+	if msg.Labels != nil {
+		msg.Severity = msg.Labels["severity"]
+	}
 
 	// Prefer configured template over Grafana-provided default, if available.
 	if g.template != nil {
@@ -129,6 +171,7 @@ func (g *Grafana) ParseHTTP(r *http.Request) ([]*gateway.Message, error) {
 			return nil, err
 		}
 		msg.Content = strings.TrimSpace(buf.String())
+		msg.Body = msg.Content
 	} else if payload.Message != "" {
 		if payload.Title != "" {
 			msg.Content = payload.Title + "\n"
@@ -138,7 +181,89 @@ func (g *Grafana) ParseHTTP(r *http.Request) ([]*gateway.Message, error) {
 		return nil, fmt.Errorf("no message content found")
 	}
 
-	return []*gateway.Message{&msg}, nil
+	return &msg, nil
+}
+
+// Split returns a [Payload] for each alert in the payload, with status, labels, and annotations
+// taken from the alert itself, where set. As titles and messages set in payloads refer to all alerts
+// in the payload, these are replaced with a title formed from the alert status and summary (or name,
+// where no summary is set), and a message formed from the alert description, or its values where no
+// description is set.
+func (p Payload) split() []Payload {
+	var result = make([]Payload, 0, len(p.Alerts))
+	for _, a := range p.Alerts {
+		v := Payload{
+			Status:            cmp.Or(a.Status, p.Status),
+			Alerts:            []Alert{a},
+			ExternalURL:       p.ExternalURL,
+			GroupLabels:       p.GroupLabels,
+			CommonLabels:      a.Labels,
+			CommonAnnotations: a.Annotations,
+			State:             p.State,
+		}
+
+		if v.CommonLabels == nil {
+			v.CommonLabels = p.CommonLabels
+		}
+		if v.CommonAnnotations == nil {
+			v.CommonAnnotations = p.CommonAnnotations
+		}
+
+		var summary = cmp.Or(v.CommonAnnotations["summary"], v.CommonLabels["alertname"])
+		v.Title = strings.TrimSpace("[" + strings.ToUpper(v.Status) + "] " + summary)
+		v.Message = cmp.Or(v.CommonAnnotations["description"], a.ValueString, summary)
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// Links returns unique links to dashboards, panels, silences, and alert rules referred to by alerts
+// in the payload, in that order.
+func (p Payload) links() []gateway.Link {
+	var links []gateway.Link
+	var seen = make(map[string]bool)
+
+	add := func(title, url string) {
+		if url != "" && !seen[url] {
+			links, seen[url] = append(links, gateway.Link{Title: title, URL: url}), true
+		}
+	}
+
+	for _, a := range p.Alerts {
+		add("Dashboard", a.DashboardURL)
+		add("Panel", a.PanelURL)
+		add("Silence", a.SilenceURL)
+		add("Source", a.GeneratorURL)
+	}
+
+	return links
+}
+
+// Fingerprint returns a stable identifier for the alert in the payload, as provided by Grafana.
+// Payloads not containing exactly one alert have no fingerprint, as these are split into a payload
+// for each alert; see [Payload.split].
+func (p Payload) fingerprint() string {
+	if len(p.Alerts) != 1 {
+		return ""
+	}
+	return p.Alerts[0].Fingerprint
+}
+
+// Timestamp returns the earliest start time for alerts in the payload, or the zero time if no valid
+// start times were found.
+func (p Payload) timestamp() time.Time {
+	var ts time.Time
+	for _, a := range p.Alerts {
+		t, err := time.Parse(time.RFC3339, a.StartsAt)
+		if err != nil || t.IsZero() {
+			continue
+		} else if ts.IsZero() || t.Before(ts) {
+			ts = t
+		}
+	}
+
+	return ts
 }
 
 // Init ensures the [Grafana] source is configured correctly, and initializes any sub-resources
@@ -147,6 +272,11 @@ func (g *Grafana) Init(_ context.Context) error {
 	return nil
 }
 
+// Close releases any resources held by the [Grafana] source.
+func (g *Grafana) Close(_ context.Context) error {
+	return nil
+}
+
 // UnmarshalTOML configures the [Grafana] source based on values sourced from TOML configuration.
 func (g *Grafana) UnmarshalTOML(data any) error {
 	conf, ok := data.(map[string]any)
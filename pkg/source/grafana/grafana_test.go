@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 	"text/template"
+	"time"
 
 	// Internal packages.
 	"go.deuill.org/webhook-gateway/pkg/gateway"
@@ -146,13 +147,109 @@ func TestGrafanaParseTemplate(t *testing.T) {
 				return tpl
 			}()},
 			request: httptest.NewRequest("POST", "/test", strings.NewReader(`{"status": "firing"}`)),
-			expect:  []*gateway.Message{{Content: "Alert! Alert! firing"}},
+			expect: []*gateway.Message{{
+				Content: "Alert! Alert! firing",
+				Body:    "Alert! Alert! firing",
+				Status:  gateway.StatusFiring,
+				Source:  "grafana",
+			}},
 		},
 		{
 			descr:   "message from content",
 			source:  &Grafana{},
 			request: httptest.NewRequest("POST", "/test", strings.NewReader(`{"title": "Hello", "message": "World"}`)),
-			expect:  []*gateway.Message{{Content: "Hello\nWorld"}},
+			expect: []*gateway.Message{{
+				Content: "Hello\nWorld",
+				Title:   "Hello",
+				Body:    "World",
+				Source:  "grafana",
+			}},
+		},
+		{
+			descr:  "message with alert metadata",
+			source: &Grafana{},
+			request: httptest.NewRequest("POST", "/test", strings.NewReader(`{
+				"status": "resolved",
+				"title": "[RESOLVED] High CPU",
+				"message": "CPU usage is back to normal",
+				"commonLabels": {"alertname": "HighCPU", "severity": "critical"},
+				"alerts": [
+					{
+						"fingerprint": "a1",
+						"startsAt": "2024-05-01T10:00:00Z",
+						"dashboardURL": "https://grafana.example.com/d/1",
+						"silenceURL": "https://grafana.example.com/silence/1",
+						"generatorURL": "https://grafana.example.com/alerting/1"
+					}
+				]
+			}`)),
+			expect: []*gateway.Message{{
+				Content:  "[RESOLVED] High CPU\nCPU usage is back to normal",
+				Title:    "[RESOLVED] High CPU",
+				Body:     "CPU usage is back to normal",
+				Status:   gateway.StatusResolved,
+				Severity: "critical",
+				Labels:   map[string]string{"alertname": "HighCPU", "severity": "critical"},
+				Links: []gateway.Link{
+					{Title: "Dashboard", URL: "https://grafana.example.com/d/1"},
+					{Title: "Silence", URL: "https://grafana.example.com/silence/1"},
+					{Title: "Source", URL: "https://grafana.example.com/alerting/1"},
+				},
+				Source:      "grafana",
+				Fingerprint: "a1",
+				Timestamp:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			}},
+		},
+		{
+			descr:  "messages split by alert",
+			source: &Grafana{},
+			request: httptest.NewRequest("POST", "/test", strings.NewReader(`{
+				"status": "firing",
+				"title": "[FIRING:1, RESOLVED:1] High CPU",
+				"message": "Multiple alerts",
+				"commonLabels": {"alertname": "HighCPU"},
+				"alerts": [
+					{
+						"status": "firing",
+						"fingerprint": "a1",
+						"labels": {"alertname": "HighCPU", "host": "one", "severity": "critical"},
+						"annotations": {"summary": "High CPU on one", "description": "CPU usage is at 95%"},
+						"startsAt": "2024-05-01T10:00:00Z"
+					},
+					{
+						"status": "resolved",
+						"fingerprint": "b2",
+						"labels": {"alertname": "HighCPU", "host": "two"},
+						"valueString": "[ var='A' value=12 ]",
+						"startsAt": "2024-05-01T09:30:00Z",
+						"generatorURL": "https://grafana.example.com/alerting/1"
+					}
+				]
+			}`)),
+			expect: []*gateway.Message{
+				{
+					Content:     "[FIRING] High CPU on one\nCPU usage is at 95%",
+					Title:       "[FIRING] High CPU on one",
+					Body:        "CPU usage is at 95%",
+					Status:      gateway.StatusFiring,
+					Severity:    "critical",
+					Labels:      map[string]string{"alertname": "HighCPU", "host": "one", "severity": "critical"},
+					Source:      "grafana",
+					Fingerprint: "a1",
+					Timestamp:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				},
+				{
+					Content:     "[RESOLVED] HighCPU\n[ var='A' value=12 ]",
+					Title:       "[RESOLVED] HighCPU",
+					Body:        "[ var='A' value=12 ]",
+					Status:      gateway.StatusResolved,
+					Labels:      map[string]string{"alertname": "HighCPU", "host": "two"},
+					Links:       []gateway.Link{{Title: "Source", URL: "https://grafana.example.com/alerting/1"}},
+					Source:      "grafana",
+					Fingerprint: "b2",
+					Timestamp:   time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+				},
+			},
 		},
 	}
 
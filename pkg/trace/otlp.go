@@ -0,0 +1,196 @@
+package trace
+
+import (
+	// Standard library.
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Name of instrumentation scope reported for all spans exported.
+const scopeName = "go.deuill.org/webhook-gateway"
+
+// An OTLPExporter is an [Exporter] sending spans to OpenTelemetry collectors, using OTLP over HTTP
+// with JSON encoding.
+type OTLPExporter struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	client      *http.Client
+}
+
+// NewOTLPExporter returns an [OTLPExporter] for the collector endpoint given, typically ending in
+// '/v1/traces'. Spans are reported against the service name given, and requests carry any headers
+// given, e.g. for authentication.
+func NewOTLPExporter(endpoint, serviceName string, headers map[string]string) (*OTLPExporter, error) {
+	if u, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid endpoint '%s': %w", endpoint, err)
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint '%s', expected HTTP or HTTPS URL", endpoint)
+	}
+
+	return &OTLPExporter{
+		endpoint:    endpoint,
+		headers:     headers,
+		serviceName: serviceName,
+		client:      &http.Client{},
+	}, nil
+}
+
+// Export sends the spans given to the collector in a single request.
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	var scope = otlpScopeSpans{Scope: otlpScope{Name: scopeName}}
+	for _, s := range spans {
+		scope.Spans = append(scope.Spans, newOTLPSpan(s))
+	}
+
+	body, err := json.Marshal(otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   otlpResource{Attributes: []otlpAttribute{newOTLPAttribute("service.name", e.serviceName)}},
+			ScopeSpans: []otlpScopeSpans{scope},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status '%s' from collector: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Types used for encoding requests to collectors, as defined in the OTLP protocol buffers and as
+// mapped to JSON. Trace and span IDs are encoded as hexadecimal strings, and 64-bit integers as
+// decimal strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              Kind            `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// Status code for failed spans, as defined in OTLP. Successful spans are left with an unset status.
+const otlpStatusError = 2
+
+// NewOTLPSpan returns the given [SpanData] in a form suitable for encoding in OTLP requests.
+func newOTLPSpan(s SpanData) otlpSpan {
+	var span = otlpSpan{
+		TraceID:           s.Context.TraceID.String(),
+		SpanID:            s.Context.SpanID.String(),
+		Name:              s.Name,
+		Kind:              s.Kind,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+	}
+
+	if s.Parent != (SpanID{}) {
+		span.ParentSpanID = s.Parent.String()
+	}
+	if s.Err != nil {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: s.Err.Error()}
+	}
+	for _, a := range s.Attributes {
+		span.Attributes = append(span.Attributes, newOTLPAttribute(a.Key, a.Value))
+	}
+
+	return span
+}
+
+// NewOTLPAttribute returns an attribute for the key and value given, in a form suitable for
+// encoding in OTLP requests.
+func newOTLPAttribute(key string, value any) otlpAttribute {
+	var v otlpValue
+	switch value := value.(type) {
+	case string:
+		v.StringValue = &value
+	case bool:
+		v.BoolValue = &value
+	case int:
+		s := strconv.Itoa(value)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		v.IntValue = &s
+	case uint64:
+		s := strconv.FormatUint(value, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &value
+	default:
+		s := fmt.Sprint(value)
+		v.StringValue = &s
+	}
+
+	return otlpAttribute{Key: key, Value: v}
+}
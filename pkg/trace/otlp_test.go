@@ -0,0 +1,118 @@
+package trace
+
+import (
+	// Standard library.
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// A TestCollector is a stand-in for an OpenTelemetry collector, recording all requests made to it.
+type testCollector struct {
+	mu       sync.Mutex
+	requests []otlpRequest
+	headers  []http.Header
+	status   int
+}
+
+func (c *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var req otlpRequest
+	if r.Method != "POST" || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if c.status != 0 {
+		http.Error(w, "collector unavailable", c.status)
+		return
+	}
+
+	c.requests, c.headers = append(c.requests, req), append(c.headers, r.Header)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var collector = &testCollector{}
+	var srv = httptest.NewServer(collector)
+	defer srv.Close()
+
+	exp, err := NewOTLPExporter(srv.URL+"/v1/traces", "test-service", map[string]string{"Authorization": "Bearer secret"})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter(): want error 'nil', have '%s'", err)
+	}
+
+	tracer, err := NewTracer(exp)
+	if err != nil {
+		t.Fatalf("NewTracer(): want error 'nil', have '%s'", err)
+	} else if err := tracer.Init(context.Background()); err != nil {
+		t.Fatalf("Tracer.Init(): want error 'nil', have '%s'", err)
+	}
+
+	SetDefault(tracer)
+	defer SetDefault(nil)
+
+	ctx, parent := Start(context.Background(), "POST /test", KindServer)
+	parent.SetAttribute("http.request.method", "POST")
+	parent.SetAttribute("http.response.status_code", 202)
+
+	_, child := Start(ctx, "push test", KindProducer)
+	child.SetError(errors.New("delivery queue is full"))
+	child.End()
+	parent.End()
+	parent.End()
+
+	if err := tracer.Close(context.Background()); err != nil {
+		t.Fatalf("Tracer.Close(): want error 'nil', have '%s'", err)
+	}
+
+	collector.mu.Lock()
+	requests, headers := collector.requests, collector.headers
+	collector.status = http.StatusServiceUnavailable
+	collector.mu.Unlock()
+
+	if len(requests) != 1 {
+		t.Fatalf("Tracer.Close(): want 1 request made to collector, have %d", len(requests))
+	} else if have := headers[0].Get("Authorization"); have != "Bearer secret" {
+		t.Fatalf("OTLPExporter.Export(): want authorization header 'Bearer secret', have '%s'", have)
+	}
+
+	var rs = requests[0].ResourceSpans
+	if len(rs) != 1 || len(rs[0].ScopeSpans) != 1 {
+		t.Fatalf("OTLPExporter.Export(): want single resource and scope, have '%+v'", rs)
+	} else if attr := rs[0].Resource.Attributes; len(attr) != 1 || *attr[0].Value.StringValue != "test-service" {
+		t.Fatalf("OTLPExporter.Export(): want service name 'test-service', have '%+v'", attr)
+	}
+
+	var spans = rs[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("OTLPExporter.Export(): want 2 spans, have %d", len(spans))
+	}
+
+	var c, p = spans[0], spans[1]
+	if c.Name != "push test" || c.Kind != KindProducer || c.Status.Code != otlpStatusError || c.Status.Message != "delivery queue is full" {
+		t.Fatalf("OTLPExporter.Export(): want failed producer span for child, have '%+v'", c)
+	} else if c.TraceID != p.TraceID || c.ParentSpanID != p.SpanID {
+		t.Fatalf("OTLPExporter.Export(): want child of span '%s', have parent '%s'", p.SpanID, c.ParentSpanID)
+	} else if p.Name != "POST /test" || p.Kind != KindServer || p.ParentSpanID != "" || p.Status.Code != 0 {
+		t.Fatalf("OTLPExporter.Export(): want successful root server span for parent, have '%+v'", p)
+	} else if p.TraceID != parent.Context().TraceID.String() || p.StartTimeUnixNano == "" || p.EndTimeUnixNano == "" {
+		t.Fatalf("OTLPExporter.Export(): want trace ID and timestamps for parent, have '%+v'", p)
+	} else if len(p.Attributes) != 2 || *p.Attributes[1].Value.IntValue != "202" {
+		t.Fatalf("OTLPExporter.Export(): want integer attribute for status code, have '%+v'", p.Attributes)
+	}
+
+	// Failed exports are reported as errors.
+	err = exp.Export(context.Background(), []SpanData{{Name: "test"}})
+	if want := "unexpected status '503 Service Unavailable' from collector: collector unavailable"; err == nil || err.Error() != want {
+		t.Fatalf("OTLPExporter.Export(): want error '%s', have '%v'", want, err)
+	}
+}
@@ -0,0 +1,297 @@
+// Package trace contains types used for tracing requests and messages as they pass through
+// gateways and destinations, as propagated via W3C Trace Context headers, and as exported to
+// OpenTelemetry collectors via OTLP.
+package trace
+
+import (
+	// Standard library.
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A TraceID uniquely identifies a trace, i.e. a set of spans sharing a common root.
+type TraceID [16]byte
+
+// String returns the [TraceID] as a lower-case hexadecimal string.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// A SpanID uniquely identifies a span within a trace.
+type SpanID [8]byte
+
+// String returns the [SpanID] as a lower-case hexadecimal string.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// A SpanContext represents the identity of a span, as propagated across process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool // Whether the span is recorded and exported.
+}
+
+// IsValid returns whether the [SpanContext] has non-zero trace and span IDs.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// TraceParent returns the [SpanContext] in the format used for W3C 'traceparent' headers, or an
+// empty string if the span context is not valid.
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+
+	var flags = "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceParent returns a [SpanContext] for the W3C 'traceparent' header value given. Values
+// for future versions of the format are accepted, as long as they are prefixed with fields known
+// to the current version, as described in the specification.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, fmt.Errorf("invalid format for trace parent '%s'", s)
+	} else if version, err := parseHex(s[0:2]); err != nil || version[0] == 0xff {
+		return sc, fmt.Errorf("invalid version for trace parent '%s'", s)
+	} else if (version[0] == 0 && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return sc, fmt.Errorf("invalid format for trace parent '%s'", s)
+	}
+
+	traceID, err := parseHex(s[3:35])
+	if err != nil {
+		return sc, fmt.Errorf("invalid trace ID for trace parent '%s'", s)
+	}
+	spanID, err := parseHex(s[36:52])
+	if err != nil {
+		return sc, fmt.Errorf("invalid span ID for trace parent '%s'", s)
+	}
+	flags, err := parseHex(s[53:55])
+	if err != nil {
+		return sc, fmt.Errorf("invalid flags for trace parent '%s'", s)
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Sampled = flags[0]&0x01 != 0
+
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid all-zero identifiers for trace parent '%s'", s)
+	}
+
+	return sc, nil
+}
+
+// ParseHex decodes the lower-case hexadecimal string given, as required for 'traceparent' values.
+func parseHex(s string) ([]byte, error) {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return nil, fmt.Errorf("invalid character '%c' in hexadecimal string", c)
+		}
+	}
+	return hex.DecodeString(s)
+}
+
+// A Kind describes the relationship between a span and its parent or children, with values
+// matching those used in OTLP.
+type Kind int
+
+// Kinds of spans, as reported to collectors.
+const (
+	KindInternal Kind = iota + 1 // An operation internal to the process.
+	KindServer                   // The handling of a remote request, e.g. an incoming HTTP request.
+	KindClient                   // A request made to a remote service.
+	KindProducer                 // The submission of work for asynchronous processing.
+	KindConsumer                 // The asynchronous processing of work submitted previously.
+)
+
+// An Attribute represents a key-value pair attached to a span. Values are expected to be strings,
+// booleans, integers, or floating-point numbers; any other values are reported as strings.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// A Span represents a single operation within a trace, as started via [Start]. Spans are recorded
+// once ended, and are exported by the [Tracer] they were started with, if sampled.
+type Span struct {
+	tracer  *Tracer
+	context SpanContext
+	parent  SpanID
+	name    string
+	kind    Kind
+	start   time.Time
+
+	mu         sync.Mutex
+	attributes []Attribute
+	err        error
+	ended      bool
+}
+
+// Context returns the [SpanContext] for the [Span].
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+// SetAttribute attaches the key and value given to the [Span], replacing any existing value for
+// the key.
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.attributes {
+		if s.attributes[i].Key == key {
+			s.attributes[i].Value = value
+			return
+		}
+	}
+
+	s.attributes = append(s.attributes, Attribute{Key: key, Value: value})
+}
+
+// SetError marks the [Span] as failed with the error given, if not nil.
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End completes the [Span], recording it for export if sampled. Calling End more than once has no
+// further effect.
+func (s *Span) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.ended = true
+	if s.tracer == nil || !s.context.Sampled {
+		return
+	}
+
+	s.tracer.record(SpanData{
+		Name:       s.name,
+		Kind:       s.kind,
+		Context:    s.context,
+		Parent:     s.parent,
+		Start:      s.start,
+		End:        time.Now(),
+		Attributes: slices.Clone(s.attributes),
+		Err:        s.err,
+	})
+}
+
+// SpanData represents a completed [Span], as passed to an [Exporter].
+type SpanData struct {
+	Name       string
+	Kind       Kind
+	Context    SpanContext
+	Parent     SpanID // The ID of the parent span, if any.
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Err        error // The error the span failed with, if any.
+}
+
+// Start begins a new [Span] of the kind given, as a child of the span stored in the given
+// [context.Context], if any, and returns the span along with a context storing it. Spans without a
+// parent start a new trace, which is sampled according to the default [Tracer], if any.
+//
+// Spans are always assigned valid identifiers, even where no default tracer is set, allowing for
+// these to be propagated and used for correlating logs regardless of whether tracing is enabled.
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	var tracer = Default()
+	var s = &Span{tracer: tracer, name: name, kind: kind, start: time.Now()}
+
+	if parent := SpanFromContext(ctx); parent != nil {
+		s.context.TraceID, s.context.Sampled = parent.context.TraceID, parent.context.Sampled
+		s.parent = parent.context.SpanID
+	} else {
+		s.context.TraceID = newTraceID()
+		s.context.Sampled = tracer != nil && tracer.sample(s.context.TraceID)
+	}
+
+	s.context.SpanID = newSpanID()
+	return context.WithValue(ctx, spanKey, s), s
+}
+
+// NewTraceID returns a random, non-zero [TraceID].
+func newTraceID() TraceID {
+	var id TraceID
+	for id == (TraceID{}) {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+// NewSpanID returns a random, non-zero [SpanID].
+func newSpanID() SpanID {
+	var id SpanID
+	for id == (SpanID{}) {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+// ContextKey is a unique type for values stored in contexts.
+type contextKey int
+
+// SpanKey is a context key used for storing the current [Span].
+const spanKey contextKey = iota
+
+// SpanFromContext returns the [Span] stored in the given [context.Context], if any. Spans for remote
+// parents, as stored via [ContextWithRemote], are never recorded.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey).(*Span)
+	return s
+}
+
+// ContextWithRemote returns the given [context.Context] with the [SpanContext] given stored as the
+// parent for spans started in the context, e.g. where propagated from remote callers. Invalid span
+// contexts are ignored.
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey, &Span{context: sc, ended: true})
+}
+
+// SpanContextFromContext returns the [SpanContext] for the [Span] stored in the given
+// [context.Context], if any, or a zero-value span context otherwise.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.context
+	}
+	return SpanContext{}
+}
+
+// Extract returns the given [context.Context] with the remote parent described in the W3C
+// 'traceparent' header given stored, as per [ContextWithRemote]. Missing or invalid headers are
+// ignored.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	if sc, err := ParseTraceParent(h.Get("Traceparent")); err == nil {
+		return ContextWithRemote(ctx, sc)
+	}
+	return ctx
+}
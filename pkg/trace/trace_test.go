@@ -0,0 +1,151 @@
+package trace
+
+import (
+	// Standard library.
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	var testCases = []struct {
+		descr string
+		value string
+
+		traceID string
+		spanID  string
+		sampled bool
+		err     error
+	}{
+		{
+			descr:   "sampled",
+			value:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  "00f067aa0ba902b7",
+			sampled: true,
+		},
+		{
+			descr:   "not sampled",
+			value:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  "00f067aa0ba902b7",
+		},
+		{
+			descr:   "future version with additional fields",
+			value:   "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-holds",
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  "00f067aa0ba902b7",
+			sampled: true,
+		},
+		{
+			descr: "additional fields for current version",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			err:   errors.New("invalid format for trace parent '00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra'"),
+		},
+		{
+			descr: "invalid version",
+			value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			err:   errors.New("invalid version for trace parent 'ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01'"),
+		},
+		{
+			descr: "upper-case trace ID",
+			value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+			err:   errors.New("invalid trace ID for trace parent '00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01'"),
+		},
+		{
+			descr: "all-zero span ID",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			err:   errors.New("invalid all-zero identifiers for trace parent '00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01'"),
+		},
+		{
+			descr: "empty value",
+			err:   errors.New("invalid format for trace parent ''"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.descr, func(t *testing.T) {
+			sc, err := ParseTraceParent(tt.value)
+			if tt.err != nil {
+				if err == nil || err.Error() != tt.err.Error() {
+					t.Fatalf("ParseTraceParent(): want error '%s', have '%v'", tt.err, err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("ParseTraceParent(): want error 'nil', have '%s'", err)
+			}
+
+			if sc.TraceID.String() != tt.traceID {
+				t.Fatalf("ParseTraceParent(): want trace ID '%s', have '%s'", tt.traceID, sc.TraceID)
+			} else if sc.SpanID.String() != tt.spanID {
+				t.Fatalf("ParseTraceParent(): want span ID '%s', have '%s'", tt.spanID, sc.SpanID)
+			} else if sc.Sampled != tt.sampled {
+				t.Fatalf("ParseTraceParent(): want sampled '%v', have '%v'", tt.sampled, sc.Sampled)
+			}
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	var h = http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}
+
+	// Spans continue traces from remote parents, and are propagated to children.
+	ctx, parent := Start(Extract(context.Background(), h), "parent", KindServer)
+	if have := parent.Context().TraceID.String(); have != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("Start(): want trace ID from remote parent, have '%s'", have)
+	} else if have := parent.parent.String(); have != "00f067aa0ba902b7" {
+		t.Fatalf("Start(): want parent span ID from remote parent, have '%s'", have)
+	} else if !parent.Context().Sampled {
+		t.Fatalf("Start(): want sampled decision from remote parent, have none")
+	}
+
+	_, child := Start(ctx, "child", KindInternal)
+	if child.Context().TraceID != parent.Context().TraceID || child.parent != parent.Context().SpanID {
+		t.Fatalf("Start(): want child of span '%s', have parent '%s'", parent.Context().SpanID, child.parent)
+	} else if have := SpanContextFromContext(ctx).TraceParent(); have != parent.Context().TraceParent() {
+		t.Fatalf("SpanContextFromContext(): want '%s', have '%s'", parent.Context().TraceParent(), have)
+	}
+
+	// New traces are assigned valid identifiers, but are not sampled without a default tracer.
+	_, root := Start(context.Background(), "root", KindServer)
+	if !root.Context().IsValid() || root.Context().Sampled {
+		t.Fatalf("Start(): want valid, unsampled span context, have '%+v'", root.Context())
+	} else if root.Context().TraceID == parent.Context().TraceID {
+		t.Fatalf("Start(): want new trace ID, have '%s'", root.Context().TraceID)
+	}
+}
+
+func TestTracerSample(t *testing.T) {
+	var testCases = []struct {
+		rate float64
+		min  int
+		max  int
+	}{
+		{rate: 0, min: 0, max: 0},
+		{rate: 0.5, min: 400, max: 600},
+		{rate: 1, min: 1000, max: 1000},
+	}
+
+	for _, tt := range testCases {
+		tracer, err := NewTracer(nil, WithSampleRate(tt.rate))
+		if err != nil {
+			t.Fatalf("NewTracer(): want error 'nil', have '%s'", err)
+		}
+
+		var n int
+		for range 1000 {
+			if tracer.sample(newTraceID()) {
+				n++
+			}
+		}
+
+		if n < tt.min || n > tt.max {
+			t.Fatalf("Tracer.sample(): want between %d and %d traces sampled at rate %g, have %d", tt.min, tt.max, tt.rate, n)
+		}
+	}
+
+	if _, err := NewTracer(nil, WithSampleRate(1.5)); err == nil {
+		t.Fatalf("NewTracer(): want error for invalid sample rate, have 'nil'")
+	}
+}
@@ -0,0 +1,218 @@
+package trace
+
+import (
+	// Standard library.
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default options for [Tracer] instances.
+const (
+	defaultInterval   = 5 * time.Second
+	defaultBatchSize  = 512
+	defaultBufferSize = 2048
+	defaultTimeout    = 10 * time.Second
+)
+
+// An Exporter represents any type capable of sending completed spans to a remote collector.
+type Exporter interface {
+	Export(context.Context, []SpanData) error
+}
+
+// A Tracer collects completed spans, as started via [Start], and exports these in batches in the
+// background. Spans completed while the buffer of pending spans is full are dropped.
+type Tracer struct {
+	// Configuration options.
+	exporter   Exporter
+	sampleRate float64
+	interval   time.Duration
+
+	// Internal fields.
+	mu      sync.Mutex
+	spans   []SpanData
+	notify  chan struct{}
+	stop    context.CancelFunc // Stops background processing, as started on [Tracer.Init].
+	done    chan struct{}      // Closed once background processing has stopped.
+	dropped atomic.Uint64
+	logger  *slog.Logger
+}
+
+// NewTracer instantiates a [Tracer] exporting spans via the [Exporter] given, with the options
+// given. All new traces are sampled by default.
+func NewTracer(exp Exporter, options ...Option) (*Tracer, error) {
+	var t = Tracer{
+		exporter:   exp,
+		sampleRate: 1,
+		interval:   defaultInterval,
+		notify:     make(chan struct{}, 1),
+		logger:     slog.Default(),
+	}
+
+	for _, fn := range options {
+		if err := fn(&t); err != nil {
+			return nil, err
+		}
+	}
+
+	return &t, nil
+}
+
+// A Option represents any configuration provided to new instances of [Tracer] types.
+type Option func(*Tracer) error
+
+// WithSampleRate sets the ratio of new traces sampled, between 0 and 1. Spans continuing traces
+// from remote parents follow the sampling decision made by the parent instead.
+func WithSampleRate(rate float64) Option {
+	return func(t *Tracer) error {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("invalid sample rate '%g', expected value between 0 and 1", rate)
+		}
+		t.sampleRate = rate
+		return nil
+	}
+}
+
+// WithInterval sets the maximum amount of time spans are held before being exported.
+func WithInterval(d time.Duration) Option {
+	return func(t *Tracer) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid export interval '%s'", d)
+		}
+		t.interval = d
+		return nil
+	}
+}
+
+// WithLogger sets the given [slog.Logger] as the log handler for the [Tracer].
+func WithLogger(l *slog.Logger) Option {
+	return func(t *Tracer) error {
+		t.logger = l
+		return nil
+	}
+}
+
+// Init starts exporting spans in the background, until the given [context.Context] is cancelled,
+// or until the [Tracer] is closed.
+func (t *Tracer) Init(ctx context.Context) error {
+	t.mu.Lock()
+	ctx, t.stop = context.WithCancel(ctx)
+	t.done = make(chan struct{})
+	t.mu.Unlock()
+
+	go func(done chan struct{}) {
+		defer close(done)
+		t.run(ctx)
+	}(t.done)
+
+	return nil
+}
+
+// Close stops background processing for the [Tracer], and exports any spans left pending, returning
+// once done, or once the given [context.Context] is cancelled.
+func (t *Tracer) Close(ctx context.Context) error {
+	t.mu.Lock()
+	stop, done := t.stop, t.done
+	t.mu.Unlock()
+
+	if stop != nil {
+		stop()
+		<-done
+	}
+
+	if n := t.dropped.Swap(0); n > 0 {
+		t.logger.Warn("Dropped trace spans exceeding buffer size", "count", n)
+	}
+
+	return t.flush(ctx)
+}
+
+// Run exports pending spans at the configured interval, or as soon as a full batch of spans is
+// pending, until the given context is cancelled.
+func (t *Tracer) run(ctx context.Context) {
+	var ticker = time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-t.notify:
+		}
+
+		flushCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+		if err := t.flush(flushCtx); err != nil {
+			t.logger.Warn("Failed exporting trace spans", "error", err.Error())
+		}
+		cancel()
+	}
+}
+
+// Flush exports all spans currently pending, in batches.
+func (t *Tracer) flush(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+
+	for len(spans) > 0 {
+		n := min(len(spans), defaultBatchSize)
+		if err := t.exporter.Export(ctx, spans[:n]); err != nil {
+			return fmt.Errorf("failed exporting %d spans: %w", len(spans), err)
+		}
+		spans = spans[n:]
+	}
+
+	return nil
+}
+
+// Record adds the given span to the list of spans pending export, dropping it if the buffer is
+// full.
+func (t *Tracer) record(s SpanData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.spans) >= defaultBufferSize {
+		t.dropped.Add(1)
+		return
+	}
+
+	t.spans = append(t.spans, s)
+	if len(t.spans) >= defaultBatchSize {
+		select {
+		case t.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Sample returns whether a new trace with the ID given is to be sampled. Decisions are derived
+// from the trace ID, and are thus consistent for any given trace.
+func (t *Tracer) sample(id TraceID) bool {
+	if t.sampleRate >= 1 {
+		return true
+	} else if t.sampleRate <= 0 {
+		return false
+	}
+
+	return binary.BigEndian.Uint64(id[8:])>>1 < uint64(t.sampleRate*(1<<63))
+}
+
+// The default tracer, as used for starting new spans.
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault sets the [Tracer] used for recording spans started via [Start]. Setting a nil tracer
+// disables recording of spans.
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Default returns the default [Tracer], as set via [SetDefault], if any.
+func Default() *Tracer {
+	return defaultTracer.Load()
+}